@@ -1,19 +1,32 @@
 package main
 
 import (
+	"context"
+	"time"
+
+	keycloakauth "github.com/JorgeSaicoski/keycloak-auth"
 	"github.com/JorgeSaicoski/microservice-commons/config"
 	"github.com/JorgeSaicoski/microservice-commons/database"
+	"github.com/JorgeSaicoski/microservice-commons/responses"
 	"github.com/JorgeSaicoski/microservice-commons/server"
 	"github.com/JorgeSaicoski/microservice-commons/utils"
+	authapi "github.com/JorgeSaicoski/professional-tracker/internal/api"
+	"github.com/JorgeSaicoski/professional-tracker/internal/api/invoices"
 	"github.com/JorgeSaicoski/professional-tracker/internal/api/projects"
 	"github.com/JorgeSaicoski/professional-tracker/internal/api/sessions"
 	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
 	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/events"
+	invoicesService "github.com/JorgeSaicoski/professional-tracker/internal/services/invoices"
 	projectsService "github.com/JorgeSaicoski/professional-tracker/internal/services/projects"
 	sessionsService "github.com/JorgeSaicoski/professional-tracker/internal/services/sessions"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/totals"
 	"github.com/gin-gonic/gin"
 )
 
+// MetricsRole is the realm role allowed to read the service metrics
+const MetricsRole = "metrics-reader"
+
 func main() {
 	server := server.NewServer(server.ServerOptions{
 		ServiceName:    "professional-tracker",
@@ -32,25 +45,100 @@ func setupRoutes(router *gin.Engine, cfg *config.Config) {
 
 	coreURL := utils.GetEnv("PROJECT_CORE_URL", "http://localhost:8000/api/internal")
 
-	coreClient := clients.NewCoreProjectHTTPClient(coreURL)
+	// Per-attempt timeouts, retries of reads and a circuit breaker keep a
+	// slow or failing project-core from hanging every request
+	coreOptions := clients.DefaultHTTPClientOptions()
+	coreOptions.Timeout = time.Duration(utils.GetEnvInt("PROJECT_CORE_TIMEOUT_MS", 5000)) * time.Millisecond
+	coreOptions.MaxRetries = utils.GetEnvInt("PROJECT_CORE_MAX_RETRIES", coreOptions.MaxRetries)
+	coreOptions.RetryBaseDelay = time.Duration(utils.GetEnvInt("PROJECT_CORE_RETRY_BASE_DELAY_MS", 100)) * time.Millisecond
+	coreOptions.BreakerThreshold = utils.GetEnvInt("PROJECT_CORE_BREAKER_THRESHOLD", coreOptions.BreakerThreshold)
+	coreOptions.BreakerCooldown = time.Duration(utils.GetEnvInt("PROJECT_CORE_BREAKER_COOLDOWN_SECONDS", 30)) * time.Second
+	if coreOptions.CallTimeouts, err = clients.ParseCallTimeouts(utils.GetEnv("PROJECT_CORE_CALL_TIMEOUTS", "")); err != nil {
+		panic("Invalid PROJECT_CORE_CALL_TIMEOUTS: " + err.Error())
+	}
+
+	// Cache project and membership lookups; PROJECT_CORE_CACHE_TTL_SECONDS=0 turns it off
+	coreClient := clients.NewCoreProjectHTTPClientWithOptions(coreURL, coreOptions)
+	var coreCache *clients.CachingCoreProjectClient
+	if ttl := utils.GetEnvInt("PROJECT_CORE_CACHE_TTL_SECONDS", 30); ttl > 0 {
+		coreCache = clients.NewCachingCoreProjectClient(coreClient, clients.CacheOptions{
+			TTL:         time.Duration(ttl) * time.Second,
+			NegativeTTL: time.Duration(utils.GetEnvInt("PROJECT_CORE_CACHE_NEGATIVE_TTL_SECONDS", 10)) * time.Second,
+			MaxEntries:  utils.GetEnvInt("PROJECT_CORE_CACHE_MAX_ENTRIES", 10000),
+		})
+		coreClient = coreCache
+	}
+
+	// Convert float money columns to cents before auto-migration sees them
+	if err := db.MigrateMoneyToMinorUnits(dbConnection.DB); err != nil {
+		panic("Failed to migrate money columns: " + err.Error())
+	}
+	// Deduct breaks from sessions finished before net time was stored
+	if err := db.MigrateSessionBreakdown(dbConnection.DB); err != nil {
+		panic("Failed to migrate session breakdown: " + err.Error())
+	}
+
+	// Totals per currency are new: once created, build them from the sessions
+	backfillCurrencyTotals := !dbConnection.DB.Migrator().HasTable(&db.ProjectCurrencyTotals{})
 
 	// Auto-migrate models
 	if err := database.QuickMigrate(dbConnection,
 		&db.ProfessionalProject{},
 		&db.ProjectAssignment{},
+		&db.ProjectCurrencyTotals{},
+		&db.AssignmentRate{},
+		&db.ClientRate{},
+		&db.CompanySettings{},
+		&db.DocumentTemplate{},
+		&db.ExchangeRate{},
 		&db.TimeSession{},
 		&db.SessionBreak{},
 		&db.UserActiveSession{},
+		&db.PeriodLock{},
+		&db.CalendarFeed{},
+		&db.ImportRule{},
+		&db.DraftSession{},
+		&db.ImportBatch{},
+		&db.ImportMapping{},
+		&db.ImportedEntry{},
+		&db.Invoice{},
+		&db.InvoiceLine{},
+		&db.InvoiceSequence{},
 	); err != nil {
 		panic("Failed to migrate database: " + err.Error())
 	}
+	if backfillCurrencyTotals {
+		if err := totals.RecalculateAll(dbConnection.DB); err != nil {
+			panic("Failed to build currency totals: " + err.Error())
+		}
+	}
 
 	// Initialize services
 	projectService := projectsService.NewProfessionalProjectService(dbConnection, coreClient)
-	sessionService := sessionsService.NewTimeSessionService(dbConnection)
+	// In-process broker: session events reach only clients connected to this
+	// instance. Swap in a shared-bus events.Broker when running several replicas.
+	sessionService := sessionsService.NewTimeSessionService(dbConnection, coreClient, events.NewMemoryBroker())
+	invoiceService := invoicesService.NewInvoiceService(dbConnection, coreClient)
+
+	// Idle detection is off unless SESSION_IDLE_THRESHOLD_MINUTES is set, since
+	// clients that never send heartbeats would otherwise get their sessions closed
+	go sessionService.RunIdleSweeper(context.Background(), sessionsService.IdleConfig{
+		Threshold:  time.Duration(utils.GetEnvInt("SESSION_IDLE_THRESHOLD_MINUTES", 0)) * time.Minute,
+		Mode:       utils.GetEnv("SESSION_IDLE_MODE", sessionsService.IdleModeClose),
+		CloseAfter: time.Duration(utils.GetEnvInt("SESSION_IDLE_CLOSE_AFTER_MINUTES", 0)) * time.Minute,
+		Interval:   time.Duration(utils.GetEnvInt("SESSION_IDLE_SWEEP_INTERVAL_SECONDS", 60)) * time.Second,
+	})
 
 	// Setup routes
+	if coreCache != nil {
+		metrics := router.Group("/metrics", authapi.AuthMiddleware(), keycloakauth.RequireRole(MetricsRole))
+		metrics.GET("/core-cache", func(c *gin.Context) {
+			responses.Success(c, "Core cache statistics", coreCache.Stats())
+		})
+	}
+
 	api := router.Group("")
 	projects.RegisterRoutes(api, projectService)
 	sessions.RegisterRoutes(api, sessionService)
+	invoices.RegisterRoutes(api, invoiceService)
 }
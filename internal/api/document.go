@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/documents"
+	"github.com/gin-gonic/gin"
+)
+
+// DocumentTemplateRequest sets the branding and locale of rendered documents.
+// Logo is base64 or a data URL ("data:image/png;base64,..."); omitting it
+// removes the stored logo.
+type DocumentTemplateRequest struct {
+	DisplayName   string   `json:"displayName"`
+	Address       []string `json:"address"`
+	Locale        string   `json:"locale"`
+	Timezone      string   `json:"timezone"`
+	Logo          string   `json:"logo"`
+	LogoMime      string   `json:"logoMime"`
+	Footer        string   `json:"footer"`
+	InvoiceHTML   *string  `json:"invoiceHtml"`
+	ReportHTML    *string  `json:"reportHtml"`
+	TimesheetHTML *string  `json:"timesheetHtml"`
+}
+
+// DocumentTemplateResponse echoes a stored template without the logo bytes
+type DocumentTemplateResponse struct {
+	Scope         string    `json:"scope"`
+	DisplayName   string    `json:"displayName"`
+	Address       []string  `json:"address"`
+	Locale        string    `json:"locale"`
+	Timezone      string    `json:"timezone"`
+	HasLogo       bool      `json:"hasLogo"`
+	LogoMime      string    `json:"logoMime,omitempty"`
+	Footer        string    `json:"footer"`
+	InvoiceHTML   *string   `json:"invoiceHtml,omitempty"`
+	ReportHTML    *string   `json:"reportHtml,omitempty"`
+	TimesheetHTML *string   `json:"timesheetHtml,omitempty"`
+	UpdatedBy     string    `json:"updatedBy"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// ToModel decodes the logo and builds the template to store
+func (r *DocumentTemplateRequest) ToModel() (*db.DocumentTemplate, error) {
+	tpl := &db.DocumentTemplate{
+		DisplayName:   strings.TrimSpace(r.DisplayName),
+		Address:       strings.Join(r.Address, "\n"),
+		Locale:        r.Locale,
+		Timezone:      r.Timezone,
+		LogoMime:      r.LogoMime,
+		Footer:        r.Footer,
+		InvoiceHTML:   r.InvoiceHTML,
+		ReportHTML:    r.ReportHTML,
+		TimesheetHTML: r.TimesheetHTML,
+	}
+	if r.Logo == "" {
+		return tpl, nil
+	}
+
+	data := r.Logo
+	if rest, ok := strings.CutPrefix(data, "data:"); ok {
+		header, payload, found := strings.Cut(rest, ",")
+		mime, isBase64 := strings.CutSuffix(header, ";base64")
+		if !found || !isBase64 {
+			return nil, fmt.Errorf("logo data URL must be base64 encoded")
+		}
+		tpl.LogoMime, data = mime, payload
+	}
+	logo, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("logo is not valid base64")
+	}
+	tpl.Logo = logo
+	return tpl, nil
+}
+
+// DocumentTemplateToResponse converts a stored template for the API
+func DocumentTemplateToResponse(tpl *db.DocumentTemplate) *DocumentTemplateResponse {
+	address := []string{}
+	if tpl.Address != "" {
+		address = strings.Split(tpl.Address, "\n")
+	}
+	return &DocumentTemplateResponse{
+		Scope:         tpl.Scope,
+		DisplayName:   tpl.DisplayName,
+		Address:       address,
+		Locale:        tpl.Locale,
+		Timezone:      tpl.Timezone,
+		HasLogo:       len(tpl.Logo) > 0,
+		LogoMime:      tpl.LogoMime,
+		Footer:        tpl.Footer,
+		InvoiceHTML:   tpl.InvoiceHTML,
+		ReportHTML:    tpl.ReportHTML,
+		TimesheetHTML: tpl.TimesheetHTML,
+		UpdatedBy:     tpl.UpdatedBy,
+		UpdatedAt:     tpl.UpdatedAt,
+	}
+}
+
+// DocumentFormat reads ?format=html|pdf, defaulting to PDF
+func DocumentFormat(c *gin.Context) string {
+	return strings.ToLower(c.DefaultQuery("format", documents.FormatPDF))
+}
+
+// RespondDocument sends a rendered document. PDFs download as name.pdf;
+// HTML is shown inline.
+func RespondDocument(c *gin.Context, format, name string, body []byte) {
+	contentType := "text/html; charset=utf-8"
+	disposition := "inline"
+	if format == documents.FormatPDF {
+		contentType = "application/pdf"
+		disposition = "attachment"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, name+"."+format))
+	c.Data(http.StatusOK, contentType, body)
+}
@@ -0,0 +1,37 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/JorgeSaicoski/microservice-commons/responses"
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// kindStatus maps each apperr.Kind to its HTTP status
+var kindStatus = map[apperr.Kind]int{
+	apperr.KindNotFound:    http.StatusNotFound,
+	apperr.KindConflict:    http.StatusConflict,
+	apperr.KindForbidden:   http.StatusForbidden,
+	apperr.KindValidation:  http.StatusBadRequest,
+	apperr.KindUnavailable: http.StatusServiceUnavailable,
+}
+
+// RespondError writes the error response for an error returned by a service.
+// Classified errors get the status of their kind and their own code; a bare
+// gorm.ErrRecordNotFound is a 404, and anything else a 500.
+func RespondError(c *gin.Context, err error) {
+	if appErr, ok := apperr.From(err); ok {
+		if status, known := kindStatus[appErr.Kind]; known {
+			responses.Error(c, status, appErr.Code, err.Error())
+			return
+		}
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		responses.NotFound(c, err.Error())
+		return
+	}
+	responses.InternalError(c, err.Error())
+}
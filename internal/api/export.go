@@ -0,0 +1,122 @@
+package api
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/export"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportRequest holds the query of an export endpoint:
+// ?format=csv|xlsx&columns=a,b&timezone=&dateFormat=&startDate=&endDate=
+type ExportRequest struct {
+	Format    string
+	Columns   []string
+	Options   export.Options
+	StartDate *time.Time // inclusive, midnight in the export's time zone
+	EndDate   *time.Time // exclusive: the day after ?endDate=
+}
+
+// ParseExportRequest reads the export query. Dates are days in the requested
+// time zone and both are inclusive.
+func ParseExportRequest(c *gin.Context) (*ExportRequest, error) {
+	req := &ExportRequest{Format: strings.ToLower(c.DefaultQuery("format", export.FormatCSV))}
+	if err := export.CheckFormat(req.Format); err != nil {
+		return nil, err
+	}
+	if columns := c.Query("columns"); columns != "" {
+		req.Columns = strings.Split(columns, ",")
+	}
+
+	opts, err := export.ParseOptions(c.Query("timezone"), c.Query("dateFormat"))
+	if err != nil {
+		return nil, err
+	}
+	req.Options = opts
+
+	if param := c.Query("startDate"); param != "" {
+		start, err := time.ParseInLocation("2006-01-02", param, opts.Location)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid start date format (use YYYY-MM-DD)", export.ErrInvalidExport)
+		}
+		req.StartDate = &start
+	}
+	if param := c.Query("endDate"); param != "" {
+		end, err := time.ParseInLocation("2006-01-02", param, opts.Location)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid end date format (use YYYY-MM-DD)", export.ErrInvalidExport)
+		}
+		end = end.AddDate(0, 0, 1)
+		req.EndDate = &end
+	}
+	return req, nil
+}
+
+// Export streams rows to the client as a file download. Nothing is written
+// until the first row (or Finish), so a service error raised before that,
+// such as a denied project, still gets a regular JSON error response.
+type Export struct {
+	c       *gin.Context
+	format  string
+	name    string
+	header  []string
+	writer  export.Writer
+	started bool
+}
+
+// NewExport prepares a download of name.<format> with the given header row
+func NewExport(c *gin.Context, format, name string, header []string) *Export {
+	return &Export{c: c, format: format, name: name, header: header}
+}
+
+// Row writes one row, starting the download first if needed
+func (e *Export) Row(cells []export.Cell) error {
+	if !e.started {
+		if err := e.start(); err != nil {
+			return err
+		}
+	}
+	return e.writer.Row(cells)
+}
+
+// Finish completes the file, or reports err. Once rows went out the status
+// can't change anymore, so a late error only truncates the file and is logged.
+func (e *Export) Finish(err error) {
+	if err != nil {
+		if !e.started {
+			RespondError(e.c, err)
+			return
+		}
+		slog.Default().Error("export:stream-failed", "path", e.c.FullPath(), "err", err)
+		_ = e.c.Error(err)
+		return
+	}
+	if !e.started {
+		if err := e.start(); err != nil {
+			RespondError(e.c, err)
+			return
+		}
+	}
+	if err := e.writer.Close(); err != nil {
+		slog.Default().Error("export:close-failed", "path", e.c.FullPath(), "err", err)
+		_ = e.c.Error(err)
+	}
+}
+
+func (e *Export) start() error {
+	e.c.Header("Content-Type", export.ContentType(e.format))
+	e.c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.name+"."+e.format))
+	e.c.Status(http.StatusOK)
+
+	writer, err := export.NewWriter(e.c.Writer, e.format, e.name)
+	if err != nil {
+		return err
+	}
+	e.writer = writer
+	e.started = true
+	return e.writer.Header(e.header)
+}
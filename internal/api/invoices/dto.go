@@ -0,0 +1,56 @@
+package invoices
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/invoices"
+)
+
+// Request DTOs
+
+type CreateInvoiceRequest struct {
+	ProjectID       *uint   `json:"projectId"`                    // Bill one project...
+	ClientName      string  `json:"clientName"`                   // ...or every project of a client
+	CompanyID       string  `json:"companyId"`                    // With clientName; empty for personal projects
+	StartDate       string  `json:"startDate" binding:"required"` // YYYY-MM-DD, inclusive
+	EndDate         string  `json:"endDate" binding:"required"`   // YYYY-MM-DD, inclusive
+	RoundingMinutes int     `json:"roundingMinutes"`              // e.g. 6 or 15; 0 bills exact minutes
+	TaxLabel        string  `json:"taxLabel"`                     // e.g. "VAT"
+	TaxRate         string  `json:"taxRate"`                      // Percent with up to two decimals, e.g. "21.5"
+	Notes           *string `json:"notes"`
+}
+
+// Conversion methods
+
+func (r *CreateInvoiceRequest) ToInput() (*invoices.CreateInvoiceInput, error) {
+	from, err := time.Parse("2006-01-02", r.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid startDate format (use YYYY-MM-DD)")
+	}
+	to, err := time.Parse("2006-01-02", r.EndDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endDate format (use YYYY-MM-DD)")
+	}
+
+	// A percent with two decimals is a whole number of basis points.
+	var taxRate money.Amount
+	if r.TaxRate != "" {
+		if taxRate, err = money.Parse(r.TaxRate); err != nil {
+			return nil, fmt.Errorf("invalid taxRate: use a percent with up to two decimals")
+		}
+	}
+
+	return &invoices.CreateInvoiceInput{
+		ProjectID:          r.ProjectID,
+		ClientName:         r.ClientName,
+		CompanyID:          r.CompanyID,
+		From:               from,
+		To:                 to.AddDate(0, 0, 1),
+		RoundingMinutes:    r.RoundingMinutes,
+		TaxLabel:           r.TaxLabel,
+		TaxRateBasisPoints: int(taxRate),
+		Notes:              r.Notes,
+	}, nil
+}
@@ -0,0 +1,151 @@
+package invoices
+
+import (
+	"strconv"
+
+	keycloakauth "github.com/JorgeSaicoski/keycloak-auth"
+	"github.com/JorgeSaicoski/microservice-commons/responses"
+	"github.com/JorgeSaicoski/professional-tracker/internal/api"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/invoices"
+	"github.com/gin-gonic/gin"
+)
+
+/* ------------------------------------------------------------------ */
+/*  Handler definition                                                */
+/* ------------------------------------------------------------------ */
+
+type InvoiceHandler struct {
+	invoiceService *invoices.InvoiceService
+}
+
+func NewInvoiceHandler(svc *invoices.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{invoiceService: svc}
+}
+
+/* ------------------------- Invoices ------------------------------- */
+
+func (h *InvoiceHandler) CreateInvoice(c *gin.Context) {
+	var req CreateInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	input, err := req.ToInput()
+	if err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	invoice, err := h.invoiceService.CreateInvoiceCtx(c.Request.Context(), input, userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Created(c, "Invoice created successfully", invoice)
+}
+
+func (h *InvoiceHandler) ListInvoices(c *gin.Context) {
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var projectID *uint
+	if param := c.Query("projectId"); param != "" {
+		id, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			responses.BadRequest(c, "Invalid project ID")
+			return
+		}
+		pid := uint(id)
+		projectID = &pid
+	}
+
+	list, err := h.invoiceService.ListInvoicesCtx(c.Request.Context(), userID, projectID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Invoices retrieved successfully", gin.H{
+		"invoices": list,
+		"total":    len(list),
+	})
+}
+
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid invoice ID")
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	invoice, err := h.invoiceService.GetInvoiceCtx(c.Request.Context(), uint(id), userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Invoice retrieved successfully", invoice)
+}
+
+// GetInvoiceDocument renders an invoice, ?format=pdf|html
+func (h *InvoiceHandler) GetInvoiceDocument(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid invoice ID")
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	format := api.DocumentFormat(c)
+	body, err := h.invoiceService.GetInvoiceDocumentCtx(c.Request.Context(), uint(id), userID, format)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	api.RespondDocument(c, format, "invoice-"+c.Param("id"), body)
+}
+
+func (h *InvoiceHandler) VoidInvoice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid invoice ID")
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	invoice, err := h.invoiceService.VoidInvoiceCtx(c.Request.Context(), uint(id), userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Invoice voided successfully", invoice)
+}
@@ -0,0 +1,26 @@
+package invoices
+
+import (
+	"github.com/JorgeSaicoski/microservice-commons/middleware"
+	"github.com/JorgeSaicoski/professional-tracker/internal/api"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/invoices"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes registers all invoice related routes
+func RegisterRoutes(router *gin.RouterGroup, invoiceService *invoices.InvoiceService) {
+	handler := NewInvoiceHandler(invoiceService)
+
+	invoicesGroup := router.Group("/invoices")
+	invoicesGroup.Use(
+		middleware.DefaultLoggingMiddleware(),
+		api.AuthMiddleware(),
+	)
+	{
+		invoicesGroup.POST("", handler.CreateInvoice)                  // Bill unbilled sessions of a project or client
+		invoicesGroup.GET("", handler.ListInvoices)                    // Invoices of ?projectId=, else issued by the caller
+		invoicesGroup.GET("/:id", handler.GetInvoice)                  // Invoice with its lines
+		invoicesGroup.GET("/:id/document", handler.GetInvoiceDocument) // Invoice as PDF or HTML
+		invoicesGroup.POST("/:id/void", handler.VoidInvoice)           // Cancel and release the sessions
+	}
+}
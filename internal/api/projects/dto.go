@@ -1,9 +1,11 @@
 package projects
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
 	svc "github.com/JorgeSaicoski/professional-tracker/internal/services/projects"
 )
 
@@ -13,23 +15,53 @@ import (
 type CreateProfessionalProjectRequest struct {
 	Title      string  `json:"title" binding:"required"`
 	ClientName *string `json:"clientName,omitempty"`
+	Currency   string  `json:"currency,omitempty"` // ISO 4217; empty uses the company default
 }
 
 type UpdateProfessionalProjectRequest struct {
-	ClientName *string `json:"clientName"`
-	IsActive   *bool   `json:"isActive"`
+	ClientName   *string       `json:"clientName"`
+	BillableRate *money.Amount `json:"billableRate"` // Default hourly rate billed to the client
+	Currency     string        `json:"currency"`     // Of the project's billable rate; empty keeps it
+	IsActive     *bool         `json:"isActive"`
 }
 
 type CreateProjectAssignmentRequest struct {
-	WorkerUserID string  `json:"workerUserId" binding:"required"`
-	CostPerHour  float64 `json:"costPerHour" binding:"required"`
-	Description  *string `json:"description"`
+	WorkerUserID string        `json:"workerUserId" binding:"required"`
+	CostPerHour  money.Amount  `json:"costPerHour" binding:"required"`
+	BillableRate *money.Amount `json:"billableRate"` // Overrides the project's billable rate
+	Currency     string        `json:"currency"`     // ISO 4217, defaults to the project's currency
+	Description  *string       `json:"description"`
 }
 
 type UpdateProjectAssignmentRequest struct {
-	CostPerHour float64 `json:"costPerHour"`
-	Description *string `json:"description"`
-	IsActive    *bool   `json:"isActive"`
+	CostPerHour  money.Amount  `json:"costPerHour"` // A new rate takes effect now; use /rates to schedule one
+	BillableRate *money.Amount `json:"billableRate"`
+	Currency     string        `json:"currency"`
+	Description  *string       `json:"description"`
+	IsActive     *bool         `json:"isActive"`
+}
+
+type SetAssignmentRateRequest struct {
+	CostPerHour   money.Amount  `json:"costPerHour" binding:"gte=0"`
+	BillableRate  *money.Amount `json:"billableRate"`  // Keeps the current billable rate when omitted
+	Currency      string        `json:"currency"`      // Keeps the current currency when empty
+	EffectiveFrom *time.Time    `json:"effectiveFrom"` // Defaults to now
+}
+
+type SetClientRateRequest struct {
+	BillableRate money.Amount `json:"billableRate" binding:"gte=0"`
+	Currency     string       `json:"currency"` // Defaults to the project's currency
+}
+
+type SetCompanyCurrencyRequest struct {
+	Currency string `json:"currency" binding:"required"`
+}
+
+type SetExchangeRateRequest struct {
+	BaseCurrency  string      `json:"baseCurrency" binding:"required"`
+	QuoteCurrency string      `json:"quoteCurrency" binding:"required"`
+	Rate          json.Number `json:"rate" binding:"required"`     // Quote units per base unit; send a string to keep every digit
+	RateDate      string      `json:"rateDate" binding:"required"` // YYYY-MM-DD
 }
 
 // Response DTOs
@@ -39,8 +71,15 @@ type ProfessionalProjectResponse struct {
 	Title              string                      `json:"title"`
 	BaseProjectID      string                      `json:"baseProjectId"`
 	ClientName         *string                     `json:"clientName"`
-	TotalSalaryCost    float64                     `json:"totalSalaryCost"`
-	TotalHours         float64                     `json:"totalHours"`
+	TotalSalaryCost    money.Amount                `json:"totalSalaryCost"`
+	TotalHours         float64                     `json:"totalHours"` // net billable
+	TotalGrossHours    float64                     `json:"totalGrossHours"`
+	TotalBreakHours    float64                     `json:"totalBreakHours"`
+	BillableRate       *money.Amount               `json:"billableRate"`
+	TotalRevenue       money.Amount                `json:"totalRevenue"`
+	TotalBillableHours float64                     `json:"totalBillableHours"`
+	TotalsCurrency     string                      `json:"totalsCurrency"` // of TotalSalaryCost and TotalRevenue; empty when sessions are priced in several currencies
+	Currency           string                      `json:"currency"`       // empty uses the company default
 	IsActive           bool                        `json:"isActive"`
 	CreatedAt          time.Time                   `json:"createdAt"`
 	UpdatedAt          time.Time                   `json:"updatedAt"`
@@ -49,44 +88,59 @@ type ProfessionalProjectResponse struct {
 }
 
 type ProjectAssignmentResponse struct {
-	ID              uint      `json:"id"`
-	ParentProjectID uint      `json:"parentProjectId"`
-	WorkerUserID    string    `json:"workerUserId"`
-	CostPerHour     float64   `json:"costPerHour"`
-	HoursDedicated  float64   `json:"hoursDedicated"`
-	TotalCost       float64   `json:"totalCost"`
-	Description     *string   `json:"description"`
-	IsActive        bool      `json:"isActive"`
-	CreatedAt       time.Time `json:"createdAt"`
-	UpdatedAt       time.Time `json:"updatedAt"`
+	ID              uint          `json:"id"`
+	ParentProjectID uint          `json:"parentProjectId"`
+	WorkerUserID    string        `json:"workerUserId"`
+	CostPerHour     money.Amount  `json:"costPerHour"`
+	BillableRate    *money.Amount `json:"billableRate"`
+	Currency        string        `json:"currency"`
+	HoursDedicated  float64       `json:"hoursDedicated"`
+	TotalCost       money.Amount  `json:"totalCost"`
+	TotalRevenue    money.Amount  `json:"totalRevenue"`
+	TotalsCurrency  string        `json:"totalsCurrency"` // of TotalCost and TotalRevenue; empty when sessions are priced in several currencies
+	Description     *string       `json:"description"`
+	IsActive        bool          `json:"isActive"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	UpdatedAt       time.Time     `json:"updatedAt"`
 }
 
 type TimeSessionResponse struct {
-	ID                  uint       `json:"id"`
-	ProjectID           uint       `json:"projectId"`
-	ProjectAssignmentID *uint      `json:"projectAssignmentId"`
-	UserID              string     `json:"userId"`
-	CompanyID           string     `json:"companyId"`
-	StartTime           time.Time  `json:"startTime"`
-	EndTime             *time.Time `json:"endTime"`
-	SessionType         string     `json:"sessionType"`
-	DurationMinutes     int        `json:"durationMinutes"`
-	HourlyRate          *float64   `json:"hourlyRate"`
-	SessionCost         float64    `json:"sessionCost"`
-	Notes               *string    `json:"notes"`
-	IsActive            bool       `json:"isActive"`
-	CreatedAt           time.Time  `json:"createdAt"`
-	UpdatedAt           time.Time  `json:"updatedAt"`
+	ID                  uint          `json:"id"`
+	ProjectID           uint          `json:"projectId"`
+	ProjectAssignmentID *uint         `json:"projectAssignmentId"`
+	UserID              string        `json:"userId"`
+	CompanyID           string        `json:"companyId"`
+	StartTime           time.Time     `json:"startTime"`
+	EndTime             *time.Time    `json:"endTime"`
+	SessionType         string        `json:"sessionType"`
+	DurationMinutes     int           `json:"durationMinutes"` // gross, breaks included
+	BreakMinutes        int           `json:"breakMinutes"`
+	NetMinutes          int           `json:"netMinutes"` // billable
+	HourlyRate          *money.Amount `json:"hourlyRate"`
+	RateSource          string        `json:"rateSource"`
+	SessionCost         money.Amount  `json:"sessionCost"`
+	Currency            string        `json:"currency"`
+	BillableRate        *money.Amount `json:"billableRate"`
+	Billable            bool          `json:"billable"`
+	SessionRevenue      money.Amount  `json:"sessionRevenue"`
+	BillableCurrency    string        `json:"billableCurrency"`
+	Notes               *string       `json:"notes"`
+	IsActive            bool          `json:"isActive"`
+	IsManualEntry       bool          `json:"isManualEntry"`
+	EndReason           *string       `json:"endReason"`
+	InvoiceID           *uint         `json:"invoiceId"`
+	CreatedAt           time.Time     `json:"createdAt"`
+	UpdatedAt           time.Time     `json:"updatedAt"`
 }
 
 // ProfessionalAssignmentDTO is the minimal payload the frontend needs.
 type ProfessionalAssignmentDTO struct {
-	ID              uint    `json:"id"`
-	ParentProjectID uint    `json:"parentProjectId"`
-	WorkerUserID    string  `json:"workerUserId"`
-	HoursDedicated  float64 `json:"hoursDedicated"`
-	CostPerHour     float64 `json:"costPerHour"`
-	IsActive        bool    `json:"isActive"`
+	ID              uint         `json:"id"`
+	ParentProjectID uint         `json:"parentProjectId"`
+	WorkerUserID    string       `json:"workerUserId"`
+	HoursDedicated  float64      `json:"hoursDedicated"`
+	CostPerHour     money.Amount `json:"costPerHour"`
+	IsActive        bool         `json:"isActive"`
 }
 
 func NewProfessionalAssignmentDTO(a db.ProjectAssignment) *ProfessionalAssignmentDTO {
@@ -110,7 +164,9 @@ func (r *CreateProfessionalProjectRequest) ToProfessionalProject() *db.Professio
 
 func (r *UpdateProfessionalProjectRequest) ToProfessionalProject() *db.ProfessionalProject {
 	project := &db.ProfessionalProject{
-		ClientName: r.ClientName,
+		ClientName:   r.ClientName,
+		BillableRate: r.BillableRate,
+		Currency:     r.Currency,
 	}
 
 	if r.IsActive != nil {
@@ -124,14 +180,18 @@ func (r *CreateProjectAssignmentRequest) ToProjectAssignment() *db.ProjectAssign
 	return &db.ProjectAssignment{
 		WorkerUserID: r.WorkerUserID,
 		CostPerHour:  r.CostPerHour,
+		BillableRate: r.BillableRate,
+		Currency:     r.Currency,
 		Description:  r.Description,
 	}
 }
 
 func (r *UpdateProjectAssignmentRequest) ToProjectAssignment() *db.ProjectAssignment {
 	project := &db.ProjectAssignment{
-		CostPerHour: r.CostPerHour,
-		Description: r.Description,
+		CostPerHour:  r.CostPerHour,
+		BillableRate: r.BillableRate,
+		Currency:     r.Currency,
+		Description:  r.Description,
 	}
 
 	if r.IsActive != nil {
@@ -146,20 +206,28 @@ func (r *CreateProfessionalProjectRequest) ToInput() *svc.CreateProfessionalProj
 	return &svc.CreateProfessionalProjectInput{
 		Title:      r.Title,
 		ClientName: r.ClientName,
+		Currency:   r.Currency,
 	}
 }
 
 func ProfessionalProjectToResponse(project *db.ProfessionalProject) ProfessionalProjectResponse {
 	response := ProfessionalProjectResponse{
-		ID:              project.ID,
-		Title:           project.Title,
-		BaseProjectID:   project.BaseProjectID,
-		ClientName:      project.ClientName,
-		TotalSalaryCost: project.TotalSalaryCost,
-		TotalHours:      project.TotalHours,
-		IsActive:        project.IsActive,
-		CreatedAt:       project.CreatedAt,
-		UpdatedAt:       project.UpdatedAt,
+		ID:                 project.ID,
+		Title:              project.Title,
+		BaseProjectID:      project.BaseProjectID,
+		ClientName:         project.ClientName,
+		TotalSalaryCost:    project.TotalSalaryCost,
+		TotalHours:         project.TotalHours,
+		TotalGrossHours:    project.TotalGrossHours,
+		TotalBreakHours:    project.TotalBreakHours,
+		BillableRate:       project.BillableRate,
+		TotalRevenue:       project.TotalRevenue,
+		TotalBillableHours: project.TotalBillableHours,
+		TotalsCurrency:     project.TotalsCurrency,
+		Currency:           project.Currency,
+		IsActive:           project.IsActive,
+		CreatedAt:          project.CreatedAt,
+		UpdatedAt:          project.UpdatedAt,
 	}
 
 	// Convert freelance projects
@@ -195,8 +263,12 @@ func ProjectAssignmentToResponse(project *db.ProjectAssignment) ProjectAssignmen
 		ParentProjectID: project.ParentProjectID,
 		WorkerUserID:    project.WorkerUserID,
 		CostPerHour:     project.CostPerHour,
+		BillableRate:    project.BillableRate,
+		Currency:        project.Currency,
 		HoursDedicated:  project.HoursDedicated,
 		TotalCost:       project.TotalCost,
+		TotalRevenue:    project.TotalRevenue,
+		TotalsCurrency:  project.TotalsCurrency,
 		Description:     project.Description,
 		IsActive:        project.IsActive,
 		CreatedAt:       project.CreatedAt,
@@ -215,10 +287,21 @@ func TimeSessionToResponse(session *db.TimeSession) TimeSessionResponse {
 		EndTime:             session.EndTime,
 		SessionType:         session.SessionType,
 		DurationMinutes:     session.DurationMinutes,
+		BreakMinutes:        session.BreakMinutes,
+		NetMinutes:          session.NetMinutes,
 		HourlyRate:          session.HourlyRate,
+		RateSource:          session.RateSource,
 		SessionCost:         session.SessionCost,
+		Currency:            session.Currency,
+		BillableRate:        session.BillableRate,
+		Billable:            !session.NonBillable,
+		SessionRevenue:      session.SessionRevenue,
+		BillableCurrency:    session.BillableCurrency,
 		Notes:               session.Notes,
 		IsActive:            session.IsActive,
+		IsManualEntry:       session.IsManualEntry,
+		EndReason:           session.EndReason,
+		InvoiceID:           session.InvoiceID,
 		CreatedAt:           session.CreatedAt,
 		UpdatedAt:           session.UpdatedAt,
 	}
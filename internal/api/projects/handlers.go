@@ -5,10 +5,13 @@ import (
 	"io"
 	"log"
 	"strconv"
+	"time"
 
 	keycloakauth "github.com/JorgeSaicoski/keycloak-auth"
 	"github.com/JorgeSaicoski/microservice-commons/responses"
+	"github.com/JorgeSaicoski/professional-tracker/internal/api"
 	"github.com/JorgeSaicoski/professional-tracker/internal/services/projects"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/rates"
 	"github.com/gin-gonic/gin"
 )
 
@@ -42,7 +45,7 @@ func (h *ProjectHandler) CreateProfessionalProject(c *gin.Context) {
 	input := req.ToInput()
 	created, err := h.projectService.CreateProfessionalProject(input, userID)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -68,7 +71,7 @@ func (h *ProjectHandler) GetProfessionalProject(c *gin.Context) {
 
 	proj, err := h.projectService.GetProfessionalProject(uint(id), userID)
 	if err != nil {
-		responses.NotFound(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -99,7 +102,7 @@ func (h *ProjectHandler) UpdateProfessionalProject(c *gin.Context) {
 	updates := req.ToProfessionalProject()
 	proj, err := h.projectService.UpdateProfessionalProject(uint(id), updates, userID)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -122,7 +125,7 @@ func (h *ProjectHandler) DeleteProfessionalProject(c *gin.Context) {
 	}
 
 	if err := h.projectService.DeleteProfessionalProject(uint(id), userID); err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -138,7 +141,7 @@ func (h *ProjectHandler) GetUserProfessionalProjects(c *gin.Context) {
 
 	list, err := h.projectService.GetUserProfessionalProjects(userID)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -200,7 +203,7 @@ func (h *ProjectHandler) CreateProjectAssignment(c *gin.Context) {
 	created, err := h.projectService.CreateProjectAssignment(uint(parentID), fp, userID)
 	if err != nil {
 		log.Printf("ERROR: Service failed to create project assignment: %v", err)
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -228,11 +231,7 @@ func (h *ProjectHandler) GetProjectAssignment(c *gin.Context) {
 
 	fp, err := h.projectService.GetProjectAssignment(uint(fid), userID)
 	if err != nil {
-		if err.Error() == "access denied: freelance project is private to the worker" {
-			responses.Forbidden(c, err.Error())
-			return
-		}
-		responses.NotFound(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -263,11 +262,7 @@ func (h *ProjectHandler) UpdateProjectAssignment(c *gin.Context) {
 	updates := req.ToProjectAssignment()
 	fp, err := h.projectService.UpdateProjectAssignment(uint(fid), updates, userID)
 	if err != nil {
-		if err.Error() == "access denied: freelance project is private to the worker" {
-			responses.Forbidden(c, err.Error())
-			return
-		}
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -275,8 +270,251 @@ func (h *ProjectHandler) UpdateProjectAssignment(c *gin.Context) {
 	responses.Success(c, "Freelance project updated successfully", resp)
 }
 
+// GetAssignmentRates lists the rate periods of an assignment, oldest first.
+func (h *ProjectHandler) GetAssignmentRates(c *gin.Context) {
+	fid, err := strconv.ParseUint(c.Param("freelanceId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid freelance project ID")
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	history, err := h.projectService.GetAssignmentRatesCtx(c.Request.Context(), uint(fid), userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Assignment rates retrieved successfully", gin.H{
+		"rates": history,
+		"total": len(history),
+	})
+}
+
+// SetAssignmentRate starts a new rate period, now or at effectiveFrom.
+func (h *ProjectHandler) SetAssignmentRate(c *gin.Context) {
+	fid, err := strconv.ParseUint(c.Param("freelanceId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid freelance project ID")
+		return
+	}
+
+	var req SetAssignmentRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	effectiveFrom := time.Now()
+	if req.EffectiveFrom != nil {
+		effectiveFrom = *req.EffectiveFrom
+	}
+
+	change := rates.Change{
+		CostPerHour:  req.CostPerHour,
+		BillableRate: req.BillableRate,
+		Currency:     req.Currency,
+	}
+	rate, err := h.projectService.SetAssignmentRateCtx(c.Request.Context(), uint(fid), change, effectiveFrom, userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Created(c, "Assignment rate set successfully", rate)
+}
+
+// SetClientRate sets the default billable rate of the project's client.
+func (h *ProjectHandler) SetClientRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid project ID")
+		return
+	}
+
+	var req SetClientRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	rate, err := h.projectService.SetClientRateCtx(c.Request.Context(), uint(id), req.BillableRate, req.Currency, userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Client rate set successfully", rate)
+}
+
+// SetCompanyCurrency sets the default currency of the project's company.
+func (h *ProjectHandler) SetCompanyCurrency(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid project ID")
+		return
+	}
+
+	var req SetCompanyCurrencyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	settings, err := h.projectService.SetCompanyCurrencyCtx(c.Request.Context(), uint(id), req.Currency, userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Company currency set successfully", settings)
+}
+
+// SetDocumentTemplate sets the branding of the documents of the project's company
+func (h *ProjectHandler) SetDocumentTemplate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid project ID")
+		return
+	}
+
+	var req api.DocumentTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+	tpl, err := req.ToModel()
+	if err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	saved, err := h.projectService.SetDocumentTemplateCtx(c.Request.Context(), uint(id), tpl, userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Document template saved successfully", api.DocumentTemplateToResponse(saved))
+}
+
+/* ------------------------- Exchange rates ------------------------- */
+
+func (h *ProjectHandler) SetExchangeRate(c *gin.Context) {
+	var req SetExchangeRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	rateDate, err := time.Parse("2006-01-02", req.RateDate)
+	if err != nil {
+		responses.BadRequest(c, "Invalid rateDate format (use YYYY-MM-DD)")
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	rate, err := h.projectService.SetExchangeRateCtx(c.Request.Context(),
+		req.BaseCurrency, req.QuoteCurrency, req.Rate.String(), rateDate, userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Exchange rate set successfully", rate)
+}
+
+// GetExchangeRates lists the stored rates, optionally for ?base=USD&quote=EUR.
+func (h *ProjectHandler) GetExchangeRates(c *gin.Context) {
+	exchangeRates, err := h.projectService.GetExchangeRatesCtx(c.Request.Context(), c.Query("base"), c.Query("quote"))
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Exchange rates retrieved successfully", gin.H{
+		"rates": exchangeRates,
+		"total": len(exchangeRates),
+	})
+}
+
 /* ------------------------- Reports ------------------------------- */
 
+// GetAssignmentCostReport recomputes an assignment's cost between startDate
+// (inclusive) and endDate (inclusive day) with the rates in force back then.
+func (h *ProjectHandler) GetAssignmentCostReport(c *gin.Context) {
+	fid, err := strconv.ParseUint(c.Param("freelanceId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid freelance project ID")
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", c.Query("startDate"))
+	if err != nil {
+		responses.BadRequest(c, "Invalid startDate format (use YYYY-MM-DD)")
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", c.Query("endDate"))
+	if err != nil {
+		responses.BadRequest(c, "Invalid endDate format (use YYYY-MM-DD)")
+		return
+	}
+	if endDate.Before(startDate) {
+		responses.BadRequest(c, "endDate must not be before startDate")
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	report, err := h.projectService.GetAssignmentCostReportCtx(c.Request.Context(), uint(fid), startDate, endDate.AddDate(0, 0, 1), userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Assignment cost report generated successfully", report)
+}
+
+// GetProjectCostReport reports the project's time and money, converted to
+// ?currency= at the exchange rates known on ?asOf= (default today) if given.
 func (h *ProjectHandler) GetProjectCostReport(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
@@ -291,15 +529,77 @@ func (h *ProjectHandler) GetProjectCostReport(c *gin.Context) {
 		return
 	}
 
-	report, err := h.projectService.GetProjectCostReport(uint(id), userID)
+	target, err := api.ReportTarget(c, time.Now())
+	if err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := h.projectService.GetProjectCostReportCtx(c.Request.Context(), uint(id), userID, target)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
 	responses.Success(c, "Project cost report generated successfully", report)
 }
 
+// GetProjectReportDocument renders the project cost report, ?format=pdf|html
+func (h *ProjectHandler) GetProjectReportDocument(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid project ID")
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	target, err := api.ReportTarget(c, time.Now())
+	if err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	format := api.DocumentFormat(c)
+	body, err := h.projectService.GetProjectReportDocumentCtx(c.Request.Context(), uint(id), userID, target, format)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	api.RespondDocument(c, format, "project-"+idParam+"-report", body)
+}
+
+// RecomputeProjectTotals rebuilds the project and assignment totals from the
+// sessions and reports any drift from the stored values.
+func (h *ProjectHandler) RecomputeProjectTotals(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid project ID")
+		return
+	}
+
+	userID, ok := keycloakauth.GetUserID(c)
+	if !ok {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	report, err := h.projectService.RecomputeProjectTotalsCtx(c.Request.Context(), uint(id), userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Project totals recomputed successfully", report)
+}
+
 // GetMyAssignments returns all ProjectAssignments where the caller is the worker.
 // Auth is enforced by middleware; this reads user ID from header set by your gateway/middleware.
 func (h *ProjectHandler) GetMyAssignments(c *gin.Context) {
@@ -311,7 +611,7 @@ func (h *ProjectHandler) GetMyAssignments(c *gin.Context) {
 
 	assignments, err := h.projectService.GetUserProjectAssignmentsCtx(c.Request.Context(), userID)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
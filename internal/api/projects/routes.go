@@ -1,12 +1,16 @@
 package projects
 
 import (
+	keycloakauth "github.com/JorgeSaicoski/keycloak-auth"
 	"github.com/JorgeSaicoski/microservice-commons/middleware"
 	"github.com/JorgeSaicoski/professional-tracker/internal/api"
 	"github.com/JorgeSaicoski/professional-tracker/internal/services/projects"
 	"github.com/gin-gonic/gin"
 )
 
+// ExchangeRateAdminRole is the realm role allowed to change exchange rates
+const ExchangeRateAdminRole = "finance-admin"
+
 // RegisterRoutes registers all professional project related routes
 func RegisterRoutes(router *gin.RouterGroup, projectService *projects.ProfessionalProjectService) {
 	handler := NewProjectHandler(projectService)
@@ -19,24 +23,44 @@ func RegisterRoutes(router *gin.RouterGroup, projectService *projects.Profession
 	)
 	{
 		// Project CRUD
-		projectsGroup.POST("", handler.CreateProfessionalProject)          // Create professional project
-		projectsGroup.GET("/id/:id", handler.GetProfessionalProject)       // Get project by ID
-		projectsGroup.PUT("/id/:id", handler.UpdateProfessionalProject)    // Update project
-		projectsGroup.DELETE("/id/:id", handler.DeleteProfessionalProject) // Delete project
+		projectsGroup.POST("", handler.CreateProfessionalProject)                   // Create professional project
+		projectsGroup.GET("/id/:id", handler.GetProfessionalProject)                // Get project by ID
+		projectsGroup.PUT("/id/:id", handler.UpdateProfessionalProject)             // Update project
+		projectsGroup.DELETE("/id/:id", handler.DeleteProfessionalProject)          // Delete project
+		projectsGroup.PUT("/id/:id/client-rate", handler.SetClientRate)             // Default billable rate of the project's client
+		projectsGroup.PUT("/id/:id/company-currency", handler.SetCompanyCurrency)   // Default currency of the project's company
+		projectsGroup.PUT("/id/:id/document-template", handler.SetDocumentTemplate) // Branding of the company's documents
 
 		// User projects
 		projectsGroup.GET("", handler.GetUserProfessionalProjects) // Get user's professional projects
 
 		// Freelance sub-projects
-		projectsGroup.POST("/id/:id/freelance", handler.CreateProjectAssignment)             // Create freelance sub-project
-		projectsGroup.GET("/id/:id/freelance/:freelanceId", handler.GetProjectAssignment)    // Get freelance project
-		projectsGroup.PUT("/id/:id/freelance/:freelanceId", handler.UpdateProjectAssignment) // Update freelance project
+		projectsGroup.POST("/id/:id/freelance", handler.CreateProjectAssignment)              // Create freelance sub-project
+		projectsGroup.GET("/id/:id/freelance/:freelanceId", handler.GetProjectAssignment)     // Get freelance project
+		projectsGroup.PUT("/id/:id/freelance/:freelanceId", handler.UpdateProjectAssignment)  // Update freelance project
+		projectsGroup.GET("/id/:id/freelance/:freelanceId/rates", handler.GetAssignmentRates) // Rate history
+		projectsGroup.POST("/id/:id/freelance/:freelanceId/rates", handler.SetAssignmentRate) // Start a new rate period
 
 		// Reports
-		projectsGroup.GET("/id/:id/report", handler.GetProjectCostReport) // Get project cost report
+		projectsGroup.GET("/id/:id/report", handler.GetProjectCostReport)                         // Get project cost report
+		projectsGroup.GET("/id/:id/report/document", handler.GetProjectReportDocument)            // Cost report as PDF or HTML
+		projectsGroup.GET("/id/:id/freelance/:freelanceId/cost", handler.GetAssignmentCostReport) // Cost at the rates in force
+		projectsGroup.POST("/id/:id/totals/recompute", handler.RecomputeProjectTotals)            // Rebuild totals and report drift
 
 		// Assignments
 		projectsGroup.GET("/mine", handler.GetMyAssignments)
 
 	}
+
+	// Exchange rates used to convert reports; shared by all companies, so
+	// only finance administrators may change them
+	ratesGroup := router.Group("/exchange-rates")
+	ratesGroup.Use(
+		middleware.DefaultLoggingMiddleware(),
+		api.AuthMiddleware(),
+	)
+	{
+		ratesGroup.GET("", handler.GetExchangeRates)                                                 // List rates, ?base=&quote=
+		ratesGroup.PUT("", keycloakauth.RequireRole(ExchangeRateAdminRole), handler.SetExchangeRate) // Set the rate of a day
+	}
 }
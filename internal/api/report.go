@@ -0,0 +1,23 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/fx"
+	"github.com/gin-gonic/gin"
+)
+
+// ReportTarget reads the optional ?currency=EUR&asOf=YYYY-MM-DD of a report
+// request. asOf picks the exchange rates and defaults to defaultAsOf.
+func ReportTarget(c *gin.Context, defaultAsOf time.Time) (fx.Target, error) {
+	asOf := defaultAsOf
+	if param := c.Query("asOf"); param != "" {
+		parsed, err := time.Parse("2006-01-02", param)
+		if err != nil {
+			return fx.Target{}, fmt.Errorf("invalid asOf format (use YYYY-MM-DD)")
+		}
+		asOf = parsed
+	}
+	return fx.NewTarget(c.Query("currency"), asOf)
+}
@@ -0,0 +1,114 @@
+package sessions
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	keycloakauth "github.com/JorgeSaicoski/keycloak-auth"
+	"github.com/JorgeSaicoski/microservice-commons/responses"
+	"github.com/JorgeSaicoski/professional-tracker/internal/api"
+	"github.com/JorgeSaicoski/professional-tracker/internal/ical"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCalendarFeed creates an iCalendar subscription URL for the user
+func (h *SessionHandler) CreateCalendarFeed(c *gin.Context) {
+	var req CreateCalendarFeedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	feed, token, err := h.sessionService.CreateCalendarFeedCtx(c.Request.Context(), userID, req.ToInput())
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Created(c, "Calendar feed created successfully", CreatedCalendarFeedResponse{
+		CalendarFeedResponse: CalendarFeedToResponse(feed),
+		URL:                  feedURL(c, token),
+	})
+}
+
+func (h *SessionHandler) GetCalendarFeeds(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	feeds, err := h.sessionService.GetCalendarFeeds(userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Calendar feeds retrieved successfully", CalendarFeedsToResponse(feeds))
+}
+
+func (h *SessionHandler) DeleteCalendarFeed(c *gin.Context) {
+	feedID, err := strconv.ParseUint(c.Param("feedId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid feed ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.sessionService.DeleteCalendarFeed(userID, uint(feedID)); err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Calendar feed deleted successfully", nil)
+}
+
+// GetCalendarFeed serves a feed to calendar clients. They can't log in, so
+// the token in the URL is the credential.
+func (h *SessionHandler) GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	feed, events, err := h.sessionService.CalendarFeedEventsCtx(c.Request.Context(), token)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/calendar; charset=utf-8")
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+
+	w := ical.NewWriter(c.Writer, feed.Name)
+	for i := range events {
+		w.Event(&events[i])
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("calendar feed %d: write failed: %v", feed.ID, err)
+	}
+}
+
+// feedURL is the absolute subscription URL of token, as seen by the client
+func feedURL(c *gin.Context, token string) string {
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	host := c.Request.Host
+	if forwarded := c.GetHeader("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	return scheme + "://" + host + "/calendar/" + token + ".ics"
+}
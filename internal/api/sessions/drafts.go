@@ -0,0 +1,221 @@
+package sessions
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	keycloakauth "github.com/JorgeSaicoski/keycloak-auth"
+	"github.com/JorgeSaicoski/microservice-commons/responses"
+	"github.com/JorgeSaicoski/professional-tracker/internal/api"
+	"github.com/gin-gonic/gin"
+)
+
+// maxCalendarUpload bounds an uploaded .ics file
+const maxCalendarUpload = 5 << 20
+
+// ImportCalendar proposes draft sessions from an uploaded .ics file, sent as
+// the multipart field "file" or as the raw request body. The optional
+// ?from=&to= (YYYY-MM-DD, inclusive) and ?timezone= bound the import.
+func (h *SessionHandler) ImportCalendar(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	loc, err := time.LoadLocation(c.DefaultQuery("timezone", "UTC"))
+	if err != nil {
+		responses.BadRequest(c, "Invalid timezone")
+		return
+	}
+	var from, to time.Time
+	if param := c.Query("from"); param != "" {
+		if from, err = time.ParseInLocation("2006-01-02", param, loc); err != nil {
+			responses.BadRequest(c, "Invalid from date format (use YYYY-MM-DD)")
+			return
+		}
+	}
+	if param := c.Query("to"); param != "" {
+		if to, err = time.ParseInLocation("2006-01-02", param, loc); err != nil {
+			responses.BadRequest(c, "Invalid to date format (use YYYY-MM-DD)")
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxCalendarUpload)
+	var body io.Reader = c.Request.Body
+	if c.ContentType() == "multipart/form-data" {
+		file, err := c.FormFile("file")
+		if err != nil {
+			responses.BadRequest(c, "Missing calendar file")
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			responses.BadRequest(c, "Could not read uploaded file")
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	result, err := h.sessionService.ImportCalendarCtx(c.Request.Context(), userID, body, from, to, loc)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Created(c, "Calendar imported successfully", DraftImportToResponse(result))
+}
+
+// GetDrafts lists the user's draft sessions, ?status=pending|confirmed|discarded
+func (h *SessionHandler) GetDrafts(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	drafts, err := h.sessionService.GetDrafts(userID, c.Query("status"))
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Draft sessions retrieved successfully", DraftSessionsToResponse(drafts))
+}
+
+func (h *SessionHandler) UpdateDraft(c *gin.Context) {
+	draftID, err := strconv.ParseUint(c.Param("draftId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid draft ID")
+		return
+	}
+
+	var req UpdateDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	draft, err := h.sessionService.UpdateDraftCtx(c.Request.Context(), userID, uint(draftID), req.ToInput())
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Draft session updated successfully", DraftSessionToResponse(draft))
+}
+
+func (h *SessionHandler) DiscardDraft(c *gin.Context) {
+	draftID, err := strconv.ParseUint(c.Param("draftId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid draft ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.sessionService.DiscardDraft(userID, uint(draftID)); err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Draft session discarded successfully", nil)
+}
+
+// ConfirmDrafts turns drafts into sessions. Drafts are confirmed one by one,
+// so the response lists the session or the error of each requested ID.
+func (h *SessionHandler) ConfirmDrafts(c *gin.Context) {
+	var req ConfirmDraftsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	results, err := h.sessionService.ConfirmDraftsCtx(c.Request.Context(), userID, req.IDs)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Draft sessions processed", DraftConfirmationsToResponse(results))
+}
+
+func (h *SessionHandler) CreateImportRule(c *gin.Context) {
+	var req CreateImportRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	rule, err := h.sessionService.CreateImportRuleCtx(c.Request.Context(), userID, req.ToInput())
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Created(c, "Import rule created successfully", ImportRuleToResponse(rule))
+}
+
+func (h *SessionHandler) GetImportRules(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	rules, err := h.sessionService.GetImportRules(userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Import rules retrieved successfully", ImportRulesToResponse(rules))
+}
+
+func (h *SessionHandler) DeleteImportRule(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("ruleId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid rule ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.sessionService.DeleteImportRule(userID, uint(ruleID)); err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Import rule deleted successfully", nil)
+}
@@ -3,15 +3,18 @@ package sessions
 import (
 	"time"
 
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
 	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/sessions"
 )
 
 // Request DTOs
 
 type StartWorkSessionRequest struct {
-	ProjectID  uint     `json:"projectId" binding:"required"`
-	CompanyID  string   `json:"companyId" binding:"required"`
-	HourlyRate *float64 `json:"hourlyRate"`
+	ProjectID  uint          `json:"projectId" binding:"required"`
+	CompanyID  string        `json:"companyId" binding:"required"`
+	HourlyRate *money.Amount `json:"hourlyRate"`
 }
 
 type TakeBreakRequest struct {
@@ -23,9 +26,66 @@ type SwitchProjectRequest struct {
 }
 
 type SwitchCompanyRequest struct {
-	NewCompanyID string   `json:"newCompanyId" binding:"required"`
-	NewProjectID uint     `json:"newProjectId" binding:"required"`
-	HourlyRate   *float64 `json:"hourlyRate"`
+	NewCompanyID string        `json:"newCompanyId" binding:"required"`
+	NewProjectID uint          `json:"newProjectId" binding:"required"`
+	HourlyRate   *money.Amount `json:"hourlyRate"`
+}
+
+type ManualBreakRequest struct {
+	BreakType string    `json:"breakType" binding:"required"` // break, lunch, brb
+	StartTime time.Time `json:"startTime" binding:"required"`
+	EndTime   time.Time `json:"endTime" binding:"required"`
+}
+
+type CreateManualSessionRequest struct {
+	ProjectID  uint                 `json:"projectId" binding:"required"`
+	CompanyID  string               `json:"companyId" binding:"required"`
+	StartTime  time.Time            `json:"startTime" binding:"required"` // RFC 3339
+	EndTime    time.Time            `json:"endTime" binding:"required"`   // RFC 3339
+	Breaks     []ManualBreakRequest `json:"breaks"`
+	Notes      *string              `json:"notes"`
+	HourlyRate *money.Amount        `json:"hourlyRate"`
+	Billable   *bool                `json:"billable"` // defaults to true
+}
+
+type UpdateSessionRequest struct {
+	ProjectID  *uint         `json:"projectId"`
+	StartTime  *time.Time    `json:"startTime"`
+	EndTime    *time.Time    `json:"endTime"`
+	Notes      *string       `json:"notes"`
+	HourlyRate *money.Amount `json:"hourlyRate"`
+	Billable   *bool         `json:"billable"`
+}
+
+type SplitSessionRequest struct {
+	At time.Time `json:"at" binding:"required"` // RFC 3339 instant inside the session
+}
+
+type MergeSessionsRequest struct {
+	FirstSessionID  uint `json:"firstSessionId" binding:"required"`
+	SecondSessionID uint `json:"secondSessionId" binding:"required"`
+}
+
+type LockPeriodRequest struct {
+	CompanyID string    `json:"companyId" binding:"required"`
+	StartTime time.Time `json:"startTime" binding:"required"`
+	EndTime   time.Time `json:"endTime" binding:"required"`
+	Reason    *string   `json:"reason"`
+}
+
+// LockCompanyPeriodRequest locks a period of the company ProjectID belongs
+// to, for every member or only for UserID
+type LockCompanyPeriodRequest struct {
+	ProjectID uint      `json:"projectId" binding:"required"`
+	UserID    *string   `json:"userId"`
+	StartTime time.Time `json:"startTime" binding:"required"`
+	EndTime   time.Time `json:"endTime" binding:"required"`
+	Reason    *string   `json:"reason"`
+}
+
+type RecoverSessionRequest struct {
+	Action  string     `json:"action" binding:"required"` // keep, end_at, end_at_last_activity, discard
+	EndTime *time.Time `json:"endTime"`                   // required for end_at
 }
 
 type GenerateReportRequest struct {
@@ -37,21 +97,32 @@ type GenerateReportRequest struct {
 // Response DTOs
 
 type TimeSessionResponse struct {
-	ID                  uint       `json:"id"`
-	ProjectID           uint       `json:"projectId"`
-	ProjectAssignmentID *uint      `json:"projectAssignmentId"`
-	UserID              string     `json:"userId"`
-	CompanyID           string     `json:"companyId"`
-	StartTime           time.Time  `json:"startTime"`
-	EndTime             *time.Time `json:"endTime"`
-	SessionType         string     `json:"sessionType"`
-	DurationMinutes     int        `json:"durationMinutes"`
-	HourlyRate          *float64   `json:"hourlyRate"`
-	SessionCost         float64    `json:"sessionCost"`
-	Notes               *string    `json:"notes"`
-	IsActive            bool       `json:"isActive"`
-	CreatedAt           time.Time  `json:"createdAt"`
-	UpdatedAt           time.Time  `json:"updatedAt"`
+	ID                  uint          `json:"id"`
+	ProjectID           uint          `json:"projectId"`
+	ProjectAssignmentID *uint         `json:"projectAssignmentId"`
+	UserID              string        `json:"userId"`
+	CompanyID           string        `json:"companyId"`
+	StartTime           time.Time     `json:"startTime"`
+	EndTime             *time.Time    `json:"endTime"`
+	SessionType         string        `json:"sessionType"`
+	DurationMinutes     int           `json:"durationMinutes"` // gross, breaks included
+	BreakMinutes        int           `json:"breakMinutes"`
+	NetMinutes          int           `json:"netMinutes"` // billable
+	HourlyRate          *money.Amount `json:"hourlyRate"`
+	RateSource          string        `json:"rateSource"`
+	SessionCost         money.Amount  `json:"sessionCost"`
+	Currency            string        `json:"currency"`
+	BillableRate        *money.Amount `json:"billableRate"`
+	Billable            bool          `json:"billable"`
+	SessionRevenue      money.Amount  `json:"sessionRevenue"`
+	BillableCurrency    string        `json:"billableCurrency"`
+	Notes               *string       `json:"notes"`
+	IsActive            bool          `json:"isActive"`
+	IsManualEntry       bool          `json:"isManualEntry"`
+	EndReason           *string       `json:"endReason"`
+	InvoiceID           *uint         `json:"invoiceId"`
+	CreatedAt           time.Time     `json:"createdAt"`
+	UpdatedAt           time.Time     `json:"updatedAt"`
 }
 
 type SessionBreakResponse struct {
@@ -78,22 +149,204 @@ type UserActiveSessionResponse struct {
 	UpdatedAt      time.Time             `json:"updatedAt"`
 }
 
+type HeartbeatResponse struct {
+	SessionID      uint                  `json:"sessionId"`
+	LastActivityAt time.Time             `json:"lastActivityAt"`
+	IsOnBreak      bool                  `json:"isOnBreak"`
+	ResumedBreak   *SessionBreakResponse `json:"resumedBreak,omitempty"` // idle break ended by this heartbeat
+}
+
+type RecoveryInfoResponse struct {
+	ActiveSession UserActiveSessionResponse `json:"activeSession"`
+	IdleMinutes   int                       `json:"idleMinutes"`
+	NeedsRecovery bool                      `json:"needsRecovery"`
+	Actions       []string                  `json:"actions"`
+}
+
+// ProjectSessionSummaryResponse aggregates a project's sessions without per-worker detail
+type ProjectSessionSummaryResponse struct {
+	WorkSessions  int                 `json:"workSessions"`
+	Workers       int                 `json:"workers"`
+	ActiveWorkers int                 `json:"activeWorkers"`
+	GrossMinutes  int                 `json:"grossMinutes"`
+	BreakMinutes  int                 `json:"breakMinutes"`
+	NetMinutes    int                 `json:"netMinutes"`
+	TotalCost     money.Amount        `json:"totalCost"`  // in Currency; 0 when sessions are costed in several
+	Currency      string              `json:"currency"`   // empty when sessions are costed in several currencies
+	ByCurrency    []db.CurrencyTotals `json:"byCurrency"` // cost per currency
+}
+
+type SplitSessionResponse struct {
+	First  TimeSessionResponse `json:"first"`
+	Second TimeSessionResponse `json:"second"`
+}
+
+type PeriodLockResponse struct {
+	ID        uint      `json:"id"`
+	CompanyID string    `json:"companyId"`
+	UserID    *string   `json:"userId"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Reason    *string   `json:"reason"`
+	LockedBy  string    `json:"lockedBy"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreateCalendarFeedRequest struct {
+	Name      string  `json:"name"`
+	CompanyID *string `json:"companyId"` // only sessions in this company
+	ProjectID *uint   `json:"projectId"` // only sessions of this project
+}
+
+type CalendarFeedResponse struct {
+	ID             uint       `json:"id"`
+	Name           string     `json:"name"`
+	CompanyID      *string    `json:"companyId"`
+	ProjectID      *uint      `json:"projectId"`
+	LastAccessedAt *time.Time `json:"lastAccessedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// CreatedCalendarFeedResponse carries the subscription URL, shown only once
+type CreatedCalendarFeedResponse struct {
+	CalendarFeedResponse
+	URL string `json:"url"`
+}
+
+type CreateImportRuleRequest struct {
+	Keyword   *string `json:"keyword"`  // matched in summary, description and location
+	Attendee  *string `json:"attendee"` // organizer or attendee email
+	ProjectID uint    `json:"projectId" binding:"required"`
+	CompanyID string  `json:"companyId" binding:"required"`
+	Priority  int     `json:"priority"` // lower is tried first
+}
+
+type ImportRuleResponse struct {
+	ID        uint      `json:"id"`
+	Keyword   *string   `json:"keyword"`
+	Attendee  *string   `json:"attendee"`
+	ProjectID uint      `json:"projectId"`
+	CompanyID string    `json:"companyId"`
+	Priority  int       `json:"priority"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type UpdateDraftRequest struct {
+	ProjectID *uint      `json:"projectId"`
+	CompanyID *string    `json:"companyId"`
+	StartTime *time.Time `json:"startTime"`
+	EndTime   *time.Time `json:"endTime"`
+	Notes     *string    `json:"notes"`
+}
+
+type ConfirmDraftsRequest struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+type DraftSessionResponse struct {
+	ID          uint      `json:"id"`
+	ProjectID   *uint     `json:"projectId"`
+	CompanyID   *string   `json:"companyId"`
+	StartTime   time.Time `json:"startTime"`
+	EndTime     time.Time `json:"endTime"`
+	Title       string    `json:"title"`
+	Notes       *string   `json:"notes"`
+	SourceUID   string    `json:"sourceUid"`
+	SourceStart time.Time `json:"sourceStart"`
+	Status      string    `json:"status"`
+	RuleID      *uint     `json:"ruleId"`
+	SessionID   *uint     `json:"sessionId"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type DraftImportResponse struct {
+	Created    int                    `json:"created"`
+	Duplicates int                    `json:"duplicates"` // imported by an earlier upload
+	Unmapped   int                    `json:"unmapped"`   // no rule matched; assign a project before confirming
+	Skipped    int                    `json:"skipped"`    // cancelled, all-day or not yet finished
+	Drafts     []DraftSessionResponse `json:"drafts"`
+}
+
+// DraftConfirmationResponse is the outcome for one draft of a bulk confirmation
+type DraftConfirmationResponse struct {
+	DraftID   uint                 `json:"draftId"`
+	Session   *TimeSessionResponse `json:"session,omitempty"`
+	ErrorCode string               `json:"errorCode,omitempty"`
+	Error     string               `json:"error,omitempty"`
+}
+
+type SetImportMappingRequest struct {
+	Source      string `json:"source" binding:"required"` // toggl, clockify or harvest
+	ClientName  string `json:"clientName"`
+	ProjectName string `json:"projectName" binding:"required"`
+	ProjectID   uint   `json:"projectId" binding:"required"`
+	CompanyID   string `json:"companyId" binding:"required"`
+}
+
+type ImportMappingResponse struct {
+	ID          uint      `json:"id"`
+	Source      string    `json:"source"`
+	ClientName  string    `json:"clientName"`
+	ProjectName string    `json:"projectName"`
+	ProjectID   uint      `json:"projectId"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type ImportBatchResponse struct {
+	ID         uint                   `json:"id"`
+	Source     string                 `json:"source"`
+	CompanyID  string                 `json:"companyId"`
+	SourceUser string                 `json:"sourceUser"`
+	FileName   string                 `json:"fileName"`
+	Status     string                 `json:"status"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	AppliedAt  *time.Time             `json:"appliedAt"`
+	Report     *sessions.ImportReport `json:"report,omitempty"` // Omitted in lists
+}
+
 type UserTimeReportResponse struct {
-	UserID          string    `json:"userId"`
-	ProjectID       uint      `json:"projectId"`
-	CompanyID       string    `json:"companyId"`
-	TotalHours      float64   `json:"totalHours"`
-	WorkSessions    int       `json:"workSessions"`
-	BreakMinutes    int       `json:"breakMinutes"`
-	ProductiveHours float64   `json:"productiveHours"`
-	LastSession     time.Time `json:"lastSession"`
-	AverageDaily    float64   `json:"averageDaily"`
-	StartDate       string    `json:"startDate"`
-	EndDate         string    `json:"endDate"`
+	UserID          string       `json:"userId"`
+	ProjectID       uint         `json:"projectId"`
+	CompanyID       string       `json:"companyId"`
+	TotalHours      float64      `json:"totalHours"`
+	WorkSessions    int          `json:"workSessions"`
+	BreakMinutes    int          `json:"breakMinutes"`
+	ProductiveHours float64      `json:"productiveHours"`
+	TotalCost       money.Amount `json:"totalCost"`
+	LastSession     time.Time    `json:"lastSession"`
+	AverageDaily    float64      `json:"averageDaily"`
+	StartDate       string       `json:"startDate"`
+	EndDate         string       `json:"endDate"`
+
+	Currency    string              `json:"currency"` // of totalCost; empty when it mixes currencies
+	ByCurrency  []db.CurrencyTotals `json:"byCurrency"`
+	Conversions []db.ConversionUsed `json:"conversions"`
 }
 
 // Conversion methods
 
+func (r *CreateManualSessionRequest) ToInput() *sessions.ManualSessionInput {
+	breaks := make([]sessions.ManualBreakInput, len(r.Breaks))
+	for i, b := range r.Breaks {
+		breaks[i] = sessions.ManualBreakInput{
+			BreakType: b.BreakType,
+			StartTime: b.StartTime,
+			EndTime:   b.EndTime,
+		}
+	}
+	return &sessions.ManualSessionInput{
+		ProjectID:   r.ProjectID,
+		CompanyID:   r.CompanyID,
+		StartTime:   r.StartTime,
+		EndTime:     r.EndTime,
+		Breaks:      breaks,
+		Notes:       r.Notes,
+		HourlyRate:  r.HourlyRate,
+		NonBillable: r.Billable != nil && !*r.Billable,
+	}
+}
+
 func TimeSessionToResponse(session *db.TimeSession) TimeSessionResponse {
 	return TimeSessionResponse{
 		ID:                  session.ID,
@@ -105,10 +358,21 @@ func TimeSessionToResponse(session *db.TimeSession) TimeSessionResponse {
 		EndTime:             session.EndTime,
 		SessionType:         session.SessionType,
 		DurationMinutes:     session.DurationMinutes,
+		BreakMinutes:        session.BreakMinutes,
+		NetMinutes:          session.NetMinutes,
 		HourlyRate:          session.HourlyRate,
+		RateSource:          session.RateSource,
 		SessionCost:         session.SessionCost,
+		Currency:            session.Currency,
+		BillableRate:        session.BillableRate,
+		Billable:            !session.NonBillable,
+		SessionRevenue:      session.SessionRevenue,
+		BillableCurrency:    session.BillableCurrency,
 		Notes:               session.Notes,
 		IsActive:            session.IsActive,
+		IsManualEntry:       session.IsManualEntry,
+		EndReason:           session.EndReason,
+		InvoiceID:           session.InvoiceID,
 		CreatedAt:           session.CreatedAt,
 		UpdatedAt:           session.UpdatedAt,
 	}
@@ -156,6 +420,244 @@ func ActiveSessionToResponse(activeSession *db.UserActiveSession) UserActiveSess
 	return response
 }
 
+// ProjectSessionSummaryToResponse returns nil when the caller gets no summary
+func ProjectSessionSummaryToResponse(summary *sessions.ProjectSessionSummary) *ProjectSessionSummaryResponse {
+	if summary == nil {
+		return nil
+	}
+	return &ProjectSessionSummaryResponse{
+		WorkSessions:  summary.WorkSessions,
+		Workers:       summary.Workers,
+		ActiveWorkers: summary.ActiveWorkers,
+		GrossMinutes:  summary.GrossMinutes,
+		BreakMinutes:  summary.BreakMinutes,
+		NetMinutes:    summary.NetMinutes,
+		TotalCost:     summary.TotalCost,
+		Currency:      summary.Currency,
+		ByCurrency:    summary.ByCurrency,
+	}
+}
+
+func RecoveryInfoToResponse(info *sessions.RecoveryInfo) RecoveryInfoResponse {
+	active := ActiveSessionToResponse(info.ActiveSession)
+	active.Session = TimeSessionToResponse(info.Session)
+	return RecoveryInfoResponse{
+		ActiveSession: active,
+		IdleMinutes:   info.IdleMinutes,
+		NeedsRecovery: info.NeedsRecovery,
+		Actions:       info.Actions,
+	}
+}
+
+func (r *UpdateSessionRequest) ToInput() *sessions.UpdateSessionInput {
+	input := &sessions.UpdateSessionInput{
+		ProjectID:  r.ProjectID,
+		StartTime:  r.StartTime,
+		EndTime:    r.EndTime,
+		Notes:      r.Notes,
+		HourlyRate: r.HourlyRate,
+	}
+	if r.Billable != nil {
+		nonBillable := !*r.Billable
+		input.NonBillable = &nonBillable
+	}
+	return input
+}
+
+func PeriodLockToResponse(lock *db.PeriodLock) PeriodLockResponse {
+	return PeriodLockResponse{
+		ID:        lock.ID,
+		CompanyID: lock.CompanyID,
+		UserID:    lock.UserID,
+		StartTime: lock.StartTime,
+		EndTime:   lock.EndTime,
+		Reason:    lock.Reason,
+		LockedBy:  lock.LockedBy,
+		CreatedAt: lock.CreatedAt,
+	}
+}
+
+func PeriodLocksToResponse(locks []db.PeriodLock) []PeriodLockResponse {
+	responses := make([]PeriodLockResponse, len(locks))
+	for i, lock := range locks {
+		responses[i] = PeriodLockToResponse(&lock)
+	}
+	return responses
+}
+
+func (r *CreateCalendarFeedRequest) ToInput() *sessions.CalendarFeedInput {
+	return &sessions.CalendarFeedInput{
+		Name:      r.Name,
+		CompanyID: r.CompanyID,
+		ProjectID: r.ProjectID,
+	}
+}
+
+func CalendarFeedToResponse(feed *db.CalendarFeed) CalendarFeedResponse {
+	return CalendarFeedResponse{
+		ID:             feed.ID,
+		Name:           feed.Name,
+		CompanyID:      feed.CompanyID,
+		ProjectID:      feed.ProjectID,
+		LastAccessedAt: feed.LastAccessedAt,
+		CreatedAt:      feed.CreatedAt,
+	}
+}
+
+func CalendarFeedsToResponse(feeds []db.CalendarFeed) []CalendarFeedResponse {
+	responses := make([]CalendarFeedResponse, len(feeds))
+	for i, feed := range feeds {
+		responses[i] = CalendarFeedToResponse(&feed)
+	}
+	return responses
+}
+
+func (r *CreateImportRuleRequest) ToInput() *sessions.ImportRuleInput {
+	return &sessions.ImportRuleInput{
+		Keyword:   r.Keyword,
+		Attendee:  r.Attendee,
+		ProjectID: r.ProjectID,
+		CompanyID: r.CompanyID,
+		Priority:  r.Priority,
+	}
+}
+
+func ImportRuleToResponse(rule *db.ImportRule) ImportRuleResponse {
+	return ImportRuleResponse{
+		ID:        rule.ID,
+		Keyword:   rule.Keyword,
+		Attendee:  rule.Attendee,
+		ProjectID: rule.ProjectID,
+		CompanyID: rule.CompanyID,
+		Priority:  rule.Priority,
+		CreatedAt: rule.CreatedAt,
+	}
+}
+
+func ImportRulesToResponse(rules []db.ImportRule) []ImportRuleResponse {
+	responses := make([]ImportRuleResponse, len(rules))
+	for i, rule := range rules {
+		responses[i] = ImportRuleToResponse(&rule)
+	}
+	return responses
+}
+
+func (r *UpdateDraftRequest) ToInput() *sessions.DraftUpdateInput {
+	return &sessions.DraftUpdateInput{
+		ProjectID: r.ProjectID,
+		CompanyID: r.CompanyID,
+		StartTime: r.StartTime,
+		EndTime:   r.EndTime,
+		Notes:     r.Notes,
+	}
+}
+
+func DraftSessionToResponse(draft *db.DraftSession) DraftSessionResponse {
+	return DraftSessionResponse{
+		ID:          draft.ID,
+		ProjectID:   draft.ProjectID,
+		CompanyID:   draft.CompanyID,
+		StartTime:   draft.StartTime,
+		EndTime:     draft.EndTime,
+		Title:       draft.Title,
+		Notes:       draft.Notes,
+		SourceUID:   draft.SourceUID,
+		SourceStart: draft.SourceStart,
+		Status:      draft.Status,
+		RuleID:      draft.RuleID,
+		SessionID:   draft.SessionID,
+		CreatedAt:   draft.CreatedAt,
+		UpdatedAt:   draft.UpdatedAt,
+	}
+}
+
+func DraftSessionsToResponse(drafts []db.DraftSession) []DraftSessionResponse {
+	responses := make([]DraftSessionResponse, len(drafts))
+	for i, draft := range drafts {
+		responses[i] = DraftSessionToResponse(&draft)
+	}
+	return responses
+}
+
+func DraftImportToResponse(result *sessions.DraftImportResult) DraftImportResponse {
+	return DraftImportResponse{
+		Created:    result.Created,
+		Duplicates: result.Duplicates,
+		Unmapped:   result.Unmapped,
+		Skipped:    result.Skipped,
+		Drafts:     DraftSessionsToResponse(result.Drafts),
+	}
+}
+
+func DraftConfirmationsToResponse(results []sessions.DraftConfirmation) []DraftConfirmationResponse {
+	responses := make([]DraftConfirmationResponse, len(results))
+	for i, result := range results {
+		responses[i].DraftID = result.DraftID
+		if result.Err != nil {
+			responses[i].ErrorCode = "internal_error"
+			if appErr, ok := apperr.From(result.Err); ok {
+				responses[i].ErrorCode = appErr.Code
+			}
+			responses[i].Error = result.Err.Error()
+			continue
+		}
+		session := TimeSessionToResponse(result.Session)
+		responses[i].Session = &session
+	}
+	return responses
+}
+
+func (r *SetImportMappingRequest) ToInput() *sessions.ImportMappingInput {
+	return &sessions.ImportMappingInput{
+		Source:      r.Source,
+		ClientName:  r.ClientName,
+		ProjectName: r.ProjectName,
+		ProjectID:   r.ProjectID,
+		CompanyID:   r.CompanyID,
+	}
+}
+
+func ImportMappingToResponse(mapping *db.ImportMapping) ImportMappingResponse {
+	return ImportMappingResponse{
+		ID:          mapping.ID,
+		Source:      mapping.Source,
+		ClientName:  mapping.ClientName,
+		ProjectName: mapping.ProjectName,
+		ProjectID:   mapping.ProjectID,
+		CreatedAt:   mapping.CreatedAt,
+	}
+}
+
+func ImportMappingsToResponse(mappings []db.ImportMapping) []ImportMappingResponse {
+	responses := make([]ImportMappingResponse, len(mappings))
+	for i, mapping := range mappings {
+		responses[i] = ImportMappingToResponse(&mapping)
+	}
+	return responses
+}
+
+func ImportBatchToResponse(batch *db.ImportBatch, report *sessions.ImportReport) ImportBatchResponse {
+	return ImportBatchResponse{
+		ID:         batch.ID,
+		Source:     batch.Source,
+		CompanyID:  batch.CompanyID,
+		SourceUser: batch.SourceUser,
+		FileName:   batch.FileName,
+		Status:     batch.Status,
+		CreatedAt:  batch.CreatedAt,
+		AppliedAt:  batch.AppliedAt,
+		Report:     report,
+	}
+}
+
+func ImportBatchesToResponse(batches []db.ImportBatch) []ImportBatchResponse {
+	responses := make([]ImportBatchResponse, len(batches))
+	for i, batch := range batches {
+		responses[i] = ImportBatchToResponse(&batch, nil)
+	}
+	return responses
+}
+
 func UserTimeReportToResponse(report *db.UserTimeReport, startDate, endDate string) UserTimeReportResponse {
 	return UserTimeReportResponse{
 		UserID:          report.UserID,
@@ -165,9 +667,13 @@ func UserTimeReportToResponse(report *db.UserTimeReport, startDate, endDate stri
 		WorkSessions:    report.WorkSessions,
 		BreakMinutes:    report.BreakMinutes,
 		ProductiveHours: report.ProductiveHours,
+		TotalCost:       report.TotalCost,
 		LastSession:     report.LastSession,
 		AverageDaily:    report.AverageDaily,
 		StartDate:       startDate,
 		EndDate:         endDate,
+		Currency:        report.Currency,
+		ByCurrency:      report.ByCurrency,
+		Conversions:     report.Conversions,
 	}
 }
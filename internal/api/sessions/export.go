@@ -0,0 +1,108 @@
+package sessions
+
+import (
+	"strconv"
+
+	keycloakauth "github.com/JorgeSaicoski/keycloak-auth"
+	"github.com/JorgeSaicoski/microservice-commons/responses"
+	"github.com/JorgeSaicoski/professional-tracker/internal/api"
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/export"
+	"github.com/gin-gonic/gin"
+)
+
+// ExportSessionHistory streams the user's sessions as CSV or XLSX
+func (h *SessionHandler) ExportSessionHistory(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	req, err := api.ParseExportRequest(c)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+	columns, err := export.Select(export.SessionColumns, req.Columns, export.DefaultSessionColumns)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	out := api.NewExport(c, req.Format, "sessions", export.Headers(columns))
+	out.Finish(h.sessionService.StreamUserSessionsCtx(c.Request.Context(), userID, req.StartDate, req.EndDate,
+		func(session *db.TimeSession) error {
+			return out.Row(export.Cells(columns, session, req.Options))
+		}))
+}
+
+// ExportProjectSessions streams the project's sessions visible to the user
+func (h *SessionHandler) ExportProjectSessions(c *gin.Context) {
+	projectIDParam := c.Param("projectId")
+	projectID, err := strconv.ParseUint(projectIDParam, 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid project ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	req, err := api.ParseExportRequest(c)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+	columns, err := export.Select(export.SessionColumns, req.Columns, export.DefaultSessionColumns)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	out := api.NewExport(c, req.Format, "project-"+projectIDParam+"-sessions", export.Headers(columns))
+	out.Finish(h.sessionService.StreamProjectSessionsCtx(c.Request.Context(), uint(projectID), userID, req.StartDate, req.EndDate,
+		func(session *db.TimeSession) error {
+			return out.Row(export.Cells(columns, session, req.Options))
+		}))
+}
+
+// ExportTimeReport streams the user's time report as one row per day and
+// currency, optionally for ?projectId=
+func (h *SessionHandler) ExportTimeReport(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var projectID uint
+	if projectIDParam := c.Query("projectId"); projectIDParam != "" {
+		id, err := strconv.ParseUint(projectIDParam, 10, 32)
+		if err != nil {
+			responses.BadRequest(c, "Invalid project ID")
+			return
+		}
+		projectID = uint(id)
+	}
+
+	req, err := api.ParseExportRequest(c)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+	columns, err := export.Select(export.DailyColumns, req.Columns, export.DefaultDailyColumns)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	out := api.NewExport(c, req.Format, "time-report", export.Headers(columns))
+	out.Finish(h.sessionService.StreamDailyTotalsCtx(c.Request.Context(), userID, projectID, req.StartDate, req.EndDate, req.Options.Location,
+		func(totals *db.DailyTimeTotals) error {
+			return out.Row(export.Cells(columns, totals, req.Options))
+		}))
+}
@@ -2,17 +2,24 @@ package sessions
 
 import (
 	"errors"
+	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"strconv"
 	"time"
 
 	keycloakauth "github.com/JorgeSaicoski/keycloak-auth"
 	"github.com/JorgeSaicoski/microservice-commons/responses"
+	"github.com/JorgeSaicoski/professional-tracker/internal/api"
 	"github.com/JorgeSaicoski/professional-tracker/internal/services/sessions"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
+// streamKeepAlive is how often an idle event stream gets a comment line, so
+// proxies and load balancers don't close it.
+const streamKeepAlive = 25 * time.Second
+
 type SessionHandler struct {
 	sessionService *sessions.TimeSessionService
 }
@@ -41,13 +48,9 @@ func (h *SessionHandler) StartWorkSession(c *gin.Context) {
 		return
 	}
 
-	session, err := h.sessionService.StartWorkSession(req.ProjectID, req.CompanyID, userID, req.HourlyRate)
+	session, err := h.sessionService.StartWorkSessionCtx(c.Request.Context(), req.ProjectID, req.CompanyID, userID, req.HourlyRate)
 	if err != nil {
-		if err.Error() == "user already has an active session - finish current session first" {
-			responses.Conflict(c, err.Error())
-			return
-		}
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -64,7 +67,7 @@ func (h *SessionHandler) FinishWorkSession(c *gin.Context) {
 
 	session, err := h.sessionService.FinishWorkSession(userID)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -93,9 +96,9 @@ func (h *SessionHandler) GetActiveSession(c *gin.Context) {
 
 	// Not found → 200 with {active:false, session:null}
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, sessions.ErrNoActiveSession) {
 			// 5. Log specific case: record not found.
-			log.Println("DEBUG: No active session found for the user (gorm.ErrRecordNotFound)")
+			log.Println("DEBUG: No active session found for the user")
 			responses.Success(c, "ok", ActiveSessionEnvelope{
 				Active:  false,
 				Session: nil,
@@ -149,15 +152,7 @@ func (h *SessionHandler) TakeBreak(c *gin.Context) {
 
 	breakRecord, err := h.sessionService.TakeBreak(userID, req.BreakType)
 	if err != nil {
-		if err.Error() == "already on break - end current break first" {
-			responses.Conflict(c, err.Error())
-			return
-		}
-		if err.Error() == "invalid break type - use: break, lunch, or brb" {
-			responses.BadRequest(c, err.Error())
-			return
-		}
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -174,11 +169,7 @@ func (h *SessionHandler) EndBreak(c *gin.Context) {
 
 	breakRecord, err := h.sessionService.EndBreak(userID)
 	if err != nil {
-		if err.Error() == "not currently on break" {
-			responses.BadRequest(c, err.Error())
-			return
-		}
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -186,6 +177,115 @@ func (h *SessionHandler) EndBreak(c *gin.Context) {
 	responses.Success(c, "Break ended successfully, work resumed", response)
 }
 
+func (h *SessionHandler) Heartbeat(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	activeSession, resumed, err := h.sessionService.Heartbeat(userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	response := HeartbeatResponse{
+		SessionID:      activeSession.SessionID,
+		LastActivityAt: activeSession.LastActivityAt,
+		IsOnBreak:      activeSession.IsOnBreak,
+	}
+	if resumed != nil {
+		breakResponse := SessionBreakToResponse(resumed)
+		response.ResumedBreak = &breakResponse
+	}
+	responses.Success(c, "ok", response)
+}
+
+// StreamEvents pushes the user's session events as server-sent events, so
+// every device of the user stays in sync without polling /sessions/active.
+func (h *SessionHandler) StreamEvents(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	// The server's WriteTimeout would cut the stream after a few seconds
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		responses.InternalError(c, "streaming not supported")
+		return
+	}
+
+	stream, cancel := h.sessionService.Subscribe(userID)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case event, ok := <-stream:
+			if !ok {
+				return false
+			}
+			c.SSEvent(event.Type, event)
+			return true
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+			return true
+		}
+	})
+}
+
+func (h *SessionHandler) GetRecoveryInfo(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	info, err := h.sessionService.GetRecoveryInfo(userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "ok", RecoveryInfoToResponse(info))
+}
+
+func (h *SessionHandler) RecoverSession(c *gin.Context) {
+	var req RecoverSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	session, err := h.sessionService.RecoverSession(userID, req.Action, req.EndTime)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	response := TimeSessionToResponse(session)
+	responses.Success(c, "Session recovered successfully", response)
+}
+
 func (h *SessionHandler) SwitchProject(c *gin.Context) {
 	var req SwitchProjectRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -199,9 +299,9 @@ func (h *SessionHandler) SwitchProject(c *gin.Context) {
 		return
 	}
 
-	session, err := h.sessionService.SwitchProject(userID, req.NewProjectID)
+	session, err := h.sessionService.SwitchProjectCtx(c.Request.Context(), userID, req.NewProjectID)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -222,9 +322,9 @@ func (h *SessionHandler) SwitchCompany(c *gin.Context) {
 		return
 	}
 
-	session, err := h.sessionService.SwitchCompany(userID, req.NewCompanyID, req.NewProjectID, req.HourlyRate)
+	session, err := h.sessionService.SwitchCompanyCtx(c.Request.Context(), userID, req.NewCompanyID, req.NewProjectID, req.HourlyRate)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -232,6 +332,276 @@ func (h *SessionHandler) SwitchCompany(c *gin.Context) {
 	responses.Success(c, "Switched to new company successfully", response)
 }
 
+func (h *SessionHandler) CreateManualSession(c *gin.Context) {
+	var req CreateManualSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	session, err := h.sessionService.CreateManualSessionCtx(c.Request.Context(), userID, req.ToInput())
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	response := TimeSessionToResponse(session)
+	responses.Created(c, "Manual session created successfully", response)
+}
+
+func (h *SessionHandler) UpdateSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid session ID")
+		return
+	}
+
+	var req UpdateSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	session, err := h.sessionService.UpdateSessionCtx(c.Request.Context(), userID, uint(sessionID), req.ToInput())
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	response := TimeSessionToResponse(session)
+	responses.Success(c, "Session updated successfully", response)
+}
+
+func (h *SessionHandler) DeleteSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid session ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.sessionService.DeleteSession(userID, uint(sessionID)); err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Session deleted successfully", nil)
+}
+
+func (h *SessionHandler) SplitSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid session ID")
+		return
+	}
+
+	var req SplitSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	first, second, err := h.sessionService.SplitSession(userID, uint(sessionID), req.At)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Session split successfully", SplitSessionResponse{
+		First:  TimeSessionToResponse(first),
+		Second: TimeSessionToResponse(second),
+	})
+}
+
+func (h *SessionHandler) MergeSessions(c *gin.Context) {
+	var req MergeSessionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	session, err := h.sessionService.MergeSessions(userID, req.FirstSessionID, req.SecondSessionID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	response := TimeSessionToResponse(session)
+	responses.Success(c, "Sessions merged successfully", response)
+}
+
+func (h *SessionHandler) LockPeriod(c *gin.Context) {
+	var req LockPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	lock, err := h.sessionService.LockPeriod(userID, req.CompanyID, req.StartTime, req.EndTime, req.Reason)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	response := PeriodLockToResponse(lock)
+	responses.Created(c, "Period locked successfully", response)
+}
+
+func (h *SessionHandler) GetPeriodLocks(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	locks, err := h.sessionService.GetUserPeriodLocks(userID, c.Query("companyId"))
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	lockResponses := PeriodLocksToResponse(locks)
+	responses.Success(c, "Period locks retrieved successfully", gin.H{
+		"locks": lockResponses,
+		"total": len(lockResponses),
+	})
+}
+
+func (h *SessionHandler) UnlockPeriod(c *gin.Context) {
+	lockID, err := strconv.ParseUint(c.Param("lockId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid lock ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.sessionService.UnlockPeriod(userID, uint(lockID)); err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Period unlocked successfully", nil)
+}
+
+// LockCompanyPeriod locks a period for the whole company, or one of its
+// members, on behalf of a company administrator
+func (h *SessionHandler) LockCompanyPeriod(c *gin.Context) {
+	var req LockCompanyPeriodRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	lock, err := h.sessionService.LockCompanyPeriodCtx(c.Request.Context(), userID, req.ProjectID, req.UserID, req.StartTime, req.EndTime, req.Reason)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	response := PeriodLockToResponse(lock)
+	responses.Created(c, "Period locked successfully", response)
+}
+
+// GetCompanyPeriodLocks lists the locks of the company ?projectId= belongs to
+func (h *SessionHandler) GetCompanyPeriodLocks(c *gin.Context) {
+	projectID, err := strconv.ParseUint(c.Query("projectId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid project ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	locks, err := h.sessionService.GetCompanyPeriodLocksCtx(c.Request.Context(), userID, uint(projectID))
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	lockResponses := PeriodLocksToResponse(locks)
+	responses.Success(c, "Period locks retrieved successfully", gin.H{
+		"locks": lockResponses,
+		"total": len(lockResponses),
+	})
+}
+
+// UnlockCompanyPeriod removes any lock of the company ?projectId= belongs to
+func (h *SessionHandler) UnlockCompanyPeriod(c *gin.Context) {
+	lockID, err := strconv.ParseUint(c.Param("lockId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid lock ID")
+		return
+	}
+	projectID, err := strconv.ParseUint(c.Query("projectId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid project ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.sessionService.UnlockCompanyPeriodCtx(c.Request.Context(), userID, uint(projectID), uint(lockID)); err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Period unlocked successfully", nil)
+}
+
 func (h *SessionHandler) GetUserSessionHistory(c *gin.Context) {
 	userID, exists := keycloakauth.GetUserID(c)
 	if !exists {
@@ -256,7 +626,7 @@ func (h *SessionHandler) GetUserSessionHistory(c *gin.Context) {
 
 	sessions, err := h.sessionService.GetUserSessionHistory(userID, startDate, endDate)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
@@ -275,24 +645,24 @@ func (h *SessionHandler) GetProjectSessions(c *gin.Context) {
 		return
 	}
 
-	_, exists := keycloakauth.GetUserID(c)
+	userID, exists := keycloakauth.GetUserID(c)
 	if !exists {
 		responses.Unauthorized(c, "User not authenticated")
 		return
 	}
 
-	// TODO: Validate user has access to this project
-
-	sessions, err := h.sessionService.GetProjectSessions(uint(projectID))
+	view, err := h.sessionService.GetProjectSessionsCtx(c.Request.Context(), uint(projectID), userID)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		api.RespondError(c, err)
 		return
 	}
 
-	sessionResponses := TimeSessionsToResponse(sessions)
+	sessionResponses := TimeSessionsToResponse(view.Sessions)
 	responses.Success(c, "Project sessions retrieved successfully", gin.H{
-		"sessions": sessionResponses,
-		"total":    len(sessionResponses),
+		"sessions":     sessionResponses,
+		"total":        len(sessionResponses),
+		"summary":      ProjectSessionSummaryToResponse(view.Summary),
+		"detailAccess": view.DetailAccess,
 	})
 }
 
@@ -328,12 +698,114 @@ func (h *SessionHandler) GenerateUserTimeReport(c *gin.Context) {
 		return
 	}
 
-	report, err := h.sessionService.GenerateUserTimeReport(userID, projectID, startDate, endDate)
+	// Converted at the rates known on the last day of the report unless ?asOf= says otherwise
+	target, err := api.ReportTarget(c, endDate)
 	if err != nil {
-		responses.InternalError(c, err.Error())
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := h.sessionService.GenerateUserTimeReportCtx(c.Request.Context(), userID, projectID, startDate, endDate, target)
+	if err != nil {
+		api.RespondError(c, err)
 		return
 	}
 
 	response := UserTimeReportToResponse(report, startDateParam, endDateParam)
 	responses.Success(c, "Time report generated successfully", response)
 }
+
+// GetTimesheetDocument renders the user's timesheet, ?format=pdf|html
+func (h *SessionHandler) GetTimesheetDocument(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var projectID uint
+	if projectIDParam := c.Query("projectId"); projectIDParam != "" {
+		id, err := strconv.ParseUint(projectIDParam, 10, 32)
+		if err != nil {
+			responses.BadRequest(c, "Invalid project ID")
+			return
+		}
+		projectID = uint(id)
+	}
+
+	startDateParam := c.Query("startDate")
+	startDate, err := time.Parse("2006-01-02", startDateParam)
+	if err != nil {
+		responses.BadRequest(c, "Invalid start date format. Use YYYY-MM-DD")
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", c.Query("endDate"))
+	if err != nil {
+		responses.BadRequest(c, "Invalid end date format. Use YYYY-MM-DD")
+		return
+	}
+
+	target, err := api.ReportTarget(c, endDate)
+	if err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	format := api.DocumentFormat(c)
+	body, err := h.sessionService.GetTimesheetDocumentCtx(c.Request.Context(), userID, projectID, startDate, endDate, target, format)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	api.RespondDocument(c, format, "timesheet-"+startDateParam, body)
+}
+
+// GetDocumentTemplate returns the user's personal document template
+func (h *SessionHandler) GetDocumentTemplate(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	tpl, err := h.sessionService.GetPersonalDocumentTemplate(userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+	if tpl == nil {
+		responses.NotFound(c, "No document template set")
+		return
+	}
+
+	responses.Success(c, "Document template retrieved successfully", api.DocumentTemplateToResponse(tpl))
+}
+
+// SetDocumentTemplate sets the branding of the user's own documents
+func (h *SessionHandler) SetDocumentTemplate(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req api.DocumentTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+	tpl, err := req.ToModel()
+	if err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	saved, err := h.sessionService.SetPersonalDocumentTemplate(userID, tpl)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Document template saved successfully", api.DocumentTemplateToResponse(saved))
+}
@@ -0,0 +1,192 @@
+package sessions
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	keycloakauth "github.com/JorgeSaicoski/keycloak-auth"
+	"github.com/JorgeSaicoski/microservice-commons/responses"
+	"github.com/JorgeSaicoski/professional-tracker/internal/api"
+	"github.com/JorgeSaicoski/professional-tracker/internal/export"
+	"github.com/JorgeSaicoski/professional-tracker/internal/importer"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// maxImportUpload bounds an uploaded export of another time tracker
+const maxImportUpload = 20 << 20
+
+// PlanImport reads an export of Toggl, Clockify or Harvest (CSV or JSON),
+// sent as the multipart field "file" or as the raw request body, and returns
+// its dry-run report. ?source= and ?companyId= are required; ?user= picks
+// whose entries of a team export to import, ?timezone= and ?dateFormat=
+// tell how to read times exported without an offset.
+func (h *SessionHandler) PlanImport(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	opts, err := export.ParseOptions(c.Query("timezone"), c.Query("dateFormat"))
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+	in := &sessions.ImportInput{
+		Source:    c.Query("source"),
+		CompanyID: c.Query("companyId"),
+		User:      c.Query("user"),
+		Options:   importer.Options{Location: opts.Location},
+	}
+	if c.Query("dateFormat") != "" {
+		in.Options.DateLayout = opts.DateLayout
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportUpload)
+	var body io.Reader = c.Request.Body
+	if c.ContentType() == "multipart/form-data" {
+		file, err := c.FormFile("file")
+		if err != nil {
+			responses.BadRequest(c, "Missing import file")
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			responses.BadRequest(c, "Could not read uploaded file")
+			return
+		}
+		defer f.Close()
+		body, in.FileName = f, file.Filename
+	}
+
+	batch, report, err := h.sessionService.PlanImportCtx(c.Request.Context(), userID, body, in)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Created(c, "Import planned successfully", ImportBatchToResponse(batch, report))
+}
+
+func (h *SessionHandler) GetImports(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	batches, err := h.sessionService.GetImports(userID)
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Imports retrieved successfully", ImportBatchesToResponse(batches))
+}
+
+func (h *SessionHandler) GetImport(c *gin.Context) {
+	importID, err := strconv.ParseUint(c.Param("importId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid import ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	batch, report, err := h.sessionService.GetImport(userID, uint(importID))
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Import retrieved successfully", ImportBatchToResponse(batch, report))
+}
+
+// ApplyImport imports a planned batch and returns the final report
+func (h *SessionHandler) ApplyImport(c *gin.Context) {
+	importID, err := strconv.ParseUint(c.Param("importId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid import ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	batch, report, err := h.sessionService.ApplyImportCtx(c.Request.Context(), userID, uint(importID))
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Import applied successfully", ImportBatchToResponse(batch, report))
+}
+
+// GetImportMappings lists the user's mappings, optionally ?source=
+func (h *SessionHandler) GetImportMappings(c *gin.Context) {
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	mappings, err := h.sessionService.GetImportMappings(userID, c.Query("source"))
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Import mappings retrieved successfully", ImportMappingsToResponse(mappings))
+}
+
+func (h *SessionHandler) SetImportMapping(c *gin.Context) {
+	var req SetImportMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		responses.BadRequest(c, err.Error())
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	mapping, err := h.sessionService.SetImportMappingCtx(c.Request.Context(), userID, req.ToInput())
+	if err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Import mapping saved successfully", ImportMappingToResponse(mapping))
+}
+
+func (h *SessionHandler) DeleteImportMapping(c *gin.Context) {
+	mappingID, err := strconv.ParseUint(c.Param("mappingId"), 10, 32)
+	if err != nil {
+		responses.BadRequest(c, "Invalid mapping ID")
+		return
+	}
+
+	userID, exists := keycloakauth.GetUserID(c)
+	if !exists {
+		responses.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	if err := h.sessionService.DeleteImportMapping(userID, uint(mappingID)); err != nil {
+		api.RespondError(c, err)
+		return
+	}
+
+	responses.Success(c, "Import mapping deleted successfully", nil)
+}
@@ -22,18 +22,85 @@ func RegisterRoutes(router *gin.RouterGroup, sessionService *sessions.TimeSessio
 		sessionsGroup.POST("/start", handler.StartWorkSession)   // Start work session
 		sessionsGroup.POST("/finish", handler.FinishWorkSession) // Finish current session
 		sessionsGroup.GET("/active", handler.GetActiveSession)   // Get current active session
+		sessionsGroup.POST("/heartbeat", handler.Heartbeat)      // Report activity on the current session
+		sessionsGroup.GET("/stream", handler.StreamEvents)       // Server-sent events for the user's sessions
 
 		// Break management
 		sessionsGroup.POST("/break", handler.TakeBreak) // Take a break
 		sessionsGroup.POST("/resume", handler.EndBreak) // End break and resume work
 
+		// Crash recovery
+		sessionsGroup.GET("/recovery", handler.GetRecoveryInfo) // Inspect a session left running
+		sessionsGroup.POST("/recovery", handler.RecoverSession) // Keep, end or discard it
+
 		// Project and company switching
 		sessionsGroup.POST("/switch-project", handler.SwitchProject) // Switch to different project
 		sessionsGroup.POST("/switch-company", handler.SwitchCompany) // Switch to different company
 
+		// Manual time entry
+		sessionsGroup.POST("/manual", handler.CreateManualSession) // Record a completed session after the fact
+
+		// Corrections of finished sessions
+		sessionsGroup.PATCH("/id/:id", handler.UpdateSession)     // Edit project, times, notes or rate
+		sessionsGroup.DELETE("/id/:id", handler.DeleteSession)    // Delete a session and its breaks
+		sessionsGroup.POST("/id/:id/split", handler.SplitSession) // Split a session at a timestamp
+		sessionsGroup.POST("/merge", handler.MergeSessions)       // Merge two adjacent sessions
+
+		// Locked periods
+		sessionsGroup.POST("/locks", handler.LockPeriod)             // Lock a period of the user's timeline
+		sessionsGroup.GET("/locks", handler.GetPeriodLocks)          // List locks applying to the user
+		sessionsGroup.DELETE("/locks/:lockId", handler.UnlockPeriod) // Remove a lock the user set themselves
+
+		// Company locks, managed by company administrators
+		sessionsGroup.POST("/company-locks", handler.LockCompanyPeriod)             // Lock a period for the company or one member
+		sessionsGroup.GET("/company-locks", handler.GetCompanyPeriodLocks)          // List the company's locks, ?projectId=
+		sessionsGroup.DELETE("/company-locks/:lockId", handler.UnlockCompanyPeriod) // Remove any lock of the company, ?projectId=
+
 		// Session history and reports
 		sessionsGroup.GET("/history", handler.GetUserSessionHistory)         // Get user's session history
 		sessionsGroup.GET("/project/:projectId", handler.GetProjectSessions) // Get sessions for a project
 		sessionsGroup.GET("/report", handler.GenerateUserTimeReport)         // Generate user time report
+		sessionsGroup.GET("/report/document", handler.GetTimesheetDocument)  // Timesheet as PDF or HTML
+
+		// Spreadsheet exports, streamed as CSV or XLSX
+		sessionsGroup.GET("/history/export", handler.ExportSessionHistory)             // Session history
+		sessionsGroup.GET("/project/:projectId/export", handler.ExportProjectSessions) // Visible sessions of a project
+		sessionsGroup.GET("/report/export", handler.ExportTimeReport)                  // Daily totals of the time report
+
+		// iCalendar subscriptions
+		sessionsGroup.POST("/calendar-feeds", handler.CreateCalendarFeed)           // New feed URL, optionally per company or project
+		sessionsGroup.GET("/calendar-feeds", handler.GetCalendarFeeds)              // List the user's feeds
+		sessionsGroup.DELETE("/calendar-feeds/:feedId", handler.DeleteCalendarFeed) // Revoke a feed URL
+
+		// Calendar import: events become drafts to review before confirming
+		sessionsGroup.POST("/drafts/import", handler.ImportCalendar)            // Upload an .ics file
+		sessionsGroup.GET("/drafts", handler.GetDrafts)                         // List drafts by status
+		sessionsGroup.PATCH("/drafts/:draftId", handler.UpdateDraft)            // Edit project, times or notes
+		sessionsGroup.DELETE("/drafts/:draftId", handler.DiscardDraft)          // Discard a draft
+		sessionsGroup.POST("/drafts/confirm", handler.ConfirmDrafts)            // Turn drafts into sessions
+		sessionsGroup.POST("/import-rules", handler.CreateImportRule)           // Map events to a project by keyword or attendee
+		sessionsGroup.GET("/import-rules", handler.GetImportRules)              // List rules in the order they apply
+		sessionsGroup.DELETE("/import-rules/:ruleId", handler.DeleteImportRule) // Remove a rule
+
+		// Imports from other time trackers: planned as a dry run, then applied
+		sessionsGroup.POST("/imports", handler.PlanImport)                               // Upload a Toggl, Clockify or Harvest export
+		sessionsGroup.GET("/imports", handler.GetImports)                                // List the user's imports
+		sessionsGroup.GET("/imports/:importId", handler.GetImport)                       // Import with its diff report
+		sessionsGroup.POST("/imports/:importId/apply", handler.ApplyImport)              // Create the planned sessions
+		sessionsGroup.GET("/import-mappings", handler.GetImportMappings)                 // List source project mappings
+		sessionsGroup.PUT("/import-mappings", handler.SetImportMapping)                  // Map a source client and project
+		sessionsGroup.DELETE("/import-mappings/:mappingId", handler.DeleteImportMapping) // Remove a mapping
+
+		// Personal document branding
+		sessionsGroup.GET("/document-template", handler.GetDocumentTemplate) // Get the user's template
+		sessionsGroup.PUT("/document-template", handler.SetDocumentTemplate) // Set logo, address and locale
+	}
+
+	// Calendar clients fetch feeds without credentials; the secret token in
+	// the URL authorizes the request instead of the auth middleware
+	calendarGroup := router.Group("/calendar")
+	calendarGroup.Use(middleware.DefaultLoggingMiddleware())
+	{
+		calendarGroup.GET("/:token", handler.GetCalendarFeed) // /calendar/<token>.ics
 	}
 }
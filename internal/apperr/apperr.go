@@ -0,0 +1,71 @@
+// Package apperr is the error taxonomy shared by the services. Each error
+// carries a Kind, which decides the HTTP status, and a machine-readable Code
+// that clients can switch on. Services declare sentinels with the constructors
+// below and either return them directly, wrap them with fmt.Errorf("%w: ...")
+// to add detail, or attach a cause with Wrap.
+package apperr
+
+import "errors"
+
+// Kind classifies an error independently of the transport
+type Kind string
+
+const (
+	KindNotFound    Kind = "not_found"
+	KindConflict    Kind = "conflict"
+	KindForbidden   Kind = "forbidden"
+	KindValidation  Kind = "validation"
+	KindUnavailable Kind = "unavailable" // an upstream dependency failed or timed out
+)
+
+// Error is a classified domain error
+type Error struct {
+	Kind    Kind
+	Code    string // machine-readable, e.g. "active_session_exists"
+	Message string
+	Err     error // optional cause
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error    { return New(KindNotFound, code, message) }
+func Conflict(code, message string) *Error    { return New(KindConflict, code, message) }
+func Forbidden(code, message string) *Error   { return New(KindForbidden, code, message) }
+func Validation(code, message string) *Error  { return New(KindValidation, code, message) }
+func Unavailable(code, message string) *Error { return New(KindUnavailable, code, message) }
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches any *Error with the same code, so errors.Is(err, ErrX) also holds
+// for copies of ErrX produced by Wrap.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e with cause attached
+func (e *Error) Wrap(cause error) *Error {
+	wrapped := *e
+	wrapped.Err = cause
+	return &wrapped
+}
+
+// From returns the outermost *Error in err's chain
+func From(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
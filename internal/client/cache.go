@@ -0,0 +1,296 @@
+package clients
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+/* ---------------------------------------------------------------------
+   Caching decorator
+   ---------------------------------------------------------------------
+   One page load checks the same project and membership many times.
+   CachingCoreProjectClient answers repeated GetProject,
+   GetProjectMembers and GetUserProjects lookups from memory for a short
+   TTL, per user and project, and collapses concurrent identical lookups
+   into one call to Core. Denials (ErrCoreForbidden, ErrCoreNotFound) are
+   cached for a shorter NegativeTTL; unavailability is never cached.
+   Writes going through the client drop what they may have changed.
+   ------------------------------------------------------------------ */
+
+// CacheOptions tune CachingCoreProjectClient
+type CacheOptions struct {
+	TTL         time.Duration // Of successful lookups
+	NegativeTTL time.Duration // Of denials; 0 doesn't cache them
+	MaxEntries  int           // Bound of the cache, least recently used out first; 0 for no bound
+}
+
+// DefaultCacheOptions keep lookups for 30s and denials for 10s, so access
+// granted or revoked in Core directly shows up quickly
+func DefaultCacheOptions() CacheOptions {
+	return CacheOptions{
+		TTL:         30 * time.Second,
+		NegativeTTL: 10 * time.Second,
+		MaxEntries:  10000,
+	}
+}
+
+// CacheStats are the counters of a CachingCoreProjectClient
+type CacheStats struct {
+	Hits          uint64  `json:"hits"`
+	NegativeHits  uint64  `json:"negativeHits"` // Hits answered with a cached denial, included in Hits
+	Misses        uint64  `json:"misses"`
+	Shared        uint64  `json:"shared"` // Misses that shared a call with concurrent identical lookups
+	Invalidations uint64  `json:"invalidations"`
+	Evictions     uint64  `json:"evictions"` // Entries dropped to stay within MaxEntries
+	Entries       int     `json:"entries"`
+	HitRatio      float64 `json:"hitRatio"`
+}
+
+// cacheKey identifies a lookup; projectID is empty for GetUserProjects
+type cacheKey struct {
+	call      string
+	projectID string
+	userID    string
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	value   any
+	err     error
+	expires time.Time
+}
+
+// CachingCoreProjectClient decorates a CoreProjectClient with a cache
+type CachingCoreProjectClient struct {
+	next CoreProjectClient
+	opts CacheOptions
+
+	mu         sync.Mutex
+	entries    map[cacheKey]*list.Element // Elements of recency, holding *cacheEntry
+	recency    *list.List                 // Most recently used first
+	generation uint64                     // Bumped by every invalidation, so lookups in flight don't store stale answers
+	group      singleflight.Group
+
+	hits, negativeHits, misses, shared, invalidations, evictions atomic.Uint64
+}
+
+var _ CoreProjectClient = (*CachingCoreProjectClient)(nil)
+
+// NewCachingCoreProjectClient wraps next
+func NewCachingCoreProjectClient(next CoreProjectClient, opts CacheOptions) *CachingCoreProjectClient {
+	return &CachingCoreProjectClient{
+		next:    next,
+		opts:    opts,
+		entries: make(map[cacheKey]*list.Element),
+		recency: list.New(),
+	}
+}
+
+// Stats returns a snapshot of the cache counters
+func (c *CachingCoreProjectClient) Stats() CacheStats {
+	c.mu.Lock()
+	entries := len(c.entries)
+	c.mu.Unlock()
+
+	stats := CacheStats{
+		Hits:          c.hits.Load(),
+		NegativeHits:  c.negativeHits.Load(),
+		Misses:        c.misses.Load(),
+		Shared:        c.shared.Load(),
+		Invalidations: c.invalidations.Load(),
+		Evictions:     c.evictions.Load(),
+		Entries:       entries,
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+/* ---------------------------------------------------------------------
+   Cached lookups
+   ------------------------------------------------------------------ */
+
+func (c *CachingCoreProjectClient) GetProject(ctx context.Context, id string, userID string) (*BaseProject, error) {
+	v, err := c.lookup(ctx, cacheKey{"GetProject", id, userID}, func(ctx context.Context) (any, error) {
+		return c.next.GetProject(ctx, id, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	project := *v.(*BaseProject)
+	return &project, nil
+}
+
+func (c *CachingCoreProjectClient) GetProjectMembers(ctx context.Context, id string, userID string) ([]ProjectMember, error) {
+	v, err := c.lookup(ctx, cacheKey{"GetProjectMembers", id, userID}, func(ctx context.Context) (any, error) {
+		return c.next.GetProjectMembers(ctx, id, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]ProjectMember(nil), v.([]ProjectMember)...), nil
+}
+
+func (c *CachingCoreProjectClient) GetUserProjects(ctx context.Context, userID string) ([]BaseProject, error) {
+	v, err := c.lookup(ctx, cacheKey{"GetUserProjects", "", userID}, func(ctx context.Context) (any, error) {
+		return c.next.GetUserProjects(ctx, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]BaseProject(nil), v.([]BaseProject)...), nil
+}
+
+/* ---------------------------------------------------------------------
+   Writes: passed through, then the entries they affect are dropped.
+   They are dropped on errors too, since a timed-out write may still
+   have happened in Core.
+   ------------------------------------------------------------------ */
+
+func (c *CachingCoreProjectClient) CreateBaseProject(ctx context.Context, req *BaseProjectCreateRequest) (*BaseProject, error) {
+	project, err := c.next.CreateBaseProject(ctx, req)
+	c.invalidate(func(k cacheKey) bool { return k.call == "GetUserProjects" && k.userID == req.OwnerID })
+	return project, err
+}
+
+// UpdateProject drops the project's entries and the caller's project list.
+// Other users' lists may show the old title or status until they expire.
+// Empty updates, which services send to check update permission, change
+// nothing and drop nothing.
+func (c *CachingCoreProjectClient) UpdateProject(ctx context.Context, id string, userID string, updates *UpdateProjectRequest) (*BaseProject, error) {
+	project, err := c.next.UpdateProject(ctx, id, userID, updates)
+	if updates.isEmpty() {
+		return project, err
+	}
+	c.invalidate(func(k cacheKey) bool {
+		return k.projectID == id || (k.call == "GetUserProjects" && k.userID == userID)
+	})
+	return project, err
+}
+
+func (c *CachingCoreProjectClient) DeleteProject(ctx context.Context, id string, userID string) error {
+	err := c.next.DeleteProject(ctx, id, userID)
+	c.invalidateProject(id)
+	return err
+}
+
+// AddProjectMember also drops the new member's cached denials of the project
+func (c *CachingCoreProjectClient) AddProjectMember(ctx context.Context, id string, req *AddMemberRequest) (*ProjectMember, error) {
+	member, err := c.next.AddProjectMember(ctx, id, req)
+	c.invalidate(func(k cacheKey) bool {
+		return k.projectID == id || (k.call == "GetUserProjects" && k.userID == req.UserID)
+	})
+	return member, err
+}
+
+// invalidateProject drops every user's entries of the project, and the
+// project lists that may show its title or status
+func (c *CachingCoreProjectClient) invalidateProject(id string) {
+	c.invalidate(func(k cacheKey) bool { return k.projectID == id || k.call == "GetUserProjects" })
+}
+
+func (c *CachingCoreProjectClient) invalidate(match func(cacheKey) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for key, element := range c.entries {
+		if match(key) {
+			c.remove(element)
+		}
+	}
+	c.invalidations.Add(1)
+}
+
+/* ---------------------------------------------------------------------
+   Internals
+   ------------------------------------------------------------------ */
+
+// lookup answers from the cache or fetches, sharing the fetch with
+// concurrent identical lookups. The shared fetch outlives a caller that
+// gives up; the client's own timeouts bound it.
+func (c *CachingCoreProjectClient) lookup(ctx context.Context, key cacheKey, fetch func(context.Context) (any, error)) (any, error) {
+	c.mu.Lock()
+	var hit *cacheEntry
+	if element, ok := c.entries[key]; ok {
+		if entry := element.Value.(*cacheEntry); time.Now().Before(entry.expires) {
+			c.recency.MoveToFront(element)
+			hit = entry
+		} else {
+			c.remove(element)
+		}
+	}
+	generation := c.generation
+	c.mu.Unlock()
+	if hit != nil {
+		c.hits.Add(1)
+		if hit.err != nil {
+			c.negativeHits.Add(1)
+		}
+		return hit.value, hit.err
+	}
+	c.misses.Add(1)
+
+	flight := fmt.Sprintf("%d\x00%s\x00%s\x00%s", generation, key.call, key.projectID, key.userID)
+	ch := c.group.DoChan(flight, func() (any, error) {
+		value, err := fetch(context.WithoutCancel(ctx))
+		c.store(key, value, err, generation)
+		return value, err
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ErrCoreUnavailable.Wrap(fmt.Errorf("core-project %s: %w", key.call, ctx.Err()))
+	case res := <-ch:
+		if res.Shared {
+			c.shared.Add(1)
+		}
+		return res.Val, res.Err
+	}
+}
+
+// store caches an answer unless an invalidation happened since the lookup
+// started. Only successes and denials are cached. A full cache drops its
+// least recently used entry.
+func (c *CachingCoreProjectClient) store(key cacheKey, value any, err error, generation uint64) {
+	ttl := c.opts.TTL
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrCoreForbidden), errors.Is(err, ErrCoreNotFound):
+		ttl = c.opts.NegativeTTL
+	default:
+		return
+	}
+	if ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation != c.generation {
+		return
+	}
+	entry := &cacheEntry{key: key, value: value, err: err, expires: time.Now().Add(ttl)}
+	if element, exists := c.entries[key]; exists {
+		element.Value = entry
+		c.recency.MoveToFront(element)
+		return
+	}
+	for c.opts.MaxEntries > 0 && len(c.entries) >= c.opts.MaxEntries {
+		c.remove(c.recency.Back())
+		c.evictions.Add(1)
+	}
+	c.entries[key] = c.recency.PushFront(entry)
+}
+
+// remove drops an entry. Callers hold mu.
+func (c *CachingCoreProjectClient) remove(element *list.Element) {
+	c.recency.Remove(element)
+	delete(c.entries, element.Value.(*cacheEntry).key)
+}
@@ -1,11 +1,9 @@
 package clients
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"net/url"
@@ -54,6 +52,11 @@ type UpdateProjectRequest struct {
 	EndDate     *time.Time `json:"endDate,omitempty"`
 }
 
+// isEmpty reports whether the request changes nothing
+func (r *UpdateProjectRequest) isEmpty() bool {
+	return r == nil || *r == UpdateProjectRequest{}
+}
+
 // Add-member payload for Core.
 type AddMemberRequest struct {
 	UserID           string   `json:"userId"`
@@ -77,20 +80,31 @@ type CoreProjectClient interface {
 }
 
 // httpCoreProjectClient is a thin wrapper over net/http that knows how to
-// talk to the Core‑Projects service.  We keep it dumb on purpose: it builds
-// the request, unmarshals the response, nothing more.
+// talk to the Core‑Projects service.  Each method builds its call and
+// unmarshals the response; timeouts, retries and the circuit breaker live
+// in do (see resilience.go).
 
 type httpCoreProjectClient struct {
 	baseURL string       // e.g. "http://project-core:8080/api/internal"
-	http    *http.Client // injected so we can swap in mocks/timeouts later
+	http    *http.Client // injected so we can swap in mocks later
+	opts    HTTPClientOptions
+	breaker *breaker // nil when disabled
 }
 
 // NewCoreProjectHTTPClient is the public constructor used by the
 // Professional‑Tracker service at boot time.
 func NewCoreProjectHTTPClient(baseURL string) CoreProjectClient {
+	return NewCoreProjectHTTPClientWithOptions(baseURL, DefaultHTTPClientOptions())
+}
+
+// NewCoreProjectHTTPClientWithOptions builds the client with explicit
+// timeouts, retries and breaker settings.
+func NewCoreProjectHTTPClientWithOptions(baseURL string, opts HTTPClientOptions) CoreProjectClient {
 	return &httpCoreProjectClient{
 		baseURL: baseURL,
 		http:    &http.Client{},
+		opts:    opts,
+		breaker: newBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
 	}
 }
 
@@ -105,30 +119,18 @@ func (c *httpCoreProjectClient) CreateBaseProject(
 
 	// 1)  Encode request object → JSON body
 	body, _ := json.Marshal(req)
-	url := fmt.Sprintf("%s/projects", c.baseURL)
-
-	// 2)  Build the HTTP request with the caller‑supplied context so the
-	//     caller can cancel / set timeouts.
-	httpReq, _ := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	// 3)  Execute the request.
-	resp, err := c.http.Do(httpReq)
+	// 2)  Send it with the caller‑supplied context so the caller can
+	//     cancel.  Creating is not idempotent, so it is never retried.
+	raw, err := c.do(ctx, coreCall{
+		name:   "CreateBaseProject",
+		label:  "core-project create",
+		method: http.MethodPost,
+		url:    fmt.Sprintf("%s/projects", c.baseURL),
+		body:   body,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("core-project call failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// 4)  Non‑2xx → bubble up the plain body for easier troubleshooting.
-	if resp.StatusCode >= 300 {
-		raw, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("core-project returned %s – body: %s", resp.Status, raw)
-	}
-
-	// 5)  Read whole body so we can both debug‑print and unmarshal.
-	raw, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("read body: %w", err)
+		return nil, err
 	}
 	log.Printf("[DEBUG] Core response: %s", raw)
 
@@ -158,10 +160,10 @@ func (c *httpCoreProjectClient) CreateBaseProject(
 
 	var env envelope
 	if err := json.Unmarshal(raw, &env); err != nil {
-		return nil, fmt.Errorf("decode base project: %w", err)
+		return nil, ErrCoreUnavailable.Wrap(fmt.Errorf("decode base project: %w", err))
 	}
 
-	// 6)  Promote the inner struct to our public DTO, string‑ifying the ID.
+	// 3)  Promote the inner struct to our public DTO, string‑ifying the ID.
 	bp := &BaseProject{
 		ID:      env.Data.ID.String(), // json.Number.String() gives the textual form (e.g. "6")
 		Title:   env.Data.Title,
@@ -179,22 +181,16 @@ func (c *httpCoreProjectClient) CreateBaseProject(
 	------------------------------------------------------------------
 */
 func (c *httpCoreProjectClient) GetProject(ctx context.Context, id string, userID string) (*BaseProject, error) {
-	u := fmt.Sprintf("%s/projects/%s", c.baseURL, id)
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-	req.Header.Set("X-User-ID", userID)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("core-project get: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("core-project get %s: %s", resp.Status, body)
-	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.do(ctx, coreCall{
+		name:       "GetProject",
+		label:      "core-project get",
+		method:     http.MethodGet,
+		url:        fmt.Sprintf("%s/projects/%s", c.baseURL, id),
+		userID:     userID,
+		idempotent: true,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("read body: %w", err)
+		return nil, err
 	}
 	var env struct {
 		Data struct {
@@ -206,7 +202,7 @@ func (c *httpCoreProjectClient) GetProject(ctx context.Context, id string, userI
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(body, &env); err != nil {
-		return nil, fmt.Errorf("decode get project: %w", err)
+		return nil, ErrCoreUnavailable.Wrap(fmt.Errorf("decode get project: %w", err))
 	}
 	return &BaseProject{
 		ID:        env.Data.ID.String(),
@@ -226,28 +222,21 @@ func (c *httpCoreProjectClient) GetProject(ctx context.Context, id string, userI
 	------------------------------------------------------------------
 */
 func (c *httpCoreProjectClient) UpdateProject(ctx context.Context, id string, userID string, updates *UpdateProjectRequest) (*BaseProject, error) {
-	u := fmt.Sprintf("%s/projects/%s", c.baseURL, id)
 	payload := struct {
 		*UpdateProjectRequest
 		UserID string `json:"userId"`
 	}{updates, userID}
 	raw, _ := json.Marshal(payload)
 
-	req, _ := http.NewRequestWithContext(ctx, http.MethodPut, u, bytes.NewReader(raw))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("core-project update: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("core-project update %s: %s", resp.Status, body)
-	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.do(ctx, coreCall{
+		name:   "UpdateProject",
+		label:  "core-project update",
+		method: http.MethodPut,
+		url:    fmt.Sprintf("%s/projects/%s", c.baseURL, id),
+		body:   raw,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("read body: %w", err)
+		return nil, err
 	}
 	var env struct {
 		Data struct {
@@ -259,7 +248,7 @@ func (c *httpCoreProjectClient) UpdateProject(ctx context.Context, id string, us
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(body, &env); err != nil {
-		return nil, fmt.Errorf("decode update project: %w", err)
+		return nil, ErrCoreUnavailable.Wrap(fmt.Errorf("decode update project: %w", err))
 	}
 	return &BaseProject{
 		ID:        env.Data.ID.String(),
@@ -278,20 +267,14 @@ func (c *httpCoreProjectClient) UpdateProject(ctx context.Context, id string, us
 	------------------------------------------------------------------
 */
 func (c *httpCoreProjectClient) DeleteProject(ctx context.Context, id string, userID string) error {
-	u := fmt.Sprintf("%s/projects/%s", c.baseURL, id)
-	req, _ := http.NewRequestWithContext(ctx, http.MethodDelete, u, nil)
-	req.Header.Set("X-User-ID", userID)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return fmt.Errorf("core-project delete: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("core-project delete %s: %s", resp.Status, body)
-	}
-	return nil
+	_, err := c.do(ctx, coreCall{
+		name:   "DeleteProject",
+		label:  "core-project delete",
+		method: http.MethodDelete,
+		url:    fmt.Sprintf("%s/projects/%s", c.baseURL, id),
+		userID: userID,
+	})
+	return err
 }
 
 /*
@@ -302,21 +285,15 @@ func (c *httpCoreProjectClient) DeleteProject(ctx context.Context, id string, us
 	------------------------------------------------------------------
 */
 func (c *httpCoreProjectClient) GetUserProjects(ctx context.Context, userID string) ([]BaseProject, error) {
-	u := fmt.Sprintf("%s/projects?userId=%s", c.baseURL, url.QueryEscape(userID))
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("core-project list: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("core-project list %s: %s", resp.Status, body)
-	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.do(ctx, coreCall{
+		name:       "GetUserProjects",
+		label:      "core-project list",
+		method:     http.MethodGet,
+		url:        fmt.Sprintf("%s/projects?userId=%s", c.baseURL, url.QueryEscape(userID)),
+		idempotent: true,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("read body: %w", err)
+		return nil, err
 	}
 	var env struct {
 		Data struct {
@@ -330,7 +307,7 @@ func (c *httpCoreProjectClient) GetUserProjects(ctx context.Context, userID stri
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(body, &env); err != nil {
-		return nil, fmt.Errorf("decode list projects: %w", err)
+		return nil, ErrCoreUnavailable.Wrap(fmt.Errorf("decode list projects: %w", err))
 	}
 	out := make([]BaseProject, 0, len(env.Data.Data))
 	for _, p := range env.Data.Data {
@@ -353,21 +330,15 @@ func (c *httpCoreProjectClient) GetUserProjects(ctx context.Context, userID stri
 	------------------------------------------------------------------
 */
 func (c *httpCoreProjectClient) GetProjectMembers(ctx context.Context, id string, userID string) ([]ProjectMember, error) {
-	u := fmt.Sprintf("%s/projects/%s/members?userId=%s", c.baseURL, id, url.QueryEscape(userID))
-	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("core-project members: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("core-project members %s: %s", resp.Status, body)
-	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.do(ctx, coreCall{
+		name:       "GetProjectMembers",
+		label:      "core-project members",
+		method:     http.MethodGet,
+		url:        fmt.Sprintf("%s/projects/%s/members?userId=%s", c.baseURL, id, url.QueryEscape(userID)),
+		idempotent: true,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("read body: %w", err)
+		return nil, err
 	}
 	var env struct {
 		Data struct {
@@ -376,7 +347,7 @@ func (c *httpCoreProjectClient) GetProjectMembers(ctx context.Context, id string
 		} `json:"data"`
 	}
 	if err := json.Unmarshal(body, &env); err != nil {
-		return nil, fmt.Errorf("decode members: %w", err)
+		return nil, ErrCoreUnavailable.Wrap(fmt.Errorf("decode members: %w", err))
 	}
 	return env.Data.Members, nil
 }
@@ -390,29 +361,22 @@ func (c *httpCoreProjectClient) GetProjectMembers(ctx context.Context, id string
 	------------------------------------------------------------------
 */
 func (c *httpCoreProjectClient) AddProjectMember(ctx context.Context, id string, reqBody *AddMemberRequest) (*ProjectMember, error) {
-	u := fmt.Sprintf("%s/projects/%s/members", c.baseURL, id)
 	raw, _ := json.Marshal(reqBody)
-	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(raw))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("core-project add member: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("core-project add member %s: %s", resp.Status, body)
-	}
-	body, err := io.ReadAll(resp.Body)
+	body, err := c.do(ctx, coreCall{
+		name:   "AddProjectMember",
+		label:  "core-project add member",
+		method: http.MethodPost,
+		url:    fmt.Sprintf("%s/projects/%s/members", c.baseURL, id),
+		body:   raw,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("read body: %w", err)
+		return nil, err
 	}
 	var env struct {
 		Data ProjectMember `json:"data"`
 	}
 	if err := json.Unmarshal(body, &env); err != nil {
-		return nil, fmt.Errorf("decode add member: %w", err)
+		return nil, ErrCoreUnavailable.Wrap(fmt.Errorf("decode add member: %w", err))
 	}
 	return &env.Data, nil
 }
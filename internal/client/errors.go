@@ -0,0 +1,33 @@
+package clients
+
+import (
+	"net/http"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+)
+
+var (
+	// ErrCoreUnavailable is returned when project-core cannot be reached or fails.
+	ErrCoreUnavailable = apperr.Unavailable("core_unavailable", "project-core service unavailable")
+	// ErrCoreNotFound is returned when project-core does not know the resource.
+	ErrCoreNotFound = apperr.NotFound("core_not_found", "not found in project-core")
+	// ErrCoreForbidden is returned when project-core denies the user access.
+	ErrCoreForbidden = apperr.Forbidden("core_forbidden", "access denied by project-core")
+	// ErrCoreRejected is returned when project-core refuses the request itself.
+	ErrCoreRejected = apperr.Validation("core_rejected", "request rejected by project-core")
+)
+
+// statusError classifies a non-2xx response from project-core; detail keeps
+// the status line and body for troubleshooting.
+func statusError(status int, detail error) error {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return ErrCoreForbidden.Wrap(detail)
+	case status == http.StatusNotFound:
+		return ErrCoreNotFound.Wrap(detail)
+	case status == http.StatusTooManyRequests, status >= 500:
+		return ErrCoreUnavailable.Wrap(detail)
+	default:
+		return ErrCoreRejected.Wrap(detail)
+	}
+}
@@ -0,0 +1,285 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* ---------------------------------------------------------------------
+   Resilience: timeouts, retries and a circuit breaker
+   ---------------------------------------------------------------------
+   Every call to Core goes through httpCoreProjectClient.do. Each attempt
+   gets its own timeout; idempotent reads are retried with jittered
+   exponential backoff; after enough consecutive failures the breaker
+   opens and calls fail fast with ErrCoreUnavailable until a trial call
+   succeeds again. Denials (401/403) and other 4xx answers are answers,
+   not failures: they are never retried and keep the breaker closed.
+   ------------------------------------------------------------------ */
+
+// HTTPClientOptions tune how the HTTP client talks to Core
+type HTTPClientOptions struct {
+	Timeout          time.Duration            // Per attempt; 0 waits as long as the caller's context
+	CallTimeouts     map[string]time.Duration // Per call, by method name (e.g. "GetUserProjects"), overriding Timeout
+	MaxRetries       int                      // Extra attempts of GetProject, GetUserProjects and GetProjectMembers
+	RetryBaseDelay   time.Duration            // Backoff before the first retry; doubles per retry
+	RetryMaxDelay    time.Duration            // Cap of the backoff
+	BreakerThreshold int                      // Consecutive failures that open the breaker; 0 disables it
+	BreakerCooldown  time.Duration            // How long it stays open before a trial call
+}
+
+// DefaultHTTPClientOptions are used by NewCoreProjectHTTPClient
+func DefaultHTTPClientOptions() HTTPClientOptions {
+	return HTTPClientOptions{
+		Timeout:          5 * time.Second,
+		MaxRetries:       2,
+		RetryBaseDelay:   100 * time.Millisecond,
+		RetryMaxDelay:    time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  30 * time.Second,
+	}
+}
+
+// callNames are the CoreProjectClient methods CallTimeouts may name
+var callNames = []string{
+	"CreateBaseProject", "GetProject", "UpdateProject", "DeleteProject",
+	"GetUserProjects", "GetProjectMembers", "AddProjectMember",
+}
+
+// ParseCallTimeouts reads per-call timeouts written as
+// "GetUserProjects=10s,GetProject=2s"
+func ParseCallTimeouts(s string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	for _, part := range strings.Split(s, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok {
+			return nil, fmt.Errorf("call timeout %q: want Name=duration", part)
+		}
+		known := false
+		for _, call := range callNames {
+			known = known || call == name
+		}
+		if !known {
+			return nil, fmt.Errorf("call timeout %q: unknown call (use one of %s)", name, strings.Join(callNames, ", "))
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("call timeout %q: invalid duration %q", name, value)
+		}
+		timeouts[name] = d
+	}
+	return timeouts, nil
+}
+
+func (o *HTTPClientOptions) timeout(call string) time.Duration {
+	if d, ok := o.CallTimeouts[call]; ok {
+		return d
+	}
+	return o.Timeout
+}
+
+// backoff is the wait before retry n (1-based): exponential with equal
+// jitter, so retries of many callers don't hit Core in lockstep
+func (o *HTTPClientOptions) backoff(n int) time.Duration {
+	d := o.RetryBaseDelay << (n - 1)
+	if d <= 0 || (o.RetryMaxDelay > 0 && d > o.RetryMaxDelay) {
+		d = o.RetryMaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// coreCall describes one request to Core
+type coreCall struct {
+	name       string // Method name, for CallTimeouts
+	label      string // Prefix of error messages, e.g. "core-project get"
+	method     string
+	url        string
+	userID     string // Sent as X-User-ID when set
+	body       []byte // JSON payload, nil for none
+	idempotent bool   // Safe to retry
+}
+
+// do sends the call, retrying idempotent ones, and returns the body of a
+// 2xx response. Errors are classified as in statusError; transport
+// failures, timeouts and an open breaker are ErrCoreUnavailable.
+func (c *httpCoreProjectClient) do(ctx context.Context, call coreCall) ([]byte, error) {
+	attempts := 1
+	if call.idempotent {
+		attempts += c.opts.MaxRetries
+	}
+	var err error
+	for n := 0; n < attempts; n++ {
+		if n > 0 {
+			timer := time.NewTimer(c.opts.backoff(n))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, err
+			case <-timer.C:
+			}
+		}
+		var body []byte
+		body, err = c.attempt(ctx, call)
+		if err == nil {
+			return body, nil
+		}
+		if !errors.Is(err, ErrCoreUnavailable) || errors.Is(err, errCircuitOpen) || ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (c *httpCoreProjectClient) attempt(ctx context.Context, call coreCall) ([]byte, error) {
+	timeout := c.opts.timeout(call.name)
+	attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	var payload io.Reader
+	if call.body != nil {
+		payload = bytes.NewReader(call.body)
+	}
+	req, err := http.NewRequestWithContext(attemptCtx, call.method, call.url, payload)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", call.label, err)
+	}
+	if call.body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if call.userID != "" {
+		req.Header.Set("X-User-ID", call.userID)
+	}
+
+	if err := c.breaker.allow(); err != nil {
+		return nil, ErrCoreUnavailable.Wrap(fmt.Errorf("%s: %w", call.label, err))
+	}
+	resp, err := c.http.Do(req)
+	var raw []byte
+	if err == nil {
+		raw, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err != nil {
+		switch {
+		case ctx.Err() != nil:
+			// The caller gave up; that says nothing about Core
+			c.breaker.release()
+		case errors.Is(attemptCtx.Err(), context.DeadlineExceeded):
+			c.breaker.failure()
+			err = fmt.Errorf("timed out after %s: %w", timeout, err)
+		default:
+			c.breaker.failure()
+		}
+		return nil, ErrCoreUnavailable.Wrap(fmt.Errorf("%s: %w", call.label, err))
+	}
+
+	if resp.StatusCode >= 300 {
+		err := statusError(resp.StatusCode, fmt.Errorf("%s %s: %s", call.label, resp.Status, raw))
+		if errors.Is(err, ErrCoreUnavailable) {
+			c.breaker.failure()
+		} else {
+			c.breaker.success()
+		}
+		return nil, err
+	}
+	c.breaker.success()
+	return raw, nil
+}
+
+// errCircuitOpen is wrapped in ErrCoreUnavailable while the breaker is open
+var errCircuitOpen = errors.New("circuit breaker open, project-core not called")
+
+// breaker is a consecutive-failure circuit breaker. It is closed while
+// failures stay below threshold, open for cooldown once they reach it,
+// then half-open: one trial call goes through, and its outcome closes or
+// reopens it. A nil breaker or a zero threshold never opens.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool // A trial call is in flight
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a call may go out. Every allowed call must end in
+// success, failure or release.
+func (b *breaker) allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures < b.threshold {
+		return nil
+	}
+	if b.probing || time.Since(b.openedAt) < b.cooldown {
+		return errCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+func (b *breaker) success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures >= b.threshold {
+		log.Printf("[INFO] project-core circuit closed")
+	}
+	b.failures, b.probing = 0, false
+}
+
+func (b *breaker) failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		if b.failures == b.threshold {
+			log.Printf("[WARN] project-core circuit open after %d consecutive failures", b.failures)
+		}
+		b.openedAt = time.Now()
+	}
+}
+
+// release ends a call without a verdict on Core's health
+func (b *breaker) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+)
+
+// SessionTimes is the time breakdown of a TimeSession, in minutes.
+// Net time is what gets billed: gross session time minus breaks.
+type SessionTimes struct {
+	GrossMinutes int `json:"grossMinutes"`
+	BreakMinutes int `json:"breakMinutes"`
+	NetMinutes   int `json:"netMinutes"`
+}
+
+// Times computes the gross/break/net breakdown of the session from its breaks.
+// Open sessions and open breaks are measured up to now; breaks are clipped to
+// the session window so a stray break row can never make net time negative.
+func (s *TimeSession) Times(breaks []SessionBreak, now time.Time) SessionTimes {
+	end := now
+	if s.EndTime != nil {
+		end = *s.EndTime
+	}
+	if end.Before(s.StartTime) {
+		return SessionTimes{}
+	}
+
+	var breakTime time.Duration
+	for _, b := range breaks {
+		bStart := b.StartTime
+		bEnd := end
+		if b.EndTime != nil {
+			bEnd = *b.EndTime
+		}
+		if bStart.Before(s.StartTime) {
+			bStart = s.StartTime
+		}
+		if bEnd.After(end) {
+			bEnd = end
+		}
+		if bEnd.After(bStart) {
+			breakTime += bEnd.Sub(bStart)
+		}
+	}
+
+	gross := int(end.Sub(s.StartTime).Minutes())
+	brk := int(breakTime.Minutes())
+	if brk > gross {
+		brk = gross
+	}
+	return SessionTimes{
+		GrossMinutes: gross,
+		BreakMinutes: brk,
+		NetMinutes:   gross - brk,
+	}
+}
+
+// CostFor returns the cost of netMinutes at the session's hourly rate,
+// rounded to the cent.
+func (s *TimeSession) CostFor(netMinutes int) money.Amount {
+	if s.HourlyRate == nil {
+		return 0
+	}
+	return CostAtRate(netMinutes, *s.HourlyRate)
+}
+
+// RevenueFor returns what netMinutes of the session bill to the client.
+func (s *TimeSession) RevenueFor(netMinutes int) money.Amount {
+	if s.NonBillable || s.BillableRate == nil {
+		return 0
+	}
+	return CostAtRate(netMinutes, *s.BillableRate)
+}
+
+// CostAtRate returns the cost of netMinutes at hourlyRate, rounded to the cent.
+func CostAtRate(netMinutes int, hourlyRate money.Amount) money.Amount {
+	return hourlyRate.ForMinutes(netMinutes)
+}
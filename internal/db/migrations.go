@@ -0,0 +1,107 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// moneyColumns lists the float columns that now hold exact minor units
+var moneyColumns = []struct{ table, from, to string }{
+	{"professional_projects", "total_salary_cost", "total_salary_cost_minor"},
+	{"professional_projects", "billable_rate", "billable_rate_minor"},
+	{"professional_projects", "total_revenue", "total_revenue_minor"},
+	{"project_assignments", "cost_per_hour", "cost_per_hour_minor"},
+	{"project_assignments", "billable_rate", "billable_rate_minor"},
+	{"project_assignments", "total_cost", "total_cost_minor"},
+	{"project_assignments", "total_revenue", "total_revenue_minor"},
+	{"assignment_rates", "cost_per_hour", "cost_per_hour_minor"},
+	{"assignment_rates", "billable_rate", "billable_rate_minor"},
+	{"time_sessions", "hourly_rate", "hourly_rate_minor"},
+	{"time_sessions", "session_cost", "session_cost_minor"},
+	{"time_sessions", "billable_rate", "billable_rate_minor"},
+	{"time_sessions", "session_revenue", "session_revenue_minor"},
+	{"client_rates", "billable_rate", "billable_rate_minor"},
+}
+
+// MigrateMoneyToMinorUnits converts the float money columns of an existing
+// database to integer cents, rounding half away from zero. It must run before
+// auto-migration, which would otherwise add the new columns empty; columns
+// already converted are skipped, so it is safe to run on every start.
+func MigrateMoneyToMinorUnits(gdb *gorm.DB) error {
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, c := range moneyColumns {
+			if !migrator.HasTable(c.table) || !migrator.HasColumn(c.table, c.from) || migrator.HasColumn(c.table, c.to) {
+				continue
+			}
+			if err := migrator.RenameColumn(c.table, c.from, c.to); err != nil {
+				return fmt.Errorf("failed to rename %s.%s: %w", c.table, c.from, err)
+			}
+			// Postgres ROUND on numeric rounds half away from zero, matching money.ForMinutes.
+			sql := fmt.Sprintf(`ALTER TABLE %q ALTER COLUMN %q TYPE bigint USING ROUND(%q::numeric * 100)::bigint`,
+				c.table, c.to, c.to)
+			if err := tx.Exec(sql).Error; err != nil {
+				return fmt.Errorf("failed to convert %s.%s to minor units: %w", c.table, c.to, err)
+			}
+		}
+		return nil
+	})
+}
+
+// MigrateSessionBreakdown fills the break and net minutes of sessions finished
+// before breaks were deducted, and reprices their cost on net time, so
+// invoices, exports and total increments don't count them as 0 minutes. It
+// adds the columns itself, before auto-migration would add them empty, which
+// makes it run once. Money columns must already be in minor units.
+func MigrateSessionBreakdown(gdb *gorm.DB) error {
+	migrator := gdb.Migrator()
+	if !migrator.HasTable(&TimeSession{}) || migrator.HasColumn(&TimeSession{}, "NetMinutes") {
+		return nil
+	}
+	return gdb.Transaction(func(tx *gorm.DB) error {
+		migrator := tx.Migrator()
+		for _, field := range []string{"BreakMinutes", "NetMinutes"} {
+			if migrator.HasColumn(&TimeSession{}, field) {
+				continue
+			}
+			if err := migrator.AddColumn(&TimeSession{}, field); err != nil {
+				return fmt.Errorf("failed to add time_sessions.%s: %w", field, err)
+			}
+		}
+
+		var sessions []TimeSession
+		return tx.Select("id", "start_time", "end_time", "hourly_rate_minor").
+			Where("end_time IS NOT NULL").
+			FindInBatches(&sessions, 500, func(batch *gorm.DB, _ int) error {
+				ids := make([]uint, 0, len(sessions))
+				for _, session := range sessions {
+					ids = append(ids, session.ID)
+				}
+				var breaks []SessionBreak
+				if err := tx.Select("session_id", "start_time", "end_time").
+					Where("session_id IN ?", ids).Find(&breaks).Error; err != nil {
+					return fmt.Errorf("failed to load session breaks: %w", err)
+				}
+				bySession := make(map[uint][]SessionBreak, len(sessions))
+				for _, b := range breaks {
+					bySession[b.SessionID] = append(bySession[b.SessionID], b)
+				}
+
+				for i := range sessions {
+					session := &sessions[i]
+					times := session.Times(bySession[session.ID], time.Now())
+					if err := tx.Model(&TimeSession{}).Where("id = ?", session.ID).UpdateColumns(map[string]interface{}{
+						"duration_minutes":   times.GrossMinutes,
+						"break_minutes":      times.BreakMinutes,
+						"net_minutes":        times.NetMinutes,
+						"session_cost_minor": int64(session.CostFor(times.NetMinutes)),
+					}).Error; err != nil {
+						return fmt.Errorf("failed to fill the breakdown of session %d: %w", session.ID, err)
+					}
+				}
+				return nil
+			}).Error
+	})
+}
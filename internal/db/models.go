@@ -2,19 +2,28 @@ package db
 
 import (
 	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
 )
 
 // ProfessionalProject extends BaseProject from project-core with time tracking capabilities
 type ProfessionalProject struct {
-	ID              uint      `json:"id" gorm:"primaryKey"`
-	BaseProjectID   string    `json:"baseProjectId" gorm:"uniqueIndex;not null"` // Links to project-core BaseProject
-	Title           string    `json:"title"`                                     // Title
-	ClientName      *string   `json:"clientName"`                                // Optional client (e.g., "THD" for TCS project)
-	TotalSalaryCost float64   `json:"totalSalaryCost" gorm:"default:0"`          // Calculated field
-	TotalHours      float64   `json:"totalHours" gorm:"default:0"`               // Calculated field
-	IsActive        bool      `json:"isActive" gorm:"default:true"`              // Project status
-	CreatedAt       time.Time `json:"createdAt"`
-	UpdatedAt       time.Time `json:"updatedAt"`
+	ID                 uint          `json:"id" gorm:"primaryKey"`
+	BaseProjectID      string        `json:"baseProjectId" gorm:"uniqueIndex;not null"`                       // Links to project-core BaseProject
+	Title              string        `json:"title"`                                                           // Title
+	ClientName         *string       `json:"clientName"`                                                      // Optional client (e.g., "THD" for TCS project)
+	TotalSalaryCost    money.Amount  `json:"totalSalaryCost" gorm:"column:total_salary_cost_minor;default:0"` // Calculated field (on net hours), in TotalsCurrency
+	TotalHours         float64       `json:"totalHours" gorm:"default:0"`                                     // Calculated field: net hours, billable or not
+	TotalGrossHours    float64       `json:"totalGrossHours" gorm:"default:0"`                                // Calculated field: hours including breaks
+	TotalBreakHours    float64       `json:"totalBreakHours" gorm:"default:0"`                                // Calculated field
+	BillableRate       *money.Amount `json:"billableRate" gorm:"column:billable_rate_minor"`                  // Default hourly rate billed to the client
+	TotalRevenue       money.Amount  `json:"totalRevenue" gorm:"column:total_revenue_minor;default:0"`        // Calculated: billable net hours * billable rate, in TotalsCurrency
+	TotalsCurrency     string        `json:"totalsCurrency" gorm:"size:3;default:''"`                         // Of TotalSalaryCost and TotalRevenue; empty when sessions are priced in several currencies
+	TotalBillableHours float64       `json:"totalBillableHours" gorm:"default:0"`                             // Calculated: net hours of billable sessions
+	Currency           string        `json:"currency" gorm:"size:3;default:''"`                               // Of BillableRate; empty uses the company default
+	IsActive           bool          `json:"isActive" gorm:"default:true"`                                    // Project status
+	CreatedAt          time.Time     `json:"createdAt"`
+	UpdatedAt          time.Time     `json:"updatedAt"`
 
 	// Relations
 	ProjectAssignments []ProjectAssignment `json:"projectAssignments" gorm:"foreignKey:ParentProjectID"`
@@ -26,39 +35,82 @@ type ProfessionalProject struct {
 // A single user may have multiple assignments to the same project if their rate or role changes over time.
 
 type ProjectAssignment struct {
-	ID              uint      `json:"id" gorm:"primaryKey"`
-	ParentProjectID uint      `json:"parentProjectId" gorm:"not null"` // Links to ProfessionalProject
-	WorkerUserID    string    `json:"workerUserId" gorm:"not null"`    // Single worker only (privacy model)
-	CostPerHour     float64   `json:"costPerHour" gorm:"not null"`     // Freelance rate
-	HoursDedicated  float64   `json:"hoursDedicated" gorm:"default:0"` // Calculated total
-	TotalCost       float64   `json:"totalCost" gorm:"default:0"`      // Calculated: hours * rate
-	Description     *string   `json:"description"`                     // Optional description
-	IsActive        bool      `json:"isActive" gorm:"default:true"`
-	CreatedAt       time.Time `json:"createdAt"`
-	UpdatedAt       time.Time `json:"updatedAt"`
+	ID              uint          `json:"id" gorm:"primaryKey"`
+	ParentProjectID uint          `json:"parentProjectId" gorm:"not null"`                          // Links to ProfessionalProject
+	WorkerUserID    string        `json:"workerUserId" gorm:"not null"`                             // Single worker only (privacy model)
+	CostPerHour     money.Amount  `json:"costPerHour" gorm:"column:cost_per_hour_minor;not null"`   // Freelance rate
+	Currency        string        `json:"currency" gorm:"default:'USD'"`                            // Currency of CostPerHour (ISO 4217)
+	BillableRate    *money.Amount `json:"billableRate" gorm:"column:billable_rate_minor"`           // Billed to the client per hour; overrides the project rate
+	HoursDedicated  float64       `json:"hoursDedicated" gorm:"default:0"`                          // Calculated total
+	TotalCost       money.Amount  `json:"totalCost" gorm:"column:total_cost_minor;default:0"`       // Calculated: hours * rate, in TotalsCurrency
+	TotalRevenue    money.Amount  `json:"totalRevenue" gorm:"column:total_revenue_minor;default:0"` // Calculated: billable hours * billable rate, in TotalsCurrency
+	TotalsCurrency  string        `json:"totalsCurrency" gorm:"size:3;default:''"`                  // Of TotalCost and TotalRevenue; empty when sessions are priced in several currencies
+	Description     *string       `json:"description"`                                              // Optional description
+	IsActive        bool          `json:"isActive" gorm:"default:true"`
+	CreatedAt       time.Time     `json:"createdAt"`
+	UpdatedAt       time.Time     `json:"updatedAt"`
 
 	// Relations
 	ParentProject ProfessionalProject `json:"parentProject" gorm:"foreignKey:ParentProjectID"`
 	TimeSessions  []TimeSession       `json:"timeSessions" gorm:"foreignKey:ProjectAssignmentID"`
+	Rates         []AssignmentRate    `json:"rates,omitempty" gorm:"foreignKey:AssignmentID"`
+}
+
+// ProjectCurrencyTotals is the money of a project's finished work sessions,
+// or of one assignment's, recorded in one currency. Amounts in different
+// currencies are never added up: the scalar totals of a project or
+// assignment are only filled while all of its money is in one currency.
+type ProjectCurrencyTotals struct {
+	ProjectID    uint         `json:"projectId" gorm:"primaryKey;autoIncrement:false"`
+	AssignmentID uint         `json:"assignmentId" gorm:"primaryKey;autoIncrement:false"` // 0 for the project as a whole
+	Currency     string       `json:"currency" gorm:"primaryKey;size:3"`
+	TotalCost    money.Amount `json:"totalCost" gorm:"column:total_cost_minor;not null;default:0"`
+	TotalRevenue money.Amount `json:"totalRevenue" gorm:"column:total_revenue_minor;not null;default:0"`
+	UpdatedAt    time.Time    `json:"updatedAt"`
+}
+
+// AssignmentRate is one period of an assignment's rate history. Periods of an
+// assignment don't overlap. Sessions take the period in force at their start;
+// the assignment's CostPerHour follows the latest period already in force when set.
+type AssignmentRate struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	AssignmentID  uint          `json:"assignmentId" gorm:"not null;index"`
+	CostPerHour   money.Amount  `json:"costPerHour" gorm:"column:cost_per_hour_minor;not null"`
+	BillableRate  *money.Amount `json:"billableRate" gorm:"column:billable_rate_minor"`
+	Currency      string        `json:"currency" gorm:"not null;default:'USD'"` // ISO 4217, of both rates
+	EffectiveFrom time.Time     `json:"effectiveFrom" gorm:"not null"`
+	EffectiveTo   *time.Time    `json:"effectiveTo"` // Exclusive; nil while the rate is current
+	CreatedAt     time.Time     `json:"createdAt"`
 }
 
 // TimeSession represents individual work sessions with detailed tracking
 type TimeSession struct {
-	ID                  uint       `json:"id" gorm:"primaryKey"`
-	ProjectID           uint       `json:"projectId" gorm:"not null"` // Professional project ID
-	ProjectAssignmentID *uint      `json:"projectAssignmentId"`       // Optional freelance sub-project
-	UserID              string     `json:"userId" gorm:"not null"`    // Worker
-	CompanyID           string     `json:"companyId" gorm:"not null"` // Company context
-	StartTime           time.Time  `json:"startTime" gorm:"not null"`
-	EndTime             *time.Time `json:"endTime"`                           // nil for active sessions
-	SessionType         string     `json:"sessionType" gorm:"default:'work'"` // work, break, lunch, brb
-	DurationMinutes     int        `json:"durationMinutes" gorm:"default:0"`  // Calculated duration
-	HourlyRate          *float64   `json:"hourlyRate"`                        // Rate at time of session
-	SessionCost         float64    `json:"sessionCost" gorm:"default:0"`      // Calculated cost
-	Notes               *string    `json:"notes"`                             // Optional session notes
-	IsActive            bool       `json:"isActive" gorm:"default:false"`     // Is currently active
-	CreatedAt           time.Time  `json:"createdAt"`
-	UpdatedAt           time.Time  `json:"updatedAt"`
+	ID                  uint          `json:"id" gorm:"primaryKey"`
+	ProjectID           uint          `json:"projectId" gorm:"not null"` // Professional project ID
+	ProjectAssignmentID *uint         `json:"projectAssignmentId"`       // Optional freelance sub-project
+	UserID              string        `json:"userId" gorm:"not null"`    // Worker
+	CompanyID           string        `json:"companyId" gorm:"not null"` // Company context
+	StartTime           time.Time     `json:"startTime" gorm:"not null"`
+	EndTime             *time.Time    `json:"endTime"`                                                      // nil for active sessions
+	SessionType         string        `json:"sessionType" gorm:"default:'work'"`                            // work, break, lunch, brb
+	DurationMinutes     int           `json:"durationMinutes" gorm:"default:0"`                             // Calculated gross duration
+	BreakMinutes        int           `json:"breakMinutes" gorm:"default:0"`                                // Calculated sum of breaks
+	NetMinutes          int           `json:"netMinutes" gorm:"default:0"`                                  // Calculated: duration - breaks
+	HourlyRate          *money.Amount `json:"hourlyRate" gorm:"column:hourly_rate_minor"`                   // Rate at time of session
+	RateSource          string        `json:"rateSource" gorm:"default:''"`                                 // assignment, client; empty when no rate
+	SessionCost         money.Amount  `json:"sessionCost" gorm:"column:session_cost_minor;default:0"`       // Calculated cost (on net minutes)
+	BillableRate        *money.Amount `json:"billableRate" gorm:"column:billable_rate_minor"`               // Rate billed to the client at time of session
+	NonBillable         bool          `json:"nonBillable" gorm:"default:false"`                             // Internal work the client is not billed for
+	SessionRevenue      money.Amount  `json:"sessionRevenue" gorm:"column:session_revenue_minor;default:0"` // Calculated revenue (on net minutes), 0 when non-billable
+	Currency            string        `json:"currency" gorm:"size:3;default:'USD'"`                         // Of HourlyRate and SessionCost
+	BillableCurrency    string        `json:"billableCurrency" gorm:"size:3;default:'USD'"`                 // Of BillableRate and SessionRevenue
+	Notes               *string       `json:"notes"`                                                        // Optional session notes
+	IsActive            bool          `json:"isActive" gorm:"default:false"`                                // Is currently active
+	IsManualEntry       bool          `json:"isManualEntry" gorm:"default:false"`                           // Entered after the fact, not timed live
+	EndReason           *string       `json:"endReason"`                                                    // Why the session was closed automatically; nil when finished by the user
+	InvoiceID           *uint         `json:"invoiceId" gorm:"index"`                                       // Set once billed; invoiced sessions can't be changed
+	CreatedAt           time.Time     `json:"createdAt"`
+	UpdatedAt           time.Time     `json:"updatedAt"`
 
 	// Relations
 	Project           ProfessionalProject `json:"project" gorm:"foreignKey:ProjectID"`
@@ -97,30 +149,342 @@ type UserActiveSession struct {
 	CurrentBreak *SessionBreak `json:"currentBreak" gorm:"foreignKey:CurrentBreakID"`
 }
 
+// ClientRate is the default hourly rate billed to a client within a company,
+// used when neither the assignment nor the project sets one. An empty
+// CompanyID covers personal projects.
+type ClientRate struct {
+	ID           uint         `json:"id" gorm:"primaryKey"`
+	CompanyID    string       `json:"companyId" gorm:"uniqueIndex:idx_client_rate"`
+	ClientName   string       `json:"clientName" gorm:"not null;uniqueIndex:idx_client_rate"`
+	BillableRate money.Amount `json:"billableRate" gorm:"column:billable_rate_minor;not null"`
+	Currency     string       `json:"currency" gorm:"size:3;not null;default:'USD'"`
+	UpdatedBy    string       `json:"updatedBy" gorm:"not null"`
+	CreatedAt    time.Time    `json:"createdAt"`
+	UpdatedAt    time.Time    `json:"updatedAt"`
+}
+
+// Invoice bills a client for finished work sessions, which point back to it
+// through TimeSession.InvoiceID so no session is billed twice.
+type Invoice struct {
+	ID                 uint          `json:"id" gorm:"primaryKey"`
+	Number             string        `json:"number" gorm:"not null;uniqueIndex:idx_invoice_sequence_number"` // e.g. INV-2026-0007, sequential per SequenceKey and year
+	SequenceKey        string        `json:"-" gorm:"not null;uniqueIndex:idx_invoice_sequence_number"`      // Sequence the number was taken from, see InvoiceSequenceKey
+	CompanyID          string        `json:"companyId"`                                                      // Issuing company; empty for personal projects
+	ClientName         string        `json:"clientName" gorm:"not null"`
+	ProjectID          *uint         `json:"projectId" gorm:"index"` // nil when billing every project of the client
+	PeriodStart        time.Time     `json:"periodStart" gorm:"not null"`
+	PeriodEnd          time.Time     `json:"periodEnd" gorm:"not null"` // Exclusive
+	Currency           string        `json:"currency" gorm:"size:3;not null"`
+	RoundingMinutes    int           `json:"roundingMinutes" gorm:"default:0"` // Line time rounded up to this increment; 0 bills exact minutes
+	Subtotal           money.Amount  `json:"subtotal" gorm:"column:subtotal_minor;not null"`
+	TaxLabel           string        `json:"taxLabel"`
+	TaxRateBasisPoints int           `json:"taxRateBasisPoints" gorm:"default:0"` // 2150 = 21.5%
+	TaxAmount          money.Amount  `json:"taxAmount" gorm:"column:tax_amount_minor;not null"`
+	Total              money.Amount  `json:"total" gorm:"column:total_minor;not null"`
+	Status             string        `json:"status" gorm:"not null;default:'issued'"` // issued, void
+	Notes              *string       `json:"notes"`
+	IssuedBy           string        `json:"issuedBy" gorm:"not null"`
+	IssuedAt           time.Time     `json:"issuedAt" gorm:"not null"`
+	VoidedAt           *time.Time    `json:"voidedAt"`
+	CreatedAt          time.Time     `json:"createdAt"`
+	UpdatedAt          time.Time     `json:"updatedAt"`
+	Lines              []InvoiceLine `json:"lines" gorm:"foreignKey:InvoiceID"`
+}
+
+// InvoiceLine groups the invoiced sessions of one project billed at one rate
+type InvoiceLine struct {
+	ID            uint         `json:"id" gorm:"primaryKey"`
+	InvoiceID     uint         `json:"invoiceId" gorm:"not null;index"`
+	Position      int          `json:"position" gorm:"not null"`
+	ProjectID     uint         `json:"projectId" gorm:"not null"`
+	Description   string       `json:"description" gorm:"not null"`
+	Sessions      int          `json:"sessions"`
+	WorkedMinutes int          `json:"workedMinutes"` // Net minutes of the sessions
+	BilledMinutes int          `json:"billedMinutes"` // After the invoice's rounding
+	Rate          money.Amount `json:"rate" gorm:"column:rate_minor;not null"`
+	Amount        money.Amount `json:"amount" gorm:"column:amount_minor;not null"` // Rate for BilledMinutes, rounded once
+}
+
+// InvoiceSequence hands out invoice numbers without gaps per company, or per
+// user for personal invoices, and year
+type InvoiceSequence struct {
+	CompanyID  string `gorm:"primaryKey"` // InvoiceSequenceKey of the company or user
+	Year       int    `gorm:"primaryKey;autoIncrement:false"`
+	LastNumber int    `gorm:"not null;default:0"`
+}
+
+// InvoiceSequenceKey is the sequence an invoice of companyID issued by userID
+// is numbered in: the company's, or the user's own for personal projects, so
+// freelancers don't share one sequence.
+func InvoiceSequenceKey(companyID, userID string) string {
+	if companyID == "" {
+		return "user:" + userID
+	}
+	return companyID
+}
+
+// Invoice statuses
+const (
+	InvoiceStatusIssued = "issued"
+	InvoiceStatusVoid   = "void"
+)
+
+// DocumentTemplate customizes the HTML and PDF documents rendered for a
+// company ("company:<id>") or for one user's own documents ("user:<id>").
+// The *HTML fields optionally replace the built-in html/template layouts.
+type DocumentTemplate struct {
+	Scope         string    `json:"scope" gorm:"primaryKey"`
+	DisplayName   string    `json:"displayName"`
+	Address       string    `json:"address"`  // One line per row
+	Locale        string    `json:"locale"`   // e.g. en-US, pt-BR
+	Timezone      string    `json:"timezone"` // IANA name, e.g. America/Sao_Paulo
+	Logo          []byte    `json:"-" gorm:"type:bytea"`
+	LogoMime      string    `json:"logoMime"` // image/png or image/jpeg
+	Footer        string    `json:"footer"`
+	InvoiceHTML   *string   `json:"invoiceHtml"`
+	ReportHTML    *string   `json:"reportHtml"`
+	TimesheetHTML *string   `json:"timesheetHtml"`
+	UpdatedBy     string    `json:"updatedBy" gorm:"not null"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// CompanySettings holds per-company defaults kept by this service; the
+// company itself lives in project-core.
+type CompanySettings struct {
+	CompanyID string    `json:"companyId" gorm:"primaryKey"`
+	Currency  string    `json:"currency" gorm:"size:3;not null;default:'USD'"` // Default for projects that set none
+	UpdatedBy string    `json:"updatedBy" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ExchangeRate is the price of one BaseCurrency unit in QuoteCurrency on
+// RateDate. Conversions use the latest rate on or before the date asked for.
+type ExchangeRate struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	BaseCurrency  string    `json:"baseCurrency" gorm:"size:3;not null;uniqueIndex:idx_exchange_rate"`
+	QuoteCurrency string    `json:"quoteCurrency" gorm:"size:3;not null;uniqueIndex:idx_exchange_rate"`
+	RateDate      time.Time `json:"rateDate" gorm:"type:date;not null;uniqueIndex:idx_exchange_rate"`
+	Rate          string    `json:"rate" gorm:"type:numeric(20,10);not null"` // Exact decimal, see money.ParseExchangeRate
+	UpdatedBy     string    `json:"updatedBy" gorm:"not null"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// PeriodLock closes a time range for entry and correction (e.g. after payroll
+// or a timesheet was submitted). A nil UserID locks the range for the whole company.
+type PeriodLock struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	CompanyID string    `json:"companyId" gorm:"not null;index"`
+	UserID    *string   `json:"userId" gorm:"index"`
+	StartTime time.Time `json:"startTime" gorm:"not null"`
+	EndTime   time.Time `json:"endTime" gorm:"not null"`
+	Reason    *string   `json:"reason"`
+	LockedBy  string    `json:"lockedBy" gorm:"not null"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// CalendarFeed is a secret iCalendar subscription URL over one user's
+// sessions, optionally narrowed to a company or a project. Only a hash of the
+// token is kept; the token itself is shown once, when the feed is created.
+type CalendarFeed struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	UserID         string     `json:"userId" gorm:"not null;index"`
+	Name           string     `json:"name"`
+	TokenHash      string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	CompanyID      *string    `json:"companyId"` // Only sessions in this company
+	ProjectID      *uint      `json:"projectId"` // Only sessions of this project
+	LastAccessedAt *time.Time `json:"lastAccessedAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+}
+
+// ImportRule maps imported calendar events onto a project. Keyword matches
+// the event's summary, description or location case-insensitively; Attendee
+// matches the organizer's or an attendee's email. When both are set both must
+// match. Rules are tried by Priority, then ID; the first match wins.
+type ImportRule struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"userId" gorm:"not null;index"`
+	Keyword   *string   `json:"keyword"`
+	Attendee  *string   `json:"attendee"`
+	ProjectID uint      `json:"projectId" gorm:"not null"`
+	CompanyID string    `json:"companyId" gorm:"not null"`
+	Priority  int       `json:"priority"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// DraftSession is a session proposed from an imported calendar event. It
+// waits for review until the user confirms it into a TimeSession or
+// discards it. Each event occurrence (SourceUID, SourceStart) is imported
+// once per user, so uploading the same calendar again adds only new events.
+type DraftSession struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"userId" gorm:"not null;uniqueIndex:idx_draft_source;index:idx_draft_status"`
+	ProjectID   *uint     `json:"projectId"` // Nil until a rule or the user maps it
+	CompanyID   *string   `json:"companyId"`
+	StartTime   time.Time `json:"startTime" gorm:"not null"`
+	EndTime     time.Time `json:"endTime" gorm:"not null"`
+	Title       string    `json:"title"`
+	Notes       *string   `json:"notes"`
+	SourceUID   string    `json:"sourceUid" gorm:"not null;uniqueIndex:idx_draft_source"`
+	SourceStart time.Time `json:"sourceStart" gorm:"not null;uniqueIndex:idx_draft_source"` // Occurrence start in the calendar, kept when StartTime is edited
+	Status      string    `json:"status" gorm:"not null;default:pending;index:idx_draft_status"`
+	RuleID      *uint     `json:"ruleId"`    // The ImportRule that mapped it, if any
+	SessionID   *uint     `json:"sessionId"` // Set once confirmed
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// Draft session statuses
+const (
+	DraftStatusPending   = "pending"
+	DraftStatusConfirmed = "confirmed"
+	DraftStatusDiscarded = "discarded"
+)
+
+// ImportBatch is one upload of another time tracker's export. It is planned
+// (a dry run with a diff report) when uploaded and applied on request; the
+// normalized entries are kept until then.
+type ImportBatch struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     string     `json:"userId" gorm:"not null;index"`
+	Source     string     `json:"source" gorm:"not null"` // toggl, clockify, harvest
+	CompanyID  string     `json:"companyId" gorm:"not null"`
+	SourceUser string     `json:"sourceUser"` // Whose entries of a multi-user export to import; empty for all
+	FileName   string     `json:"fileName"`
+	Status     string     `json:"status" gorm:"not null"`
+	Entries    []byte     `json:"-" gorm:"type:bytea"` // JSON of the normalized entries; cleared once applied
+	Report     []byte     `json:"-" gorm:"type:bytea"` // JSON of the latest report
+	CreatedAt  time.Time  `json:"createdAt"`
+	AppliedAt  *time.Time `json:"appliedAt"`
+}
+
+// Import batch statuses
+const (
+	ImportStatusPlanned  = "planned"
+	ImportStatusApplying = "applying"
+	ImportStatusApplied  = "applied"
+)
+
+// ImportMapping ties a client and project of an import source to a
+// professional project, so later imports land in the same place
+type ImportMapping struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"userId" gorm:"not null;uniqueIndex:idx_import_mapping"`
+	Source      string    `json:"source" gorm:"not null;uniqueIndex:idx_import_mapping"`
+	ClientName  string    `json:"clientName" gorm:"not null;uniqueIndex:idx_import_mapping"`
+	ProjectName string    `json:"projectName" gorm:"not null;uniqueIndex:idx_import_mapping"`
+	ProjectID   uint      `json:"projectId" gorm:"not null"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// ImportedEntry records an imported source entry, so importing it again is
+// a no-op even after its session was edited or deleted. Fingerprint tells
+// whether the entry changed in the source since.
+type ImportedEntry struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"userId" gorm:"not null;uniqueIndex:idx_imported_entry"`
+	Source      string    `json:"source" gorm:"not null;uniqueIndex:idx_imported_entry"`
+	ExternalID  string    `json:"externalId" gorm:"not null;uniqueIndex:idx_imported_entry"`
+	Fingerprint string    `json:"fingerprint" gorm:"not null"`
+	SessionID   uint      `json:"sessionId" gorm:"not null"`
+	BatchID     uint      `json:"batchId" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
 // ProjectTimeReport represents aggregated time data for reporting
 type ProjectTimeReport struct {
-	ProjectID      uint      `json:"projectId"`
-	ProjectTitle   string    `json:"projectTitle"`
-	CompanyID      string    `json:"companyId"`
-	TotalHours     float64   `json:"totalHours"`
-	TotalCost      float64   `json:"totalCost"`
-	WorkSessions   int       `json:"workSessions"`
-	AverageSession float64   `json:"averageSession"` // in hours
-	LastActivity   time.Time `json:"lastActivity"`
-	ActiveWorkers  int       `json:"activeWorkers"`
+	ProjectID      uint         `json:"projectId"`
+	ProjectTitle   string       `json:"projectTitle"`
+	CompanyID      string       `json:"companyId"`
+	TotalHours     float64      `json:"totalHours"` // net billable hours
+	GrossHours     float64      `json:"grossHours"`
+	BreakHours     float64      `json:"breakHours"`
+	TotalCost      money.Amount `json:"totalCost"`
+	WorkSessions   int          `json:"workSessions"`
+	AverageSession float64      `json:"averageSession"` // in hours
+	BillableHours  float64      `json:"billableHours"`  // net hours of billable sessions
+	Revenue        money.Amount `json:"revenue"`        // billed to the client
+	Margin         money.Amount `json:"margin"`         // revenue - cost
+	MarginPercent  float64      `json:"marginPercent"`  // margin / revenue * 100; 0 without revenue
+	LastActivity   time.Time    `json:"lastActivity"`
+	ActiveWorkers  int          `json:"activeWorkers"`
+
+	Currency    string           `json:"currency"`    // of the money totals; empty without any
+	ByCurrency  []CurrencyTotals `json:"byCurrency"`  // unconverted totals per session currency
+	Conversions []ConversionUsed `json:"conversions"` // exchange rates applied to reach Currency
+}
+
+// CurrencyTotals is the part of a report's money recorded in one currency
+type CurrencyTotals struct {
+	Currency     string       `json:"currency"`
+	TotalCost    money.Amount `json:"totalCost"`
+	Revenue      money.Amount `json:"revenue"`
+	WorkSessions int          `json:"workSessions"`
+}
+
+// ConversionUsed records an exchange rate a report converted with
+type ConversionUsed struct {
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Rate     string    `json:"rate"`     // To per From, as applied
+	RateDate time.Time `json:"rateDate"` // date of the stored rate used
+	Inverted bool      `json:"inverted"` // the stored rate was To->From
+}
+
+// AssignmentCostReport recomputes the cost of an assignment over a period
+// with the rates that were in force when each session started
+type AssignmentCostReport struct {
+	AssignmentID uint                 `json:"assignmentId"`
+	From         time.Time            `json:"from"`
+	To           time.Time            `json:"to"`
+	TotalHours   float64              `json:"totalHours"`   // net hours
+	TotalCost    money.Amount         `json:"totalCost"`    // at the rates in force
+	RecordedCost money.Amount         `json:"recordedCost"` // sum of the stored session costs
+	WorkSessions int                  `json:"workSessions"`
+	Periods      []RatePeriodCostLine `json:"periods"`
+}
+
+// RatePeriodCostLine is the share of an AssignmentCostReport billed at one rate
+type RatePeriodCostLine struct {
+	CostPerHour   money.Amount `json:"costPerHour"`
+	Currency      string       `json:"currency"`
+	EffectiveFrom time.Time    `json:"effectiveFrom"`
+	EffectiveTo   *time.Time   `json:"effectiveTo"`
+	Hours         float64      `json:"hours"`
+	Cost          money.Amount `json:"cost"`
+	WorkSessions  int          `json:"workSessions"`
 }
 
 // UserTimeReport represents individual user time tracking data
 type UserTimeReport struct {
-	UserID          string    `json:"userId"`
-	ProjectID       uint      `json:"projectId"`
-	CompanyID       string    `json:"companyId"`
-	TotalHours      float64   `json:"totalHours"`
-	WorkSessions    int       `json:"workSessions"`
-	BreakMinutes    int       `json:"breakMinutes"`
-	ProductiveHours float64   `json:"productiveHours"` // total - breaks
-	LastSession     time.Time `json:"lastSession"`
-	AverageDaily    float64   `json:"averageDaily"` // hours per day
+	UserID          string       `json:"userId"`
+	ProjectID       uint         `json:"projectId"`
+	CompanyID       string       `json:"companyId"`
+	TotalHours      float64      `json:"totalHours"` // gross hours
+	WorkSessions    int          `json:"workSessions"`
+	BreakMinutes    int          `json:"breakMinutes"`
+	ProductiveHours float64      `json:"productiveHours"` // total - breaks (net billable)
+	TotalCost       money.Amount `json:"totalCost"`       // on net hours
+	LastSession     time.Time    `json:"lastSession"`
+	AverageDaily    float64      `json:"averageDaily"` // hours per day
+
+	Currency    string           `json:"currency"`    // of TotalCost; empty without any
+	ByCurrency  []CurrencyTotals `json:"byCurrency"`  // unconverted totals per session currency
+	Conversions []ConversionUsed `json:"conversions"` // exchange rates applied to reach Currency
+}
+
+// DailyTimeTotals sums one user's work sessions of one day (in the requested
+// time zone) recorded in one currency
+type DailyTimeTotals struct {
+	Day          time.Time    `json:"day"`
+	Currency     string       `json:"currency"`
+	WorkSessions int          `json:"workSessions"`
+	GrossMinutes int          `json:"grossMinutes"`
+	BreakMinutes int          `json:"breakMinutes"`
+	NetMinutes   int          `json:"netMinutes"`
+	TotalCost    money.Amount `json:"totalCost"`
 }
 
 // SessionType constants
@@ -136,4 +500,21 @@ const (
 	BreakTypeShort = "break" // 5-15 minutes
 	BreakTypeLunch = "lunch" // 30-60 minutes
 	BreakTypeBRB   = "brb"   // 1-5 minutes (bathroom, quick interruption)
+	BreakTypeIdle  = "idle"  // Inserted by the idle sweeper while no heartbeat arrives
+)
+
+// RateSource constants
+const (
+	RateSourceAssignment = "assignment" // Taken from the worker's active ProjectAssignment
+	RateSourceClient     = "client"     // Supplied by the caller, no assignment to check it against
+	RateSourceImport     = "import"     // Taken from the time tracker the session was imported from
+)
+
+// DefaultCurrency is used for rates recorded without a currency
+const DefaultCurrency = "USD"
+
+// EndReason constants
+const (
+	EndReasonIdleTimeout = "idle_timeout" // Closed by the idle sweeper at the last activity time
+	EndReasonRecovered   = "recovered"    // Closed through the crash recovery flow
 )
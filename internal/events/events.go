@@ -0,0 +1,42 @@
+package events
+
+import "time"
+
+// Session event types pushed to a user's devices
+const (
+	SessionStarted         = "session.started"
+	SessionBreakStarted    = "session.break_started"
+	SessionBreakEnded      = "session.break_ended"
+	SessionProjectSwitched = "session.project_switched"
+	SessionCompanySwitched = "session.company_switched"
+	SessionFinished        = "session.finished"
+	SessionDiscarded       = "session.discarded"
+)
+
+// SessionEvent describes a change to a user's active session
+type SessionEvent struct {
+	Type      string    `json:"type"`
+	UserID    string    `json:"userId"`
+	SessionID uint      `json:"sessionId"`
+	ProjectID uint      `json:"projectId"`
+	CompanyID string    `json:"companyId"`
+	BreakID   *uint     `json:"breakId,omitempty"`
+	BreakType *string   `json:"breakType,omitempty"`
+	EndReason *string   `json:"endReason,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// Broker fans session events out to the subscribers of each user.
+//
+// MemoryBroker only reaches subscribers connected to the same instance; a
+// multi-instance deployment needs an implementation backed by a shared bus
+// (e.g. PostgreSQL LISTEN/NOTIFY or Redis pub/sub).
+type Broker interface {
+	// Publish delivers event to every current subscriber of event.UserID.
+	// It must not block on slow subscribers.
+	Publish(event SessionEvent)
+
+	// Subscribe registers a subscriber for userID. The returned cancel func
+	// unregisters it and closes the channel.
+	Subscribe(userID string) (<-chan SessionEvent, func())
+}
@@ -0,0 +1,62 @@
+package events
+
+import "sync"
+
+// subscriberBuffer is how many events a slow subscriber may lag behind before
+// further events are dropped for it.
+const subscriberBuffer = 16
+
+// MemoryBroker is an in-process Broker for single-instance deployments
+type MemoryBroker struct {
+	mu     sync.RWMutex
+	nextID uint64
+	subs   map[string]map[uint64]chan SessionEvent
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subs: make(map[string]map[uint64]chan SessionEvent),
+	}
+}
+
+// Publish delivers event to the user's subscribers, dropping it for any
+// subscriber whose buffer is full.
+func (b *MemoryBroker) Publish(event SessionEvent) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, ch := range b.subs[event.UserID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe registers a subscriber for userID
+func (b *MemoryBroker) Subscribe(userID string) (<-chan SessionEvent, func()) {
+	ch := make(chan SessionEvent, subscriberBuffer)
+
+	b.mu.Lock()
+	b.nextID++
+	id := b.nextID
+	if b.subs[userID] == nil {
+		b.subs[userID] = make(map[uint64]chan SessionEvent)
+	}
+	b.subs[userID][id] = ch
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs[userID], id)
+			if len(b.subs[userID]) == 0 {
+				delete(b.subs, userID)
+			}
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// csvFlushRows is how many rows are buffered before they go to the client
+const csvFlushRows = 200
+
+type csvWriter struct {
+	w       io.Writer
+	csv     *csv.Writer
+	pending int
+}
+
+// newCSVWriter writes RFC 4180 CSV. A UTF-8 byte order mark goes first so
+// spreadsheet applications don't misread accented text.
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: w, csv: csv.NewWriter(w)}
+}
+
+func (cw *csvWriter) Header(labels []string) error {
+	if _, err := io.WriteString(cw.w, "\ufeff"); err != nil {
+		return err
+	}
+	return cw.csv.Write(labels)
+}
+
+func (cw *csvWriter) Row(cells []Cell) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		record[i] = cell.Value
+		if !cell.Numeric {
+			record[i] = neutralizeFormula(cell.Value)
+		}
+	}
+	if err := cw.csv.Write(record); err != nil {
+		return err
+	}
+	cw.pending++
+	if cw.pending >= csvFlushRows {
+		cw.pending = 0
+		cw.csv.Flush()
+		return cw.csv.Error()
+	}
+	return nil
+}
+
+func (cw *csvWriter) Close() error {
+	cw.csv.Flush()
+	return cw.csv.Error()
+}
+
+// neutralizeFormula keeps free text such as session notes from being run as
+// a formula when the file is opened in a spreadsheet.
+func neutralizeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,181 @@
+// Package export writes tabular data as CSV or XLSX, one row at a time, so
+// large exports stream to the client instead of being built in memory.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+)
+
+// ErrInvalidExport wraps malformed export options
+var ErrInvalidExport = apperr.Validation("invalid_export", "invalid export request")
+
+// Supported output formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// ContentType returns the MIME type of format
+func ContentType(format string) string {
+	if format == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// CheckFormat rejects formats NewWriter can't produce
+func CheckFormat(format string) error {
+	if format != FormatCSV && format != FormatXLSX {
+		return fmt.Errorf("%w: unsupported format %q (use csv or xlsx)", ErrInvalidExport, format)
+	}
+	return nil
+}
+
+// Cell is one value of a row. Numeric cells are written as numbers in XLSX
+// so spreadsheets can sum them; everything else is text.
+type Cell struct {
+	Value   string
+	Numeric bool
+}
+
+// Text is a text cell
+func Text(s string) Cell {
+	return Cell{Value: s}
+}
+
+// Int is a numeric cell holding n
+func Int(n int64) Cell {
+	return Cell{Value: strconv.FormatInt(n, 10), Numeric: true}
+}
+
+// Decimal is a numeric cell holding f with decimals digits
+func Decimal(f float64, decimals int) Cell {
+	return Cell{Value: strconv.FormatFloat(f, 'f', decimals, 64), Numeric: true}
+}
+
+// Money is a numeric cell holding a, or an empty cell when a is nil
+func Money(a *money.Amount) Cell {
+	if a == nil {
+		return Cell{}
+	}
+	return Cell{Value: a.String(), Numeric: true}
+}
+
+// Writer receives the header and then the rows of one table
+type Writer interface {
+	Header(labels []string) error
+	Row(cells []Cell) error
+	// Close flushes buffered output and completes the file
+	Close() error
+}
+
+// NewWriter returns a writer of format on w. sheet names the XLSX worksheet.
+func NewWriter(w io.Writer, format, sheet string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), nil
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet)
+	}
+	return nil, CheckFormat(format)
+}
+
+// dateLayouts are the accepted ?dateFormat= presets
+var dateLayouts = map[string]string{
+	"iso": "2006-01-02",
+	"us":  "01/02/2006",
+	"eu":  "02/01/2006",
+	"de":  "02.01.2006",
+}
+
+// Options controls how values are written
+type Options struct {
+	Location   *time.Location
+	DateLayout string
+}
+
+// ParseOptions reads an IANA time zone (default UTC) and a date format: one
+// of iso, us, eu, de, or a Go layout such as "2006/01/02".
+func ParseOptions(timezone, dateFormat string) (Options, error) {
+	opts := Options{Location: time.UTC, DateLayout: dateLayouts["iso"]}
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return Options{}, fmt.Errorf("%w: unknown timezone %q", ErrInvalidExport, timezone)
+		}
+		opts.Location = loc
+	}
+	if dateFormat != "" {
+		if layout, ok := dateLayouts[strings.ToLower(dateFormat)]; ok {
+			opts.DateLayout = layout
+		} else if strings.Contains(dateFormat, "2006") {
+			opts.DateLayout = dateFormat
+		} else {
+			return Options{}, fmt.Errorf("%w: unknown date format %q (use iso, us, eu, de or a Go layout)", ErrInvalidExport, dateFormat)
+		}
+	}
+	return opts, nil
+}
+
+// Date formats the day of t in the export's time zone
+func (o Options) Date(t time.Time) string {
+	return t.In(o.Location).Format(o.DateLayout)
+}
+
+// Time formats the clock time of t in the export's time zone
+func (o Options) Time(t time.Time) string {
+	return t.In(o.Location).Format("15:04")
+}
+
+// Column is one selectable column of rows of type T
+type Column[T any] struct {
+	Key    string
+	Header string
+	Value  func(row *T, o Options) Cell
+}
+
+// Select picks the columns named in keys, in that order; empty keys select
+// defaults.
+func Select[T any](all []Column[T], keys, defaults []string) ([]Column[T], error) {
+	if len(keys) == 0 {
+		keys = defaults
+	}
+	byKey := make(map[string]Column[T], len(all))
+	for _, col := range all {
+		byKey[col.Key] = col
+	}
+	selected := make([]Column[T], 0, len(keys))
+	for _, key := range keys {
+		col, ok := byKey[strings.TrimSpace(key)]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidExport, key)
+		}
+		selected = append(selected, col)
+	}
+	return selected, nil
+}
+
+// Headers returns the header labels of columns
+func Headers[T any](columns []Column[T]) []string {
+	labels := make([]string, len(columns))
+	for i, col := range columns {
+		labels[i] = col.Header
+	}
+	return labels
+}
+
+// Cells returns the values of row for columns
+func Cells[T any](columns []Column[T], row *T, o Options) []Cell {
+	cells := make([]Cell, len(columns))
+	for i, col := range columns {
+		cells[i] = col.Value(row, o)
+	}
+	return cells
+}
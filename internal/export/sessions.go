@@ -0,0 +1,86 @@
+package export
+
+import (
+	"strconv"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+)
+
+// SessionColumns are the columns a session export can select with ?columns=
+var SessionColumns = []Column[db.TimeSession]{
+	{"id", "Session ID", func(s *db.TimeSession, _ Options) Cell { return Int(int64(s.ID)) }},
+	{"date", "Date", func(s *db.TimeSession, o Options) Cell { return Text(o.Date(s.StartTime)) }},
+	{"start", "Start", func(s *db.TimeSession, o Options) Cell { return Text(o.Time(s.StartTime)) }},
+	{"end", "End", func(s *db.TimeSession, o Options) Cell {
+		if s.EndTime == nil {
+			return Cell{}
+		}
+		return Text(o.Time(*s.EndTime))
+	}},
+	{"end_date", "End date", func(s *db.TimeSession, o Options) Cell {
+		if s.EndTime == nil {
+			return Cell{}
+		}
+		return Text(o.Date(*s.EndTime))
+	}},
+	{"user", "User", func(s *db.TimeSession, _ Options) Cell { return Text(s.UserID) }},
+	{"company", "Company", func(s *db.TimeSession, _ Options) Cell { return Text(s.CompanyID) }},
+	{"project", "Project ID", func(s *db.TimeSession, _ Options) Cell { return Int(int64(s.ProjectID)) }},
+	{"assignment", "Assignment ID", func(s *db.TimeSession, _ Options) Cell { return optionalID(s.ProjectAssignmentID) }},
+	{"type", "Type", func(s *db.TimeSession, _ Options) Cell { return Text(s.SessionType) }},
+	{"gross_minutes", "Gross minutes", func(s *db.TimeSession, _ Options) Cell { return Int(int64(s.DurationMinutes)) }},
+	{"break_minutes", "Break minutes", func(s *db.TimeSession, _ Options) Cell { return Int(int64(s.BreakMinutes)) }},
+	{"net_minutes", "Net minutes", func(s *db.TimeSession, _ Options) Cell { return Int(int64(s.NetMinutes)) }},
+	{"net_hours", "Net hours", func(s *db.TimeSession, _ Options) Cell { return Decimal(float64(s.NetMinutes)/60, 2) }},
+	{"hourly_rate", "Hourly rate", func(s *db.TimeSession, _ Options) Cell { return Money(s.HourlyRate) }},
+	{"cost", "Cost", func(s *db.TimeSession, _ Options) Cell { return Money(&s.SessionCost) }},
+	{"currency", "Currency", func(s *db.TimeSession, _ Options) Cell { return Text(s.Currency) }},
+	{"billable_rate", "Billable rate", func(s *db.TimeSession, _ Options) Cell { return Money(s.BillableRate) }},
+	{"revenue", "Revenue", func(s *db.TimeSession, _ Options) Cell { return Money(&s.SessionRevenue) }},
+	{"billable_currency", "Billable currency", func(s *db.TimeSession, _ Options) Cell { return Text(s.BillableCurrency) }},
+	{"billable", "Billable", func(s *db.TimeSession, _ Options) Cell { return yesNo(!s.NonBillable) }},
+	{"manual", "Manual entry", func(s *db.TimeSession, _ Options) Cell { return yesNo(s.IsManualEntry) }},
+	{"active", "Active", func(s *db.TimeSession, _ Options) Cell { return yesNo(s.IsActive) }},
+	{"invoice", "Invoice ID", func(s *db.TimeSession, _ Options) Cell { return optionalID(s.InvoiceID) }},
+	{"notes", "Notes", func(s *db.TimeSession, _ Options) Cell {
+		if s.Notes == nil {
+			return Cell{}
+		}
+		return Text(*s.Notes)
+	}},
+}
+
+// DefaultSessionColumns are exported when no columns are requested
+var DefaultSessionColumns = []string{
+	"date", "start", "end", "project", "type", "break_minutes", "net_hours",
+	"cost", "currency", "revenue", "billable_currency", "notes",
+}
+
+// DailyColumns are the columns of a daily report export
+var DailyColumns = []Column[db.DailyTimeTotals]{
+	{"date", "Date", func(d *db.DailyTimeTotals, o Options) Cell { return Text(d.Day.Format(o.DateLayout)) }},
+	{"sessions", "Work sessions", func(d *db.DailyTimeTotals, _ Options) Cell { return Int(int64(d.WorkSessions)) }},
+	{"gross_minutes", "Gross minutes", func(d *db.DailyTimeTotals, _ Options) Cell { return Int(int64(d.GrossMinutes)) }},
+	{"break_minutes", "Break minutes", func(d *db.DailyTimeTotals, _ Options) Cell { return Int(int64(d.BreakMinutes)) }},
+	{"net_minutes", "Net minutes", func(d *db.DailyTimeTotals, _ Options) Cell { return Int(int64(d.NetMinutes)) }},
+	{"net_hours", "Net hours", func(d *db.DailyTimeTotals, _ Options) Cell { return Decimal(float64(d.NetMinutes)/60, 2) }},
+	{"cost", "Cost", func(d *db.DailyTimeTotals, _ Options) Cell { return Money(&d.TotalCost) }},
+	{"currency", "Currency", func(d *db.DailyTimeTotals, _ Options) Cell { return Text(d.Currency) }},
+}
+
+// DefaultDailyColumns are exported when no columns are requested
+var DefaultDailyColumns = []string{"date", "sessions", "break_minutes", "net_hours", "cost", "currency"}
+
+func optionalID(id *uint) Cell {
+	if id == nil {
+		return Cell{}
+	}
+	return Cell{Value: strconv.FormatUint(uint64(*id), 10), Numeric: true}
+}
+
+func yesNo(b bool) Cell {
+	if b {
+		return Text("yes")
+	}
+	return Text("no")
+}
@@ -0,0 +1,121 @@
+// Package ical reads and writes the iCalendar (RFC 5545) subset the session
+// calendar feed and the calendar import need: one VCALENDAR of VEVENTs.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxLineOctets is the longest content line allowed before folding
+const maxLineOctets = 75
+
+// Event is one VEVENT
+type Event struct {
+	UID          string
+	Start        time.Time
+	End          time.Time
+	Stamp        time.Time
+	LastModified time.Time
+	Summary      string
+	Description  string
+	Categories   []string
+	Tentative    bool // for sessions still running
+}
+
+// Writer writes a calendar with CRLF line endings and folded long lines
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+// NewWriter starts a calendar named name on w
+func NewWriter(w io.Writer, name string) *Writer {
+	cw := &Writer{w: bufio.NewWriter(w)}
+	cw.line("BEGIN:VCALENDAR")
+	cw.line("VERSION:2.0")
+	cw.line("PRODID:-//professional-tracker//sessions//EN")
+	cw.line("CALSCALE:GREGORIAN")
+	cw.line("METHOD:PUBLISH")
+	cw.line("X-WR-CALNAME:" + EscapeText(name))
+	return cw
+}
+
+// Event writes one VEVENT
+func (cw *Writer) Event(e *Event) {
+	cw.line("BEGIN:VEVENT")
+	cw.line("UID:" + EscapeText(e.UID))
+	cw.line("DTSTAMP:" + FormatTime(e.Stamp))
+	cw.line("DTSTART:" + FormatTime(e.Start))
+	cw.line("DTEND:" + FormatTime(e.End))
+	if !e.LastModified.IsZero() {
+		cw.line("LAST-MODIFIED:" + FormatTime(e.LastModified))
+	}
+	cw.line("SUMMARY:" + EscapeText(e.Summary))
+	if e.Description != "" {
+		cw.line("DESCRIPTION:" + EscapeText(e.Description))
+	}
+	if len(e.Categories) > 0 {
+		escaped := make([]string, len(e.Categories))
+		for i, category := range e.Categories {
+			escaped[i] = EscapeText(category)
+		}
+		cw.line("CATEGORIES:" + strings.Join(escaped, ","))
+	}
+	if e.Tentative {
+		cw.line("STATUS:TENTATIVE")
+	} else {
+		cw.line("STATUS:CONFIRMED")
+	}
+	cw.line("TRANSP:OPAQUE")
+	cw.line("END:VEVENT")
+}
+
+// Close ends the calendar and flushes it
+func (cw *Writer) Close() error {
+	cw.line("END:VCALENDAR")
+	if cw.err != nil {
+		return cw.err
+	}
+	return cw.w.Flush()
+}
+
+// line writes a content line, folding it into 75-octet chunks without
+// splitting a UTF-8 sequence
+func (cw *Writer) line(s string) {
+	if cw.err != nil {
+		return
+	}
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		cw.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		limit = maxLineOctets - 1 // the leading space counts
+	}
+	cw.write(s + "\r\n")
+}
+
+func (cw *Writer) write(s string) {
+	if cw.err == nil {
+		_, cw.err = cw.w.WriteString(s)
+	}
+}
+
+// FormatTime formats t as a UTC DATE-TIME
+func FormatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// EscapeText escapes a TEXT property value
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
@@ -0,0 +1,285 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrMalformed is returned for input that isn't an iCalendar file
+var ErrMalformed = errors.New("malformed calendar")
+
+// maxLineBytes bounds one unfolded content line
+const maxLineBytes = 1 << 20
+
+// ParsedEvent is a VEVENT read from a calendar file. Times are in the event's
+// time zone; floating times and unknown zones use the parser's default.
+type ParsedEvent struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	Status       string // CONFIRMED, TENTATIVE, CANCELLED or empty
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Organizer    string   // email, lowercase
+	Attendees    []string // emails, lowercase
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time // set on a changed instance of a recurring event
+}
+
+// Parse reads the VEVENTs of a calendar. loc applies to floating times and
+// to TZIDs the system time zone database doesn't know (e.g. Windows names).
+func Parse(r io.Reader, loc *time.Location) ([]ParsedEvent, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []ParsedEvent
+	var current *ParsedEvent
+	var duration string
+	depth := 0 // nesting inside the VEVENT, e.g. VALARM
+	sawCalendar := false
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		name, params, value, err := splitProperty(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(value, "VCALENDAR"):
+			sawCalendar = true
+			continue
+		case name == "BEGIN" && strings.EqualFold(value, "VEVENT") && current == nil:
+			current = &ParsedEvent{}
+			duration = ""
+			continue
+		case name == "BEGIN" && current != nil:
+			depth++
+			continue
+		case name == "END" && current != nil && depth > 0:
+			depth--
+			continue
+		case name == "END" && strings.EqualFold(value, "VEVENT") && current != nil:
+			if current.End.IsZero() {
+				current.End = current.Start
+				if d, err := parseDuration(duration); err == nil {
+					current.End = current.Start.Add(d)
+				} else if current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				}
+			}
+			if !current.Start.IsZero() {
+				events = append(events, *current)
+			}
+			current = nil
+			continue
+		}
+		if current == nil || depth > 0 {
+			continue
+		}
+
+		switch name {
+		case "UID":
+			current.UID = value
+		case "SUMMARY":
+			current.Summary = unescapeText(value)
+		case "DESCRIPTION":
+			current.Description = unescapeText(value)
+		case "LOCATION":
+			current.Location = unescapeText(value)
+		case "STATUS":
+			current.Status = strings.ToUpper(value)
+		case "DTSTART":
+			current.Start, current.AllDay, err = parseTime(value, params, loc)
+		case "DTEND":
+			current.End, _, err = parseTime(value, params, loc)
+		case "DURATION":
+			duration = value
+		case "ORGANIZER":
+			current.Organizer = mailAddress(value)
+		case "ATTENDEE":
+			current.Attendees = append(current.Attendees, mailAddress(value))
+		case "RRULE":
+			current.RRule = value
+		case "EXDATE":
+			for _, part := range strings.Split(value, ",") {
+				t, _, perr := parseTime(part, params, loc)
+				if perr != nil {
+					err = perr
+					break
+				}
+				current.ExDates = append(current.ExDates, t)
+			}
+		case "RECURRENCE-ID":
+			var t time.Time
+			t, _, err = parseTime(value, params, loc)
+			current.RecurrenceID = &t
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrMalformed, name, err)
+		}
+	}
+	if !sawCalendar {
+		return nil, fmt.Errorf("%w: no VCALENDAR", ErrMalformed)
+	}
+	return events, nil
+}
+
+// unfold joins continuation lines (starting with a space or tab) onto the
+// line before them
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineBytes)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return lines, nil
+}
+
+// splitProperty splits NAME;PARAM=VALUE;...:value, honouring quoted
+// parameter values that contain ':' or ';'
+func splitProperty(line string) (string, map[string]string, string, error) {
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", nil, "", fmt.Errorf("%w: line without a value: %q", ErrMalformed, truncate(line))
+	}
+
+	head, value := line[:colon], line[colon+1:]
+	params := make(map[string]string)
+	parts := splitOutsideQuotes(head, ';')
+	for _, part := range parts[1:] {
+		key, val, _ := strings.Cut(part, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+	return strings.ToUpper(parts[0]), params, value, nil
+}
+
+func splitOutsideQuotes(s string, sep rune) []string {
+	var parts []string
+	inQuotes := false
+	start := 0
+	for i, r := range s {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == sep && !inQuotes {
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseTime reads a DATE or DATE-TIME value, reporting whether it was a DATE
+func parseTime(value string, params map[string]string, loc *time.Location) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	if tzid := params["TZID"]; tzid != "" {
+		if zone, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = zone
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// parseDuration reads an RFC 5545 duration such as PT1H30M or P1D
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "+")
+	if value == "" || value[0] != 'P' {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	var total time.Duration
+	number := ""
+	inTime := false
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			number += string(r)
+			continue
+		case r == 'T':
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		number = ""
+		switch {
+		case r == 'W':
+			total += time.Duration(n) * 7 * 24 * time.Hour
+		case r == 'D':
+			total += time.Duration(n) * 24 * time.Hour
+		case r == 'H' && inTime:
+			total += time.Duration(n) * time.Hour
+		case r == 'M' && inTime:
+			total += time.Duration(n) * time.Minute
+		case r == 'S' && inTime:
+			total += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+	}
+	return total, nil
+}
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+func unescapeText(s string) string {
+	return textUnescaper.Replace(s)
+}
+
+// mailAddress extracts the address of a mailto: URI
+func mailAddress(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 7 && strings.EqualFold(value[:7], "mailto:") {
+		value = value[7:]
+	}
+	return strings.ToLower(value)
+}
+
+func truncate(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
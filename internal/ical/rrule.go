@@ -0,0 +1,179 @@
+package ical
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences bounds the expansion of one recurring event
+const maxOccurrences = 5000
+
+// Occurrence is one instance of a possibly recurring event
+type Occurrence struct {
+	Start time.Time
+	End   time.Time
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday,
+	"TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday,
+}
+
+type recurrence struct {
+	freq     string
+	interval int
+	count    int
+	until    time.Time
+	byDay    []time.Weekday
+}
+
+// Occurrences returns the instances of the event starting in [from, to).
+// RRULEs are expanded for FREQ=DAILY, WEEKLY (with BYDAY) and MONTHLY with
+// INTERVAL, COUNT and UNTIL, minus EXDATEs; other rules yield only the first
+// instance. Wall-clock times are kept across DST changes.
+func (e *ParsedEvent) Occurrences(from, to time.Time) []Occurrence {
+	length := e.End.Sub(e.Start)
+	var out []Occurrence
+	add := func(start time.Time) {
+		if !start.Before(from) && start.Before(to) && !e.excluded(start) {
+			out = append(out, Occurrence{Start: start, End: start.Add(length)})
+		}
+	}
+
+	rule, ok := parseRRule(e.RRule, e.Start.Location())
+	if !ok {
+		add(e.Start)
+		return out
+	}
+
+	emitted := 0
+	next := func(start time.Time) bool {
+		if start.Before(e.Start) {
+			return true
+		}
+		if (rule.count > 0 && emitted >= rule.count) ||
+			(!rule.until.IsZero() && start.After(rule.until)) ||
+			!start.Before(to) || len(out) >= maxOccurrences {
+			return false
+		}
+		emitted++
+		add(start)
+		return true
+	}
+
+	y, m, d := e.Start.Date()
+	hh, mm, ss := e.Start.Clock()
+	loc := e.Start.Location()
+	at := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, hh, mm, ss, 0, loc)
+	}
+
+	switch rule.freq {
+	case "DAILY":
+		for i := 0; ; i += rule.interval {
+			if !next(at(y, m, d+i)) {
+				break
+			}
+		}
+	case "WEEKLY":
+		days := rule.byDay
+		if len(days) == 0 {
+			days = []time.Weekday{e.Start.Weekday()}
+		}
+		// Weeks start on Monday (the RFC's default WKST)
+		monday := d - (int(e.Start.Weekday())+6)%7
+	weeks:
+		for w := 0; ; w += rule.interval {
+			for _, day := range days {
+				offset := (int(day) + 6) % 7
+				if !next(at(y, m, monday+7*w+offset)) {
+					break weeks
+				}
+			}
+		}
+	case "MONTHLY":
+		for i := 0; i < 12*100*rule.interval; i += rule.interval {
+			start := at(y, m+time.Month(i), d)
+			if start.Day() != d {
+				continue // no such day in this month
+			}
+			if !next(start) {
+				break
+			}
+		}
+	}
+	return out
+}
+
+func (e *ParsedEvent) excluded(start time.Time) bool {
+	for _, ex := range e.ExDates {
+		if ex.Equal(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRRule reads the supported parts of an RRULE. Rules using parts it
+// can't honour (BYSETPOS, BYMONTHDAY and the like) are reported unsupported
+// rather than expanded wrongly.
+func parseRRule(value string, loc *time.Location) (*recurrence, bool) {
+	if value == "" {
+		return nil, false
+	}
+	rule := &recurrence{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, false
+			}
+			rule.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, false
+			}
+			rule.count = n
+		case "UNTIL":
+			t, allDay, err := parseTime(val, nil, loc)
+			if err != nil {
+				return nil, false
+			}
+			if allDay {
+				t = t.AddDate(0, 0, 1).Add(-time.Second)
+			}
+			rule.until = t
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, false // e.g. 1MO, which needs BYDAY ordinals
+				}
+				rule.byDay = append(rule.byDay, weekday)
+			}
+			// Chronological within a Monday-based week, so COUNT stops at the right one
+			sort.Slice(rule.byDay, func(i, j int) bool {
+				return (rule.byDay[i]+6)%7 < (rule.byDay[j]+6)%7
+			})
+		case "WKST":
+		default:
+			return nil, false
+		}
+	}
+	switch rule.freq {
+	case "DAILY", "WEEKLY", "MONTHLY":
+	default:
+		return nil, false
+	}
+	if len(rule.byDay) > 0 && rule.freq != "WEEKLY" {
+		return nil, false
+	}
+	return rule, true
+}
@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"math"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+)
+
+// parseClockifyCSV reads Clockify's detailed report CSV
+func parseClockifyCSV(r io.Reader, opts Options) ([]Entry, error) {
+	t, err := readTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.require([]string{"start date"}, []string{"start time"}, []string{"end date"}, []string{"end time"}); err != nil {
+		return nil, err
+	}
+	billableRateColumn := t.prefixed("billable rate")
+	costRateColumn := t.prefixed("cost rate")
+	currency := currencyIn(billableRateColumn)
+	if currency == "" {
+		currency = currencyIn(costRateColumn)
+	}
+
+	var entries []Entry
+	for {
+		row, err := t.next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		e := Entry{
+			Line:        t.line,
+			ExternalID:  t.get(row, "id"),
+			User:        t.get(row, "user"),
+			Email:       t.get(row, "email"),
+			Client:      t.get(row, "client"),
+			Project:     t.get(row, "project"),
+			Task:        t.get(row, "task"),
+			Description: t.get(row, "description"),
+			Tags:        splitTags(t.get(row, "tags")),
+			Billable:    isYes(t.get(row, "billable")),
+			Currency:    currency,
+		}
+		if e.Start, err = localTime(t.get(row, "start date"), t.get(row, "start time"), opts); err != nil {
+			e.Problem = err.Error()
+		} else if e.End, err = localTime(t.get(row, "end date"), t.get(row, "end time"), opts); err != nil {
+			e.Problem = err.Error()
+		}
+		if billableRateColumn != "" {
+			if e.BillableRate, err = parseRate(t.get(row, billableRateColumn)); err != nil && e.Problem == "" {
+				e.Problem = err.Error()
+			}
+		}
+		if costRateColumn != "" {
+			if e.CostRate, err = parseRate(t.get(row, costRateColumn)); err != nil && e.Problem == "" {
+				e.Problem = err.Error()
+			}
+		}
+		entries = append(entries, e)
+	}
+}
+
+// parseClockifyJSON reads time entries of Clockify's API (hydrated) and of
+// its detailed report: an array, or an object with "timeentries"
+func parseClockifyJSON(r io.Reader, opts Options) ([]Entry, error) {
+	records, err := decodeRecords(r, "timeentries", "timeEntries", "time_entries")
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(records))
+	for i, rec := range records {
+		e := Entry{
+			Line:        i + 1,
+			ExternalID:  rec.str("_id", "id"),
+			User:        rec.str("userName", "user.name"),
+			Email:       rec.str("userEmail", "user.email"),
+			Client:      rec.str("clientName", "project.clientName"),
+			Project:     rec.str("projectName", "project.name"),
+			Task:        rec.str("taskName", "task.name"),
+			Description: rec.str("description"),
+			Tags:        rec.names("tags"),
+			Billable:    rec.flag("billable"),
+			Currency:    currencyIn(rec.str("hourlyRate.currency", "costRate.currency", "currency")),
+		}
+		if e.Start, err = parseTimestamp(rec.str("timeInterval.start"), opts); err != nil {
+			e.Problem = err.Error()
+		} else if end := rec.str("timeInterval.end"); end == "" {
+			e.Problem = "entry is still running"
+		} else if e.End, err = parseTimestamp(end, opts); err != nil {
+			e.Problem = err.Error()
+		}
+		// Clockify gives rates in cents
+		if cents, ok := rec.num("hourlyRate.amount"); ok {
+			e.BillableRate = money.Amount(math.Round(cents)).Ptr()
+		}
+		if cents, ok := rec.num("costRate.amount"); ok {
+			e.CostRate = money.Amount(math.Round(cents)).Ptr()
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
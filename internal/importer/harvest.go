@@ -0,0 +1,155 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+)
+
+// harvestDayStart is where entries logged as hours only begin
+const harvestDayStart = 9 * time.Hour
+
+// dayStacker places Harvest entries that carry only a duration one after
+// another from harvestDayStart, per user and day, in file order
+type dayStacker map[string]time.Time
+
+func (s dayStacker) place(user string, day time.Time, d time.Duration) (time.Time, time.Time) {
+	key := user + "|" + day.Format("2006-01-02")
+	start, ok := s[key]
+	if !ok {
+		start = day.Add(harvestDayStart)
+	}
+	s[key] = start.Add(d)
+	return start, start.Add(d)
+}
+
+// parseHarvestCSV reads Harvest's detailed time report CSV. Harvest records
+// hours per day; entries without start and end times are laid out from 9:00.
+func parseHarvestCSV(r io.Reader, opts Options) ([]Entry, error) {
+	t, err := readTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.require([]string{"date"}, []string{"hours"}); err != nil {
+		return nil, err
+	}
+
+	stacker := make(dayStacker)
+	var entries []Entry
+	for {
+		row, err := t.next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		e := Entry{
+			Line:        t.line,
+			ExternalID:  t.get(row, "id", "time entry id"),
+			User:        strings.TrimSpace(t.get(row, "first name") + " " + t.get(row, "last name")),
+			Email:       t.get(row, "email"),
+			Client:      t.get(row, "client"),
+			Project:     t.get(row, "project"),
+			Task:        t.get(row, "task"),
+			Description: t.get(row, "notes"),
+			Billable:    isYes(t.get(row, "billable?", "billable")),
+			Currency:    currencyIn(t.get(row, "currency")),
+		}
+		if e.BillableRate, err = parseRate(t.get(row, "billable rate")); err != nil {
+			e.Problem = err.Error()
+		}
+		if e.CostRate, err = parseRate(t.get(row, "cost rate")); err != nil && e.Problem == "" {
+			e.Problem = err.Error()
+		}
+		if problem := e.placeHarvest(stacker, t.get(row, "date"), t.get(row, "hours"),
+			t.get(row, "started at", "start time"), t.get(row, "ended at", "end time"), opts); problem != "" && e.Problem == "" {
+			e.Problem = problem
+		}
+		entries = append(entries, e)
+	}
+}
+
+// parseHarvestJSON reads time entries of Harvest's API v2: an array, or an
+// object with "time_entries"
+func parseHarvestJSON(r io.Reader, opts Options) ([]Entry, error) {
+	records, err := decodeRecords(r, "time_entries")
+	if err != nil {
+		return nil, err
+	}
+	stacker := make(dayStacker)
+	entries := make([]Entry, 0, len(records))
+	for i, rec := range records {
+		e := Entry{
+			Line:        i + 1,
+			ExternalID:  rec.str("id"),
+			User:        rec.str("user.name"),
+			Email:       rec.str("user.email"),
+			Client:      rec.str("client.name"),
+			Project:     rec.str("project.name"),
+			Task:        rec.str("task.name"),
+			Description: rec.str("notes"),
+			Billable:    rec.flag("billable"),
+			Currency:    currencyIn(rec.str("client.currency", "currency")),
+		}
+		if rate, ok := rec.num("billable_rate"); ok {
+			e.BillableRate = money.Amount(math.Round(rate * money.MinorUnits)).Ptr()
+		}
+		if rate, ok := rec.num("cost_rate"); ok {
+			e.CostRate = money.Amount(math.Round(rate * money.MinorUnits)).Ptr()
+		}
+		if rec.flag("is_running") {
+			e.Problem = "entry is still running"
+		} else if problem := e.placeHarvest(stacker, rec.str("spent_date"), rec.str("hours"),
+			rec.str("started_time"), rec.str("ended_time"), opts); problem != "" {
+			e.Problem = problem
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// placeHarvest sets Start and End from the entry's start and end times, or
+// stacks its hours on the day when it has none
+func (e *Entry) placeHarvest(stacker dayStacker, date, hours, started, ended string, opts Options) string {
+	day, err := parseDate(date, opts)
+	if err != nil {
+		return err.Error()
+	}
+	if started != "" && ended != "" {
+		if e.Start, err = localTime(date, started, opts); err != nil {
+			return err.Error()
+		}
+		if e.End, err = localTime(date, ended, opts); err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+	d, err := parseHours(hours)
+	if err != nil {
+		return err.Error()
+	}
+	e.Start, e.End = stacker.place(strings.ToLower(e.User), day, d)
+	return ""
+}
+
+// parseHours reads decimal hours ("1.5") or hours and minutes ("1:30")
+func parseHours(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if h, m, ok := strings.Cut(value, ":"); ok {
+		hours, err1 := strconv.Atoi(h)
+		minutes, err2 := strconv.Atoi(m)
+		if err1 == nil && err2 == nil && hours >= 0 && minutes >= 0 && minutes < 60 {
+			return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+		}
+	} else if f, err := strconv.ParseFloat(strings.ReplaceAll(value, ",", "."), 64); err == nil && f >= 0 {
+		return time.Duration(math.Round(f*float64(time.Hour/time.Second))) * time.Second, nil
+	}
+	return 0, fmt.Errorf("unreadable hours %q", value)
+}
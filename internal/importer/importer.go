@@ -0,0 +1,310 @@
+// Package importer reads the time entries exported by other time trackers
+// (Toggl Track, Clockify and Harvest, as CSV or JSON) into one normalized
+// shape the sessions service can plan an import from.
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+)
+
+// ErrInvalidImport wraps unreadable files and unknown sources
+var ErrInvalidImport = apperr.Validation("invalid_import", "invalid import file")
+
+// Supported sources
+const (
+	SourceToggl    = "toggl"
+	SourceClockify = "clockify"
+	SourceHarvest  = "harvest"
+)
+
+// MaxEntries bounds the entries of one file
+const MaxEntries = 50000
+
+// Entry is one exported time entry. Problem is set instead of failing the
+// whole file when a row can't be read, so the import report can point at it.
+type Entry struct {
+	Line         int           `json:"line"`       // CSV line or JSON array index, 1-based
+	ExternalID   string        `json:"externalId"` // The tool's entry ID, or a hash of the entry when it exports none
+	User         string        `json:"user"`
+	Email        string        `json:"email"`
+	Client       string        `json:"client"`
+	Project      string        `json:"project"`
+	Task         string        `json:"task"`
+	Description  string        `json:"description"`
+	Tags         []string      `json:"tags"`
+	Start        time.Time     `json:"start"`
+	End          time.Time     `json:"end"`
+	Billable     bool          `json:"billable"`
+	BillableRate *money.Amount `json:"billableRate"` // Per hour
+	CostRate     *money.Amount `json:"costRate"`     // Per hour
+	Currency     string        `json:"currency"`     // Of both rates; empty when the export doesn't say
+	Problem      string        `json:"problem,omitempty"`
+}
+
+// Options control how local dates and times in a file are read
+type Options struct {
+	Location   *time.Location // Of times exported without an offset
+	DateLayout string         // Go layout of dates; empty tries ISO, then US, then German order
+}
+
+// Parse reads an export of source, CSV or JSON (detected from the content)
+func Parse(source string, r io.Reader, opts Options) ([]Entry, error) {
+	if opts.Location == nil {
+		opts.Location = time.UTC
+	}
+	br := bufio.NewReader(r)
+	first, err := firstByte(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	isJSON := first == '[' || first == '{'
+
+	var entries []Entry
+	switch source {
+	case SourceToggl:
+		if isJSON {
+			entries, err = parseTogglJSON(br, opts)
+		} else {
+			entries, err = parseTogglCSV(br, opts)
+		}
+	case SourceClockify:
+		if isJSON {
+			entries, err = parseClockifyJSON(br, opts)
+		} else {
+			entries, err = parseClockifyCSV(br, opts)
+		}
+	case SourceHarvest:
+		if isJSON {
+			entries, err = parseHarvestJSON(br, opts)
+		} else {
+			entries, err = parseHarvestCSV(br, opts)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown source %q (use toggl, clockify or harvest)", ErrInvalidImport, source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > MaxEntries {
+		return nil, fmt.Errorf("%w: more than %d entries - split the export", ErrInvalidImport, MaxEntries)
+	}
+
+	for i := range entries {
+		entries[i].normalize(source)
+	}
+	return entries, nil
+}
+
+// Users lists the distinct users of entries, by email where exported
+func Users(entries []Entry) []string {
+	seen := make(map[string]bool)
+	var users []string
+	for _, e := range entries {
+		user := e.Email
+		if user == "" {
+			user = e.User
+		}
+		if user != "" && !seen[strings.ToLower(user)] {
+			seen[strings.ToLower(user)] = true
+			users = append(users, user)
+		}
+	}
+	sort.Strings(users)
+	return users
+}
+
+// IsUser reports whether the entry belongs to user, given by name or email
+func (e *Entry) IsUser(user string) bool {
+	return strings.EqualFold(e.Email, user) || strings.EqualFold(e.User, user)
+}
+
+// Fingerprint changes whenever the entry is edited in the source tool
+func (e *Entry) Fingerprint() string {
+	rate := func(a *money.Amount) string {
+		if a == nil {
+			return ""
+		}
+		return a.String()
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		e.Start.UTC().Format(time.RFC3339), e.End.UTC().Format(time.RFC3339),
+		e.Client, e.Project, e.Task, e.Description, strings.Join(e.Tags, ","),
+		strconv.FormatBool(e.Billable), rate(e.BillableRate), rate(e.CostRate), e.Currency,
+	}, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// normalize trims the names and, for entries exported without an ID,
+// derives one from what identifies the entry in the source
+func (e *Entry) normalize(source string) {
+	e.User = strings.TrimSpace(e.User)
+	e.Email = strings.TrimSpace(e.Email)
+	e.Client = strings.TrimSpace(e.Client)
+	e.Project = strings.TrimSpace(e.Project)
+	e.Task = strings.TrimSpace(e.Task)
+	e.Description = strings.TrimSpace(e.Description)
+	tags := e.Tags[:0]
+	for _, tag := range e.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	e.Tags = tags
+
+	if e.Problem == "" && !e.End.After(e.Start) {
+		e.Problem = "end is not after start"
+	}
+	if e.ExternalID == "" {
+		sum := sha256.Sum256([]byte(strings.Join([]string{
+			source, strings.ToLower(e.Email), e.User,
+			e.Start.UTC().Format(time.RFC3339), e.End.UTC().Format(time.RFC3339),
+			e.Client, e.Project, e.Description,
+		}, "\x00")))
+		e.ExternalID = "sha-" + hex.EncodeToString(sum[:12])
+	}
+}
+
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		r, size, err := br.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		if r == '\ufeff' || r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			continue
+		}
+		if err := br.UnreadRune(); err != nil {
+			return 0, err
+		}
+		if size > 1 {
+			return 0, nil
+		}
+		return byte(r), nil
+	}
+}
+
+var dateLayouts = []string{"2006-01-02", "01/02/2006", "1/2/2006", "02.01.2006", "2.1.2006", "01/02/06"}
+
+var clockLayouts = []string{"15:04:05", "15:04", "03:04:05 PM", "3:04:05 PM", "03:04 PM", "3:04 PM", "3:04PM"}
+
+// parseDate reads a date in opts.DateLayout, or the first layout that fits
+func parseDate(value string, opts Options) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	layouts := dateLayouts
+	if opts.DateLayout != "" {
+		layouts = []string{opts.DateLayout}
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, opts.Location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unreadable date %q", value)
+}
+
+// parseClock reads a time of day, 24-hour or with AM/PM, as an offset from midnight
+func parseClock(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range clockLayouts {
+		if t, err := time.Parse(layout, strings.ToUpper(value)); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("unreadable time %q", value)
+}
+
+// localTime combines a date and a time of day in opts.Location
+func localTime(date, clock string, opts Options) (time.Time, error) {
+	day, err := parseDate(date, opts)
+	if err != nil {
+		return time.Time{}, err
+	}
+	offset, err := parseClock(clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	h, m, s := int(offset/time.Hour), int(offset%time.Hour/time.Minute), int(offset%time.Minute/time.Second)
+	return time.Date(day.Year(), day.Month(), day.Day(), h, m, s, 0, opts.Location), nil
+}
+
+// parseTimestamp reads an RFC 3339 timestamp, or a local one without offset
+func parseTimestamp(value string, opts Options) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, value, opts.Location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unreadable timestamp %q", value)
+}
+
+// parseRate reads an hourly rate as exported: "50", "1,250.00", "$ 50.00"
+// or "37,5". Tools derive some rates, so they are rounded to the cent.
+func parseRate(value string) (*money.Amount, error) {
+	value = strings.TrimSpace(strings.Trim(strings.TrimSpace(value), "$€£¥"))
+	value = strings.ReplaceAll(value, " ", "")
+	if value == "" {
+		return nil, nil
+	}
+	if strings.Contains(value, ".") {
+		value = strings.ReplaceAll(value, ",", "")
+	} else {
+		value = strings.ReplaceAll(value, ",", ".")
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("unreadable rate %q", value)
+	}
+	return money.Amount(math.Round(f * money.MinorUnits)).Ptr(), nil
+}
+
+// currencyIn extracts an ISO 4217 code from a header such as
+// "Amount (USD)" or a value such as "Euro - EUR"
+func currencyIn(s string) string {
+	if open := strings.LastIndex(s, "("); open >= 0 && strings.HasSuffix(s, ")") {
+		s = s[open+1 : len(s)-1]
+	}
+	if dash := strings.LastIndex(s, "-"); dash >= 0 {
+		s = s[dash+1:]
+	}
+	if code, err := money.ParseCurrency(s); err == nil {
+		return code
+	}
+	return ""
+}
+
+func isYes(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "yes", "true", "1", "y", "billable":
+		return true
+	}
+	return false
+}
+
+func splitTags(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// peekJSON tells whether the JSON document is an array
+func peekJSON(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, "\ufeff \t\r\n"), []byte("["))
+}
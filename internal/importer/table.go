@@ -0,0 +1,194 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// table is a CSV export read by column name
+type table struct {
+	columns map[string]int // lowercase header -> index
+	headers []string
+	reader  *csv.Reader
+	line    int
+}
+
+func readTable(r io.Reader) (*table, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: no CSV header: %v", ErrInvalidImport, err)
+	}
+	t := &table{columns: make(map[string]int, len(headers)), headers: headers, reader: reader, line: 1}
+	for i, h := range headers {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		headers[i] = h
+		if _, dup := t.columns[h]; !dup {
+			t.columns[h] = i
+		}
+	}
+	return t, nil
+}
+
+// require fails unless every group has one of its columns
+func (t *table) require(groups ...[]string) error {
+	for _, names := range groups {
+		if t.column(names...) < 0 {
+			return fmt.Errorf("%w: missing column %q - is this the right export?", ErrInvalidImport, names[0])
+		}
+	}
+	return nil
+}
+
+// next returns the next non-empty row, or io.EOF
+func (t *table) next() ([]string, error) {
+	for {
+		row, err := t.reader.Read()
+		t.line++
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidImport, t.line, err)
+		}
+		if strings.TrimSpace(strings.Join(row, "")) != "" {
+			return row, nil
+		}
+	}
+}
+
+// column returns the index of the first of names present, or -1
+func (t *table) column(names ...string) int {
+	for _, name := range names {
+		if i, ok := t.columns[name]; ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// get returns the row's value in the first of names present
+func (t *table) get(row []string, names ...string) string {
+	if i := t.column(names...); i >= 0 && i < len(row) {
+		return strings.TrimSpace(row[i])
+	}
+	return ""
+}
+
+// prefixed returns the first header starting with prefix, e.g. "amount (" for
+// "Amount (USD)", which carries the currency in its name
+func (t *table) prefixed(prefix string) string {
+	for _, h := range t.headers {
+		if strings.HasPrefix(h, prefix) {
+			return h
+		}
+	}
+	return ""
+}
+
+// record is one object of a JSON export, read by dotted path
+type record map[string]interface{}
+
+// decodeRecords reads a JSON array of objects, or an object holding one
+// under one of keys
+func decodeRecords(r io.Reader, keys ...string) ([]record, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	var records []record
+	if peekJSON(data) {
+		err = json.Unmarshal(data, &records)
+	} else {
+		var wrapper map[string]json.RawMessage
+		if err = json.Unmarshal(data, &wrapper); err == nil {
+			err = fmt.Errorf("no %s array", strings.Join(keys, " or "))
+			for _, key := range keys {
+				if raw, ok := wrapper[key]; ok {
+					err = json.Unmarshal(raw, &records)
+					break
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	return records, nil
+}
+
+func (r record) value(path string) interface{} {
+	var current interface{} = map[string]interface{}(r)
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = m[key]
+	}
+	return current
+}
+
+// str returns the first of paths holding a string or number, as text
+func (r record) str(paths ...string) string {
+	for _, path := range paths {
+		switch v := r.value(path).(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+// num returns the first of paths holding a number (or numeric string)
+func (r record) num(paths ...string) (float64, bool) {
+	for _, path := range paths {
+		switch v := r.value(path).(type) {
+		case float64:
+			return v, true
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// flag returns the first of paths holding a boolean
+func (r record) flag(paths ...string) bool {
+	for _, path := range paths {
+		if v, ok := r.value(path).(bool); ok {
+			return v
+		}
+	}
+	return false
+}
+
+// names returns a list of strings, or of objects with a name, at path
+func (r record) names(path string) []string {
+	items, _ := r.value(path).([]interface{})
+	var names []string
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			names = append(names, v)
+		case map[string]interface{}:
+			if name, ok := v["name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,124 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"math"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+)
+
+// parseTogglCSV reads Toggl Track's detailed report CSV. Toggl exports no
+// entry IDs there and either a rate or only the billable amount, from which
+// the rate is derived.
+func parseTogglCSV(r io.Reader, opts Options) ([]Entry, error) {
+	t, err := readTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.require([]string{"start date"}, []string{"start time"}, []string{"end date", "stop date"}, []string{"end time", "stop time"}); err != nil {
+		return nil, err
+	}
+	amountColumn := t.prefixed("amount (")
+	if amountColumn == "" {
+		amountColumn = t.prefixed("billable amount")
+	}
+	rateColumn := t.prefixed("billable rate")
+	if rateColumn == "" {
+		rateColumn = t.prefixed("hourly rate")
+	}
+	currency := currencyIn(rateColumn)
+	if currency == "" {
+		currency = currencyIn(amountColumn)
+	}
+
+	var entries []Entry
+	for {
+		row, err := t.next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		e := Entry{
+			Line:        t.line,
+			ExternalID:  t.get(row, "id"),
+			User:        t.get(row, "user", "member"),
+			Email:       t.get(row, "email"),
+			Client:      t.get(row, "client"),
+			Project:     t.get(row, "project"),
+			Task:        t.get(row, "task"),
+			Description: t.get(row, "description"),
+			Tags:        splitTags(t.get(row, "tags")),
+			Billable:    isYes(t.get(row, "billable")),
+			Currency:    currency,
+		}
+		if e.Start, err = localTime(t.get(row, "start date"), t.get(row, "start time"), opts); err != nil {
+			e.Problem = err.Error()
+		} else if e.End, err = localTime(t.get(row, "end date", "stop date"), t.get(row, "end time", "stop time"), opts); err != nil {
+			e.Problem = err.Error()
+		}
+		if rateColumn != "" {
+			if e.BillableRate, err = parseRate(t.get(row, rateColumn)); err != nil && e.Problem == "" {
+				e.Problem = err.Error()
+			}
+		} else if amountColumn != "" && e.Billable {
+			amount, err := parseRate(t.get(row, amountColumn))
+			if err != nil && e.Problem == "" {
+				e.Problem = err.Error()
+			}
+			e.BillableRate = rateFromAmount(amount, e.End.Sub(e.Start))
+		}
+		entries = append(entries, e)
+	}
+}
+
+// parseTogglJSON reads time entries of Toggl's API and report exports: an
+// array, or an object with "data" or "time_entries"
+func parseTogglJSON(r io.Reader, opts Options) ([]Entry, error) {
+	records, err := decodeRecords(r, "data", "time_entries")
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(records))
+	for i, rec := range records {
+		e := Entry{
+			Line:        i + 1,
+			ExternalID:  rec.str("id"),
+			User:        rec.str("user", "user_name", "username"),
+			Email:       rec.str("email", "user_email"),
+			Client:      rec.str("client", "client_name", "project.client_name"),
+			Project:     rec.str("project", "project_name", "project.name"),
+			Task:        rec.str("task", "task_name"),
+			Description: rec.str("description"),
+			Tags:        rec.names("tags"),
+			Billable:    rec.flag("is_billable", "billable"),
+			Currency:    currencyIn(rec.str("cur", "currency")),
+		}
+		if e.Start, err = parseTimestamp(rec.str("start"), opts); err != nil {
+			e.Problem = err.Error()
+		} else if end := rec.str("end", "stop"); end == "" {
+			e.Problem = "entry is still running"
+		} else if e.End, err = parseTimestamp(end, opts); err != nil {
+			e.Problem = err.Error()
+		}
+		if rate, ok := rec.num("hourly_rate", "rate"); ok {
+			e.BillableRate = money.Amount(math.Round(rate * money.MinorUnits)).Ptr()
+		} else if amount, ok := rec.num("billable"); ok && e.Billable {
+			// The reports API puts the billed amount under "billable"
+			e.BillableRate = rateFromAmount(money.Amount(math.Round(amount*money.MinorUnits)).Ptr(), e.End.Sub(e.Start))
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// rateFromAmount derives the hourly rate of an amount billed for d
+func rateFromAmount(amount *money.Amount, d time.Duration) *money.Amount {
+	if amount == nil || d <= 0 {
+		return nil
+	}
+	return money.Amount(math.Round(float64(*amount) / d.Hours())).Ptr()
+}
@@ -0,0 +1,94 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+var (
+	// ErrInvalidCurrency is returned for a code that is not three ASCII letters
+	// or not a supported currency
+	ErrInvalidCurrency = errors.New("invalid currency")
+	// ErrInvalidExchangeRate is returned for a rate that is not a positive decimal
+	ErrInvalidExchangeRate = errors.New("invalid exchange rate")
+)
+
+// RateDecimals is the precision exchange rates are kept at
+const RateDecimals = 10
+
+// supportedCurrencies are the ISO 4217 codes whose minor unit is a hundredth,
+// the only scale Amount keeps. Currencies without decimals (JPY, KRW) or with
+// three (KWD, BHD) would be stored and converted at the wrong scale.
+var supportedCurrencies = func() map[string]bool {
+	codes := make(map[string]bool)
+	for _, line := range []string{
+		"AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BRL BSD BTN",
+		"BWP BYN BZD CAD CDF CHF CNY COP CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD",
+		"FKP GBP GEL GHS GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR",
+		"KPW KYD KZT LAK LBP LKR LRD LSL MAD MDL MKD MMK MNT MOP MRU MUR MVR MWK MXN MYR",
+		"MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK PHP PKR PLN QAR RON RSD RUB SAR SBD SCR",
+		"SDG SEK SGD SHP SLE SOS SRD SSP STN SYP SZL THB TJS TMT TOP TRY TTD TWD TZS UAH",
+		"USD UYU UZS WST XCD YER ZAR ZMW",
+	} {
+		for _, code := range strings.Fields(line) {
+			codes[code] = true
+		}
+	}
+	return codes
+}()
+
+// ParseCurrency normalizes an ISO 4217 code such as "eur" to "EUR". Only
+// currencies with two decimals are supported.
+func ParseCurrency(code string) (string, error) {
+	c := strings.ToUpper(strings.TrimSpace(code))
+	if len(c) != 3 {
+		return "", fmt.Errorf("%w: %q is not a 3-letter ISO 4217 code", ErrInvalidCurrency, code)
+	}
+	for _, r := range c {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("%w: %q is not a 3-letter ISO 4217 code", ErrInvalidCurrency, code)
+		}
+	}
+	if !supportedCurrencies[c] {
+		return "", fmt.Errorf("%w: %s is not supported; amounts are kept with two decimals", ErrInvalidCurrency, c)
+	}
+	return c, nil
+}
+
+// ParseExchangeRate reads a positive decimal rate with at most RateDecimals
+// decimals, e.g. "5.4321" BRL per USD, and returns it exactly.
+func ParseExchangeRate(s string) (*big.Rat, error) {
+	text := strings.TrimSpace(s)
+	whole, frac, _ := strings.Cut(text, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(frac) || len(strings.TrimRight(frac, "0")) > RateDecimals {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidExchangeRate, s)
+	}
+	rate, ok := new(big.Rat).SetString(text)
+	if !ok || rate.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q must be a positive number", ErrInvalidExchangeRate, s)
+	}
+	return rate, nil
+}
+
+// FormatExchangeRate writes rate with RateDecimals decimals, trailing zeros removed
+func FormatExchangeRate(rate *big.Rat) string {
+	text := strings.TrimRight(rate.FloatString(RateDecimals), "0")
+	return strings.TrimSuffix(text, ".")
+}
+
+// Convert multiplies a by rate, rounding half away from zero to the minor unit
+func (a Amount) Convert(rate *big.Rat) Amount {
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(a)), rate)
+	num, den := product.Num(), product.Denom()
+
+	// Round |num|/den half away from zero: (2|num| + den) / 2den.
+	abs := new(big.Int).Abs(num)
+	abs.Mul(abs, big.NewInt(2)).Add(abs, den)
+	abs.Quo(abs, new(big.Int).Mul(den, big.NewInt(2)))
+	if num.Sign() < 0 {
+		abs.Neg(abs)
+	}
+	return Amount(abs.Int64())
+}
@@ -0,0 +1,156 @@
+// Package money represents monetary values exactly, as integer minor units
+// (cents), instead of float64.
+//
+// Rounding rules:
+//   - Amounts are kept in minor units; every supported currency has two decimals.
+//   - Input with more than two decimals is rejected, not rounded.
+//   - The cost of a time span is rate * minutes / 60, rounded half away from
+//     zero to the minor unit, once per session. Totals are sums of those
+//     rounded amounts, so invoice lines always add up to the invoice total.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MinorUnits is the number of minor units in one major unit
+const MinorUnits = 100
+
+// Amount is a monetary value in minor units. Hourly rates are Amounts per hour.
+type Amount int64
+
+// ErrInvalidAmount is returned when parsing text that is not an exact amount
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// FromMajor returns the amount of whole major units (e.g. dollars)
+func FromMajor(units int64) Amount {
+	return Amount(units * MinorUnits)
+}
+
+// Parse reads a decimal amount such as "12", "-3.5" or "1250.75". More than
+// two decimals, exponents and anything else that is not an exact amount fail.
+func Parse(s string) (Amount, error) {
+	text := strings.TrimSpace(s)
+	negative := false
+	switch {
+	case strings.HasPrefix(text, "-"):
+		negative = true
+		text = text[1:]
+	case strings.HasPrefix(text, "+"):
+		text = text[1:]
+	}
+
+	whole, frac, hasDot := strings.Cut(text, ".")
+	if whole == "" || !isDigits(whole) || (hasDot && (frac == "" || !isDigits(frac))) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	frac = strings.TrimRight(frac, "0")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("%w: %q has more than two decimals", ErrInvalidAmount, s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+
+	units, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, s)
+	}
+	if negative {
+		units = -units
+	}
+	return Amount(units), nil
+}
+
+// MustParse is Parse for constants; it panics on invalid input
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// String formats the amount with two decimals, e.g. "-12.05"
+func (a Amount) String() string {
+	sign := ""
+	units := int64(a)
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, units/MinorUnits, units%MinorUnits)
+}
+
+// Float returns the amount in major units for display math such as percentages.
+// Never feed the result back into money arithmetic.
+func (a Amount) Float() float64 {
+	return float64(a) / MinorUnits
+}
+
+// ForMinutes returns the cost of minutes at the hourly rate a
+func (a Amount) ForMinutes(minutes int) Amount {
+	return Amount(divRound(int64(a)*int64(minutes), 60))
+}
+
+// Percent returns basisPoints hundredths of a percent of a (2150 = 21.5%),
+// rounded half away from zero
+func (a Amount) Percent(basisPoints int64) Amount {
+	return Amount(divRound(int64(a)*basisPoints, 100*100))
+}
+
+// Ptr returns a pointer to a copy of a
+func (a Amount) Ptr() *Amount {
+	return &a
+}
+
+// MarshalJSON writes the amount as a JSON number with two decimals
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a numeric string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	text := string(data)
+	if text == "null" {
+		return nil
+	}
+	if strings.HasPrefix(text, `"`) {
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+	}
+	parsed, err := Parse(text)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Equal compares two optional amounts
+func Equal(a, b *Amount) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// divRound divides n by d (d > 0) rounding half away from zero
+func divRound(n, d int64) int64 {
+	if n < 0 {
+		return -((-n + d/2) / d)
+	}
+	return (n + d/2) / d
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
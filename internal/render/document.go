@@ -0,0 +1,298 @@
+// Package render lays out reports, timesheets and invoices as HTML or PDF.
+// Everything happens in-process: HTML comes from html/template and PDF from a
+// small writer using the standard PDF fonts, so no external service is needed.
+package render
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+)
+
+// Document kinds, each with its own built-in layout and template override
+const (
+	KindInvoice   = "invoice"
+	KindReport    = "report"
+	KindTimesheet = "timesheet"
+)
+
+// Document is the format-neutral content of a rendered document. Values are
+// already formatted for the locale.
+type Document struct {
+	Kind        string
+	Title       string
+	Subtitle    string
+	Branding    Branding
+	Fields      []Field // key facts under the title
+	Tables      []Table
+	Totals      []Field // right-aligned summary; the last one is emphasized
+	Notes       []string
+	GeneratedAt string
+
+	// Data is the report the document was built from, for custom templates
+	Data interface{}
+}
+
+// Field is one labelled value
+type Field struct {
+	Label string
+	Value string
+}
+
+// Table is a titled grid of preformatted cells
+type Table struct {
+	Title   string
+	Columns []Column
+	Rows    [][]string
+}
+
+// Column describes a table column. Width is relative to the other columns.
+type Column struct {
+	Label string
+	Right bool
+	Width float64
+}
+
+// Branding is the issuer block printed on every document
+type Branding struct {
+	Name     string
+	Address  []string
+	Logo     []byte
+	LogoMime string
+	Footer   string
+}
+
+// Style is a template's branding and formatting, with the template overrides
+type Style struct {
+	Branding Branding
+	Locale   Locale
+	custom   map[string]string
+}
+
+// StyleFrom turns a stored template (nil for the defaults) into a Style
+func StyleFrom(tpl *db.DocumentTemplate) Style {
+	if tpl == nil {
+		return Style{Locale: NewLocale(DefaultLocale, nil)}
+	}
+
+	loc, err := time.LoadLocation(tpl.Timezone)
+	if err != nil || tpl.Timezone == "" {
+		loc = time.UTC
+	}
+	style := Style{
+		Branding: Branding{
+			Name:     tpl.DisplayName,
+			Logo:     tpl.Logo,
+			LogoMime: tpl.LogoMime,
+			Footer:   tpl.Footer,
+		},
+		Locale: NewLocale(tpl.Locale, loc),
+		custom: make(map[string]string),
+	}
+	for _, line := range strings.Split(tpl.Address, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			style.Branding.Address = append(style.Branding.Address, line)
+		}
+	}
+	for kind, html := range map[string]*string{KindInvoice: tpl.InvoiceHTML, KindReport: tpl.ReportHTML, KindTimesheet: tpl.TimesheetHTML} {
+		if html != nil && strings.TrimSpace(*html) != "" {
+			style.custom[kind] = *html
+		}
+	}
+	return style
+}
+
+func (s Style) newDocument(kind, title string, data interface{}) *Document {
+	return &Document{
+		Kind:        kind,
+		Title:       title,
+		Branding:    s.Branding,
+		GeneratedAt: s.Locale.DateTime(time.Now()),
+		Data:        data,
+	}
+}
+
+/* ------------------------------------------------------------------ */
+/*  Builders                                                          */
+/* ------------------------------------------------------------------ */
+
+// ProjectReport lays out a project cost report
+func (s Style) ProjectReport(report *db.ProjectTimeReport) *Document {
+	l := s.Locale
+	doc := s.newDocument(KindReport, "Project cost report", report)
+	doc.Subtitle = report.ProjectTitle
+	doc.Fields = []Field{
+		{"Project", fmt.Sprintf("%s (#%d)", report.ProjectTitle, report.ProjectID)},
+		{"Work sessions", fmt.Sprintf("%d", report.WorkSessions)},
+		{"Net hours", l.Number(report.TotalHours, 2)},
+		{"Gross hours", l.Number(report.GrossHours, 2)},
+		{"Break hours", l.Number(report.BreakHours, 2)},
+		{"Billable hours", l.Number(report.BillableHours, 2)},
+	}
+	if !report.LastActivity.IsZero() {
+		doc.Fields = append(doc.Fields, Field{"Last activity", l.DateTime(report.LastActivity)})
+	}
+
+	if len(report.ByCurrency) > 0 {
+		table := Table{
+			Title: "By currency",
+			Columns: []Column{
+				{Label: "Currency", Width: 1},
+				{Label: "Sessions", Right: true, Width: 1},
+				{Label: "Cost", Right: true, Width: 2},
+				{Label: "Revenue", Right: true, Width: 2},
+			},
+		}
+		for _, line := range report.ByCurrency {
+			table.Rows = append(table.Rows, []string{
+				line.Currency,
+				fmt.Sprintf("%d", line.WorkSessions),
+				l.Money(line.TotalCost, line.Currency),
+				l.Money(line.Revenue, line.Currency),
+			})
+		}
+		doc.Tables = append(doc.Tables, table)
+	}
+	if len(report.Conversions) > 0 {
+		doc.Tables = append(doc.Tables, conversionsTable(l, report.Conversions))
+	}
+
+	doc.Totals = []Field{
+		{"Cost", l.Money(report.TotalCost, report.Currency)},
+		{"Revenue", l.Money(report.Revenue, report.Currency)},
+		{"Margin", fmt.Sprintf("%s (%s%%)", l.Money(report.Margin, report.Currency), l.Number(report.MarginPercent, 1))},
+	}
+	return doc
+}
+
+// Timesheet lays out a user's time report with the sessions it covers
+func (s Style) Timesheet(report *db.UserTimeReport, sessions []db.TimeSession, from, to time.Time) *Document {
+	l := s.Locale
+	doc := s.newDocument(KindTimesheet, "Timesheet", report)
+	doc.Subtitle = fmt.Sprintf("%s - %s", l.Date(from), l.Date(to))
+	doc.Fields = []Field{
+		{"User", report.UserID},
+		{"Work sessions", fmt.Sprintf("%d", report.WorkSessions)},
+		{"Productive hours", l.Number(report.ProductiveHours, 2)},
+		{"Break minutes", fmt.Sprintf("%d", report.BreakMinutes)},
+		{"Average per day", l.Number(report.AverageDaily, 2)},
+	}
+	if report.ProjectID > 0 {
+		doc.Fields = append(doc.Fields, Field{"Project", fmt.Sprintf("#%d", report.ProjectID)})
+	}
+
+	table := Table{
+		Title: "Sessions",
+		Columns: []Column{
+			{Label: "Date", Width: 2},
+			{Label: "Start", Width: 1.2},
+			{Label: "End", Width: 1.2},
+			{Label: "Project", Width: 1},
+			{Label: "Break min", Right: true, Width: 1.2},
+			{Label: "Net hours", Right: true, Width: 1.2},
+			{Label: "Notes", Width: 3},
+		},
+	}
+	for _, session := range sessions {
+		end := ""
+		if session.EndTime != nil {
+			end = l.Time(*session.EndTime)
+		}
+		notes := ""
+		if session.Notes != nil {
+			notes = *session.Notes
+		}
+		table.Rows = append(table.Rows, []string{
+			l.Date(session.StartTime),
+			l.Time(session.StartTime),
+			end,
+			fmt.Sprintf("#%d", session.ProjectID),
+			fmt.Sprintf("%d", session.BreakMinutes),
+			l.Hours(session.NetMinutes),
+			notes,
+		})
+	}
+	doc.Tables = append(doc.Tables, table)
+	if len(report.Conversions) > 0 {
+		doc.Tables = append(doc.Tables, conversionsTable(l, report.Conversions))
+	}
+
+	doc.Totals = []Field{
+		{"Net hours", l.Number(report.ProductiveHours, 2)},
+		{"Cost", l.Money(report.TotalCost, report.Currency)},
+	}
+	return doc
+}
+
+// Invoice lays out an invoice with its lines
+func (s Style) Invoice(invoice *db.Invoice) *Document {
+	l := s.Locale
+	doc := s.newDocument(KindInvoice, "Invoice "+invoice.Number, invoice)
+	if invoice.Status == db.InvoiceStatusVoid {
+		doc.Subtitle = "VOID"
+	}
+	doc.Fields = []Field{
+		{"Invoice number", invoice.Number},
+		{"Issue date", l.Date(invoice.IssuedAt)},
+		{"Bill to", invoice.ClientName},
+		// PeriodEnd is exclusive; print the last day billed.
+		{"Period", fmt.Sprintf("%s - %s", l.Date(invoice.PeriodStart), l.Date(invoice.PeriodEnd.Add(-time.Nanosecond)))},
+		{"Currency", invoice.Currency},
+	}
+
+	table := Table{
+		Title: "Services",
+		Columns: []Column{
+			{Label: "#", Width: 0.5},
+			{Label: "Description", Width: 5},
+			{Label: "Hours", Right: true, Width: 1.2},
+			{Label: "Rate", Right: true, Width: 1.8},
+			{Label: "Amount", Right: true, Width: 2},
+		},
+	}
+	for _, line := range invoice.Lines {
+		table.Rows = append(table.Rows, []string{
+			fmt.Sprintf("%d", line.Position),
+			line.Description,
+			l.Hours(line.BilledMinutes),
+			l.Money(line.Rate, ""),
+			l.Money(line.Amount, ""),
+		})
+	}
+	doc.Tables = append(doc.Tables, table)
+
+	taxLabel := invoice.TaxLabel
+	if taxLabel == "" {
+		taxLabel = "Tax"
+	}
+	doc.Totals = []Field{
+		{"Subtotal", l.Money(invoice.Subtotal, invoice.Currency)},
+		{fmt.Sprintf("%s (%s%%)", taxLabel, l.Number(float64(invoice.TaxRateBasisPoints)/100, 2)), l.Money(invoice.TaxAmount, invoice.Currency)},
+		{"Total", l.Money(invoice.Total, invoice.Currency)},
+	}
+	if invoice.RoundingMinutes > 0 {
+		doc.Notes = append(doc.Notes, fmt.Sprintf("Hours per line are rounded up to %d-minute increments.", invoice.RoundingMinutes))
+	}
+	if invoice.Notes != nil && *invoice.Notes != "" {
+		doc.Notes = append(doc.Notes, *invoice.Notes)
+	}
+	return doc
+}
+
+func conversionsTable(l Locale, conversions []db.ConversionUsed) Table {
+	table := Table{
+		Title: "Exchange rates applied",
+		Columns: []Column{
+			{Label: "From", Width: 1},
+			{Label: "To", Width: 1},
+			{Label: "Rate", Right: true, Width: 2},
+			{Label: "Rate date", Width: 2},
+		},
+	}
+	for _, c := range conversions {
+		table.Rows = append(table.Rows, []string{c.From, c.To, c.Rate, l.Date(c.RateDate)})
+	}
+	return table
+}
@@ -0,0 +1,115 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+)
+
+// templateFuncs are available to the built-in layout and to custom templates
+var templateFuncs = template.FuncMap{
+	// logoURI inlines the logo so the HTML needs no other request to display
+	"logoURI": func(b Branding) template.URL {
+		if len(b.Logo) == 0 {
+			return ""
+		}
+		return template.URL("data:" + b.LogoMime + ";base64," + base64.StdEncoding.EncodeToString(b.Logo))
+	},
+	"isLast": func(i, n int) bool { return i == n-1 },
+}
+
+// defaultHTML is the built-in layout shared by every document kind
+const defaultHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Doc.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; font-size: 10pt; color: #222; margin: 40px; }
+header { display: flex; justify-content: space-between; align-items: flex-start; margin-bottom: 24px; }
+header img { max-height: 64px; max-width: 200px; }
+.issuer { text-align: right; }
+.issuer strong { font-size: 12pt; }
+h1 { font-size: 18pt; margin: 0 0 4px 0; }
+h2 { font-size: 12pt; margin: 24px 0 8px 0; }
+.subtitle { color: #666; margin-bottom: 16px; }
+dl.fields { display: grid; grid-template-columns: max-content auto; gap: 4px 16px; }
+dl.fields dt { color: #666; }
+dl.fields dd { margin: 0; }
+table { width: 100%; border-collapse: collapse; }
+th { text-align: left; border-bottom: 1px solid #999; padding: 4px; }
+td { border-bottom: 1px solid #ddd; padding: 4px; }
+.right { text-align: right; }
+table.totals { width: auto; margin: 16px 0 0 auto; }
+table.totals td { border: none; }
+table.totals tr.last td { font-weight: bold; border-top: 1px solid #999; }
+.notes { margin-top: 16px; color: #444; }
+footer { margin-top: 32px; color: #666; font-size: 8pt; border-top: 1px solid #ddd; padding-top: 8px; }
+</style>
+</head>
+<body>
+<header>
+  <div>{{with logoURI .Doc.Branding}}<img src="{{.}}" alt="logo">{{end}}</div>
+  <div class="issuer">
+    {{with .Doc.Branding.Name}}<strong>{{.}}</strong><br>{{end}}
+    {{range .Doc.Branding.Address}}{{.}}<br>{{end}}
+  </div>
+</header>
+<h1>{{.Doc.Title}}</h1>
+{{with .Doc.Subtitle}}<div class="subtitle">{{.}}</div>{{end}}
+<dl class="fields">
+{{range .Doc.Fields}}  <dt>{{.Label}}</dt><dd>{{.Value}}</dd>
+{{end}}</dl>
+{{range .Doc.Tables}}
+<h2>{{.Title}}</h2>
+<table>
+  <thead><tr>{{range .Columns}}<th{{if .Right}} class="right"{{end}}>{{.Label}}</th>{{end}}</tr></thead>
+  <tbody>
+  {{$columns := .Columns}}{{range .Rows}}<tr>{{range $i, $cell := .}}<td{{if (index $columns $i).Right}} class="right"{{end}}>{{$cell}}</td>{{end}}</tr>
+  {{end}}</tbody>
+</table>
+{{end}}
+{{$n := len .Doc.Totals}}{{if $n}}<table class="totals">
+{{range $i, $total := .Doc.Totals}}  <tr{{if isLast $i $n}} class="last"{{end}}><td>{{$total.Label}}</td><td class="right">{{$total.Value}}</td></tr>
+{{end}}</table>{{end}}
+{{range .Doc.Notes}}<p class="notes">{{.}}</p>
+{{end}}
+<footer>{{with .Doc.Branding.Footer}}{{.}} &middot; {{end}}Generated {{.Doc.GeneratedAt}}</footer>
+</body>
+</html>
+`
+
+var defaultTemplate = template.Must(template.New("document").Funcs(templateFuncs).Parse(defaultHTML))
+
+// templateData is what templates execute against: .Doc is the laid-out
+// document and .Data the report, timesheet or invoice behind it.
+type templateData struct {
+	Doc  *Document
+	Data interface{}
+}
+
+// ParseTemplate checks that a custom template compiles
+func ParseTemplate(text string) error {
+	_, err := template.New("custom").Funcs(templateFuncs).Parse(text)
+	return err
+}
+
+// HTML renders doc with the style's custom template for its kind, or the
+// built-in layout when there is none
+func (s Style) HTML(doc *Document) ([]byte, error) {
+	tpl := defaultTemplate
+	if text, ok := s.custom[doc.Kind]; ok {
+		custom, err := template.New("custom").Funcs(templateFuncs).Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s template: %w", doc.Kind, err)
+		}
+		tpl = custom
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, templateData{Doc: doc, Data: doc.Data}); err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", doc.Kind, err)
+	}
+	return buf.Bytes(), nil
+}
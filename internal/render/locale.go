@@ -0,0 +1,117 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+)
+
+// Locale formats numbers, money and dates for one language and region
+type Locale struct {
+	Tag        string
+	decimal    string
+	group      string
+	dateLayout string
+	timeLayout string
+	currencyLe bool // currency code before the amount ("USD 1,234.56")
+	location   *time.Location
+}
+
+// locales are the supported tags; anything else falls back to en-US
+var locales = map[string]Locale{
+	"en-US": {Tag: "en-US", decimal: ".", group: ",", dateLayout: "Jan 2, 2006", timeLayout: "3:04 PM", currencyLe: true},
+	"en-GB": {Tag: "en-GB", decimal: ".", group: ",", dateLayout: "2 Jan 2006", timeLayout: "15:04", currencyLe: true},
+	"pt-BR": {Tag: "pt-BR", decimal: ",", group: ".", dateLayout: "02/01/2006", timeLayout: "15:04"},
+	"es-ES": {Tag: "es-ES", decimal: ",", group: ".", dateLayout: "02/01/2006", timeLayout: "15:04"},
+	"fr-FR": {Tag: "fr-FR", decimal: ",", group: " ", dateLayout: "02/01/2006", timeLayout: "15:04"},
+	"de-DE": {Tag: "de-DE", decimal: ",", group: ".", dateLayout: "02.01.2006", timeLayout: "15:04"},
+}
+
+// DefaultLocale is used when a template sets none
+const DefaultLocale = "en-US"
+
+// KnownLocale reports whether tag has its own formatting rules
+func KnownLocale(tag string) bool {
+	_, ok := locales[tag]
+	return ok
+}
+
+// NewLocale returns the locale for tag showing times in loc (UTC when nil)
+func NewLocale(tag string, loc *time.Location) Locale {
+	l, ok := locales[tag]
+	if !ok {
+		l = locales[DefaultLocale]
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	l.location = loc
+	return l
+}
+
+// Money formats an amount with its currency code, e.g. "USD 1,234.56" or "1.234,56 EUR"
+func (l Locale) Money(a money.Amount, currency string) string {
+	units := int64(a)
+	sign := ""
+	if units < 0 {
+		sign, units = "-", -units
+	}
+	text := sign + l.group3(units/money.MinorUnits) + l.decimal + fmt.Sprintf("%02d", units%money.MinorUnits)
+	if currency == "" {
+		return text
+	}
+	if l.currencyLe {
+		return currency + " " + text
+	}
+	return text + " " + currency
+}
+
+// Number formats f with decimals digits after the separator
+func (l Locale) Number(f float64, decimals int) string {
+	sign := ""
+	if f < 0 {
+		sign, f = "-", -f
+	}
+	scale := math.Pow(10, float64(decimals))
+	scaled := int64(math.Round(f * scale))
+	whole := l.group3(scaled / int64(scale))
+	if decimals == 0 {
+		return sign + whole
+	}
+	return sign + whole + l.decimal + fmt.Sprintf("%0*d", decimals, scaled%int64(scale))
+}
+
+// Hours formats minutes as decimal hours, e.g. "7.50"
+func (l Locale) Hours(minutes int) string {
+	return l.Number(float64(minutes)/60.0, 2)
+}
+
+// Date formats the calendar day of t in the locale's time zone
+func (l Locale) Date(t time.Time) string {
+	return t.In(l.location).Format(l.dateLayout)
+}
+
+// Time formats the clock time of t in the locale's time zone
+func (l Locale) Time(t time.Time) string {
+	return t.In(l.location).Format(l.timeLayout)
+}
+
+// DateTime formats t as date and clock time in the locale's time zone
+func (l Locale) DateTime(t time.Time) string {
+	return l.Date(t) + " " + l.Time(t)
+}
+
+func (l Locale) group3(n int64) string {
+	digits := fmt.Sprintf("%d", n)
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(l.group)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,468 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+)
+
+// The PDF is A4 in points, set in the standard Helvetica fonts so no font
+// has to be embedded. Custom HTML templates don't apply here; the PDF always
+// uses the built-in layout with the template's branding and locale.
+const (
+	pageWidth    = 595.0
+	pageHeight   = 842.0
+	margin       = 50.0
+	bottomMargin = 70.0 // room for the footer
+	contentWidth = pageWidth - 2*margin
+)
+
+type pdfFont int
+
+const (
+	fontRegular pdfFont = iota
+	fontBold
+)
+
+// Glyph widths of the printable ASCII range (32-126) in 1/1000 em, from the
+// Helvetica and Helvetica-Bold AFM files. Other glyphs are measured as 556.
+var glyphWidths = [2][95]int{
+	{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// winAnsiExtra maps the runes outside Latin-1 that WinAnsiEncoding covers
+// and documents commonly contain
+var winAnsiExtra = map[rune]byte{
+	'€': 0x80, '…': 0x85, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94,
+	'•': 0x95, '–': 0x96, '—': 0x97,
+}
+
+// winAnsi encodes s for the standard fonts; unsupported runes become '?'
+func winAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 32 && r < 127:
+			out = append(out, byte(r))
+		case r >= 0xA0 && r <= 0xFF:
+			out = append(out, byte(r))
+		case r == '\t':
+			out = append(out, ' ')
+		default:
+			if b, ok := winAnsiExtra[r]; ok {
+				out = append(out, b)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+func textWidth(s string, font pdfFont, size float64) float64 {
+	units := 0
+	for _, b := range winAnsi(s) {
+		if b >= 32 && b < 127 {
+			units += glyphWidths[font][b-32]
+		} else {
+			units += 556
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// fit shortens s with an ellipsis until it is at most width wide
+func fit(s string, font pdfFont, size, width float64) string {
+	if textWidth(s, font, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		if candidate := string(runes) + "..."; textWidth(candidate, font, size) <= width {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// wrap breaks s into lines at most width wide
+func wrap(s string, font pdfFont, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if textWidth(candidate, font, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = fit(word, font, size, width)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// pdfImage is a logo ready to be written as an image XObject
+type pdfImage struct {
+	width, height int
+	colorSpace    string
+	filter        string
+	data          []byte
+}
+
+// loadImage prepares a PNG or JPEG logo. JPEG data is embedded as is; PNG is
+// flattened onto white and compressed, since PDF has no PNG filter.
+func loadImage(data []byte, mime string) (*pdfImage, error) {
+	switch mime {
+	case "image/jpeg":
+		cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		space := "/DeviceRGB"
+		switch cfg.ColorModel {
+		case color.GrayModel:
+			space = "/DeviceGray"
+		case color.CMYKModel:
+			space = "/DeviceCMYK"
+		}
+		return &pdfImage{width: cfg.Width, height: cfg.Height, colorSpace: space, filter: "/DCTDecode", data: data}, nil
+	case "image/png":
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		bounds := img.Bounds()
+		rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				a := uint32(c.A)
+				blend := func(v uint8) byte { return byte((uint32(v)*a + 255*(255-a)) / 255) }
+				rgb = append(rgb, blend(c.R), blend(c.G), blend(c.B))
+			}
+		}
+		return &pdfImage{width: bounds.Dx(), height: bounds.Dy(), colorSpace: "/DeviceRGB", filter: "/FlateDecode", data: deflate(rgb)}, nil
+	}
+	return nil, fmt.Errorf("unsupported image type %q", mime)
+}
+
+// CheckLogo reports whether a logo is a PNG or JPEG image that can be rendered
+func CheckLogo(data []byte, mime string) error {
+	_, err := loadImage(data, mime)
+	return err
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes()
+}
+
+// pdfPages lays content out top-down, one content stream per page
+type pdfPages struct {
+	pages []*bytes.Buffer
+	page  *bytes.Buffer
+	y     float64 // baseline cursor, from the bottom of the page
+}
+
+func (p *pdfPages) newPage() {
+	p.page = &bytes.Buffer{}
+	p.pages = append(p.pages, p.page)
+	p.y = pageHeight - margin
+}
+
+// ensure starts a new page unless height fits above the bottom margin, and
+// reports whether it did
+func (p *pdfPages) ensure(height float64) bool {
+	if p.y-height < bottomMargin {
+		p.newPage()
+		return true
+	}
+	return false
+}
+
+func (p *pdfPages) text(x, y float64, font pdfFont, size float64, gray float64, s string) {
+	fmt.Fprintf(p.page, "%.2f g BT /F%d %.1f Tf %.2f %.2f Td (%s) Tj ET\n", gray, font+1, size, x, y, pdfString(s))
+}
+
+func (p *pdfPages) textRight(right, y float64, font pdfFont, size float64, gray float64, s string) {
+	p.text(right-textWidth(s, font, size), y, font, size, gray, s)
+}
+
+func (p *pdfPages) rule(x1, x2, y float64, gray float64) {
+	fmt.Fprintf(p.page, "%.2f G 0.5 w %.2f %.2f m %.2f %.2f l S\n", gray, x1, y, x2, y)
+}
+
+// pdfString escapes s as the body of a PDF literal string
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, c := range winAnsi(s) {
+		switch {
+		case c == '(' || c == ')' || c == '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c >= 127:
+			fmt.Fprintf(&b, "\\%03o", c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// PDF renders doc as a PDF document with the built-in layout
+func (s Style) PDF(doc *Document) ([]byte, error) {
+	var logo *pdfImage
+	if len(doc.Branding.Logo) > 0 {
+		// A logo that no longer decodes shouldn't keep the document from rendering
+		if img, err := loadImage(doc.Branding.Logo, doc.Branding.LogoMime); err == nil {
+			logo = img
+		}
+	}
+
+	p := &pdfPages{}
+	p.newPage()
+	right := pageWidth - margin
+
+	// Header: logo left, issuer right
+	top := p.y
+	logoBottom := top
+	if logo != nil {
+		w, h := float64(logo.width), float64(logo.height)
+		scale := 50 / h
+		if w*scale > 160 {
+			scale = 160 / w
+		}
+		w, h = w*scale, h*scale
+		fmt.Fprintf(p.page, "q %.2f 0 0 %.2f %.2f %.2f cm /Im1 Do Q\n", w, h, margin, top-h)
+		logoBottom = top - h
+	}
+	issuerY := top - 12
+	if doc.Branding.Name != "" {
+		p.textRight(right, issuerY, fontBold, 12, 0, fit(doc.Branding.Name, fontBold, 12, contentWidth/2))
+		issuerY -= 14
+	}
+	for _, line := range doc.Branding.Address {
+		p.textRight(right, issuerY, fontRegular, 9, 0.3, fit(line, fontRegular, 9, contentWidth/2))
+		issuerY -= 12
+	}
+	p.y = min(logoBottom, issuerY+12) - 24
+
+	// Title and key facts
+	p.y -= 18
+	p.text(margin, p.y, fontBold, 18, 0, fit(doc.Title, fontBold, 18, contentWidth))
+	if doc.Subtitle != "" {
+		p.y -= 16
+		p.text(margin, p.y, fontRegular, 10, 0.4, fit(doc.Subtitle, fontRegular, 10, contentWidth))
+	}
+	p.y -= 14
+	for _, field := range doc.Fields {
+		p.ensure(13)
+		p.y -= 13
+		p.text(margin, p.y, fontRegular, 9, 0.4, fit(field.Label, fontRegular, 9, 125))
+		p.text(margin+130, p.y, fontRegular, 9, 0, fit(field.Value, fontRegular, 9, contentWidth-130))
+	}
+	p.y -= 10
+
+	for _, table := range doc.Tables {
+		p.table(table)
+	}
+
+	// Totals, right-aligned; the last one emphasized
+	if len(doc.Totals) > 0 {
+		const blockWidth = 260.0
+		left := right - blockWidth
+		p.ensure(float64(len(doc.Totals))*16 + 6)
+		for i, total := range doc.Totals {
+			font := fontRegular
+			if i == len(doc.Totals)-1 {
+				font = fontBold
+				p.y -= 4
+				p.rule(left, right, p.y, 0.6)
+			}
+			p.y -= 15
+			value := total.Value
+			p.text(left, p.y, font, 10, 0, fit(total.Label, font, 10, blockWidth-textWidth(value, font, 10)-10))
+			p.textRight(right, p.y, font, 10, 0, value)
+		}
+	}
+
+	if len(doc.Notes) > 0 {
+		p.y -= 10
+		for _, note := range doc.Notes {
+			p.y -= 4
+			for _, line := range wrap(note, fontRegular, 9, contentWidth) {
+				p.ensure(12)
+				p.y -= 12
+				p.text(margin, p.y, fontRegular, 9, 0.25, line)
+			}
+		}
+	}
+
+	// Footers go on last, once the page count is known
+	footer := "Generated " + doc.GeneratedAt
+	if doc.Branding.Footer != "" {
+		footer = doc.Branding.Footer + " · " + footer
+	}
+	for i, page := range p.pages {
+		p.page = page
+		pageLabel := fmt.Sprintf("Page %d of %d", i+1, len(p.pages))
+		p.rule(margin, right, 48, 0.8)
+		p.text(margin, 36, fontRegular, 8, 0.4, fit(footer, fontRegular, 8, contentWidth-textWidth(pageLabel, fontRegular, 8)-12))
+		p.textRight(right, 36, fontRegular, 8, 0.4, pageLabel)
+	}
+
+	return writePDF(doc.Title, p.pages, logo), nil
+}
+
+// table draws t, repeating its header row on every page it spans
+func (p *pdfPages) table(t Table) {
+	total := 0.0
+	for _, col := range t.Columns {
+		total += columnWeight(col)
+	}
+	widths := make([]float64, len(t.Columns))
+	for i, col := range t.Columns {
+		widths[i] = contentWidth * columnWeight(col) / total
+	}
+
+	cells := func(values []string, font pdfFont, gray float64) {
+		x := margin
+		for i, col := range t.Columns {
+			if i < len(values) {
+				value := fit(values[i], font, 9, widths[i]-6)
+				if col.Right {
+					p.textRight(x+widths[i]-3, p.y, font, 9, gray, value)
+				} else {
+					p.text(x+3, p.y, font, 9, gray, value)
+				}
+			}
+			x += widths[i]
+		}
+	}
+	header := func() {
+		labels := make([]string, len(t.Columns))
+		for i, col := range t.Columns {
+			labels[i] = col.Label
+		}
+		p.y -= 12
+		cells(labels, fontBold, 0)
+		p.y -= 4
+		p.rule(margin, pageWidth-margin, p.y, 0.5)
+	}
+
+	// Keep the title with the header and the first row
+	p.ensure(22 + 16 + 15)
+	p.y -= 22
+	p.text(margin, p.y, fontBold, 11, 0, fit(t.Title, fontBold, 11, contentWidth))
+	p.y -= 4
+	header()
+
+	if len(t.Rows) == 0 {
+		p.y -= 12
+		p.text(margin+3, p.y, fontRegular, 9, 0.5, "No entries")
+		p.y -= 3
+	}
+	for _, row := range t.Rows {
+		if p.ensure(15) {
+			header()
+		}
+		p.y -= 12
+		cells(row, fontRegular, 0)
+		p.y -= 3
+		p.rule(margin, pageWidth-margin, p.y, 0.85)
+	}
+	p.y -= 6
+}
+
+func columnWeight(col Column) float64 {
+	if col.Width <= 0 {
+		return 1
+	}
+	return col.Width
+}
+
+// writePDF assembles the file: catalog, page tree, the two fonts, the logo,
+// then a content stream and page object per page, the info dictionary and
+// the cross-reference table.
+func writePDF(title string, pages []*bytes.Buffer, logo *pdfImage) []byte {
+	var objects [][]byte
+	add := func(body string) int {
+		objects = append(objects, []byte(body))
+		return len(objects)
+	}
+	stream := func(dict string, data []byte) int {
+		body := fmt.Sprintf("<< %s /Length %d >>\nstream\n", dict, len(data))
+		objects = append(objects, append(append([]byte(body), data...), []byte("\nendstream")...))
+		return len(objects)
+	}
+
+	catalog := add("<< /Type /Catalog /Pages 2 0 R >>")
+	pagesRef := add("") // filled once the kids are known
+	regular := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	bold := add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+	resources := fmt.Sprintf("/Font << /F1 %d 0 R /F2 %d 0 R >>", regular, bold)
+	if logo != nil {
+		image := stream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent 8 /Filter %s",
+			logo.width, logo.height, logo.colorSpace, logo.filter), logo.data)
+		resources += fmt.Sprintf(" /XObject << /Im1 %d 0 R >>", image)
+	}
+
+	kids := make([]string, 0, len(pages))
+	for _, page := range pages {
+		content := stream("/Filter /FlateDecode", deflate(page.Bytes()))
+		ref := add(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] /Resources << %s >> /Contents %d 0 R >>",
+			pagesRef, pageWidth, pageHeight, resources, content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", ref))
+	}
+	objects[pagesRef-1] = []byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	info := add(fmt.Sprintf("<< /Title (%s) /Producer (professional-tracker) >>", pdfString(title)))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, body := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalog, info, xref)
+	return out.Bytes()
+}
@@ -0,0 +1,149 @@
+// Package documents stores the templates rendered invoices, timesheets and
+// reports are styled with, per company or per user.
+package documents
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/render"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxLogoBytes caps the logo size; it is inlined into every document
+const MaxLogoBytes = 512 * 1024
+
+var (
+	// ErrInvalidTemplate wraps validation failures of a document template
+	ErrInvalidTemplate = apperr.Validation("invalid_document_template", "invalid document template")
+	// ErrUnsupportedFormat is returned for a document format other than html or pdf
+	ErrUnsupportedFormat = apperr.Validation("unsupported_format", "unsupported document format (use html or pdf)")
+)
+
+// Format names accepted by the document endpoints
+const (
+	FormatHTML = "html"
+	FormatPDF  = "pdf"
+)
+
+// CompanyScope is the template scope shared by a company's projects
+func CompanyScope(companyID string) string {
+	return "company:" + companyID
+}
+
+// UserScope is the template scope of a user's personal documents
+func UserScope(userID string) string {
+	return "user:" + userID
+}
+
+// Load returns the first template stored for scopes, in order, or nil when
+// none is, so documents fall back to the built-in style.
+func Load(tx *gorm.DB, scopes ...string) (*db.DocumentTemplate, error) {
+	for _, scope := range scopes {
+		var tpl db.DocumentTemplate
+		err := tx.Where("scope = ?", scope).First(&tpl).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load document template: %w", err)
+		}
+		return &tpl, nil
+	}
+	return nil, nil
+}
+
+// Save validates tpl and stores it as the template of scope, replacing the
+// previous one.
+func Save(tx *gorm.DB, scope string, tpl *db.DocumentTemplate, userID string) (*db.DocumentTemplate, error) {
+	if err := Validate(tpl); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tpl.Scope = scope
+	tpl.UpdatedBy = userID
+	tpl.CreatedAt = now
+	tpl.UpdatedAt = now
+	if err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"display_name", "address", "locale", "timezone", "logo", "logo_mime", "footer",
+			"invoice_html", "report_html", "timesheet_html", "updated_by", "updated_at",
+		}),
+	}).Create(tpl).Error; err != nil {
+		return nil, fmt.Errorf("failed to save document template: %w", err)
+	}
+	return tpl, nil
+}
+
+// Validate checks the locale, time zone, logo and custom templates, filling
+// in the default locale and time zone when they are empty.
+func Validate(tpl *db.DocumentTemplate) error {
+	if tpl.Locale == "" {
+		tpl.Locale = render.DefaultLocale
+	}
+	if !render.KnownLocale(tpl.Locale) {
+		return fmt.Errorf("%w: unsupported locale %q", ErrInvalidTemplate, tpl.Locale)
+	}
+	if tpl.Timezone == "" {
+		tpl.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(tpl.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidTemplate, tpl.Timezone)
+	}
+
+	if len(tpl.Logo) > 0 {
+		if len(tpl.Logo) > MaxLogoBytes {
+			return fmt.Errorf("%w: logo exceeds %d KB", ErrInvalidTemplate, MaxLogoBytes/1024)
+		}
+		if err := render.CheckLogo(tpl.Logo, tpl.LogoMime); err != nil {
+			return fmt.Errorf("%w: logo must be a PNG or JPEG image: %v", ErrInvalidTemplate, err)
+		}
+	} else {
+		tpl.LogoMime = ""
+	}
+
+	for kind, html := range map[string]*string{
+		render.KindInvoice:   tpl.InvoiceHTML,
+		render.KindReport:    tpl.ReportHTML,
+		render.KindTimesheet: tpl.TimesheetHTML,
+	} {
+		if html == nil || strings.TrimSpace(*html) == "" {
+			continue
+		}
+		if err := render.ParseTemplate(*html); err != nil {
+			return fmt.Errorf("%w: %s template: %v", ErrInvalidTemplate, kind, err)
+		}
+	}
+	return nil
+}
+
+// CheckFormat rejects formats Render can't produce
+func CheckFormat(format string) error {
+	if format != FormatHTML && format != FormatPDF {
+		return ErrUnsupportedFormat
+	}
+	return nil
+}
+
+// Render lays doc out in format with style. A custom template that fails
+// while executing is reported as invalid rather than as a server error.
+func Render(style render.Style, doc *render.Document, format string) ([]byte, error) {
+	if err := CheckFormat(format); err != nil {
+		return nil, err
+	}
+	if format == FormatPDF {
+		return style.PDF(doc)
+	}
+	out, err := style.HTML(doc)
+	if err != nil {
+		return nil, ErrInvalidTemplate.Wrap(err)
+	}
+	return out, nil
+}
@@ -0,0 +1,249 @@
+// Package fx keeps the exchange-rate table and converts report money between
+// currencies with it.
+package fx
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvalidExchangeRate is returned for malformed currencies or rates
+	ErrInvalidExchangeRate = apperr.Validation("invalid_exchange_rate", "invalid exchange rate")
+	// ErrNoExchangeRate is returned when a report needs a conversion the table
+	// has no rate for on or before the requested date.
+	ErrNoExchangeRate = apperr.Validation("exchange_rate_missing", "no exchange rate for the requested conversion")
+	// ErrMixedCurrencies is returned when a report without a target currency
+	// would have to add up amounts in several currencies.
+	ErrMixedCurrencies = apperr.Validation("mixed_currencies", "amounts are in several currencies - request the report in one currency")
+)
+
+// Target asks a report to bring its money to Currency with the rates known on
+// AsOf. The zero Target leaves amounts in their own currencies.
+type Target struct {
+	Currency string
+	AsOf     time.Time
+}
+
+// NewTarget validates currency; a zero asOf means today
+func NewTarget(currency string, asOf time.Time) (Target, error) {
+	if currency == "" {
+		return Target{}, nil
+	}
+	code, err := money.ParseCurrency(currency)
+	if err != nil {
+		return Target{}, ErrInvalidExchangeRate.Wrap(err)
+	}
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+	return Target{Currency: code, AsOf: asOf}, nil
+}
+
+// SetRate records the price of one base unit in quote on date, replacing the
+// rate already stored for that day.
+func SetRate(tx *gorm.DB, base, quote, rate string, date time.Time, userID string) (*db.ExchangeRate, error) {
+	base, err := money.ParseCurrency(base)
+	if err != nil {
+		return nil, ErrInvalidExchangeRate.Wrap(err)
+	}
+	quote, err = money.ParseCurrency(quote)
+	if err != nil {
+		return nil, ErrInvalidExchangeRate.Wrap(err)
+	}
+	if base == quote {
+		return nil, fmt.Errorf("%w: base and quote currency must differ", ErrInvalidExchangeRate)
+	}
+	value, err := money.ParseExchangeRate(rate)
+	if err != nil {
+		return nil, ErrInvalidExchangeRate.Wrap(err)
+	}
+
+	now := time.Now()
+	row := &db.ExchangeRate{
+		BaseCurrency:  base,
+		QuoteCurrency: quote,
+		RateDate:      time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC),
+		Rate:          money.FormatExchangeRate(value),
+		UpdatedBy:     userID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "base_currency"}, {Name: "quote_currency"}, {Name: "rate_date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"rate", "updated_by", "updated_at"}),
+	}).Create(row).Error; err != nil {
+		return nil, fmt.Errorf("failed to save exchange rate: %w", err)
+	}
+	return row, nil
+}
+
+// List returns the stored rates, newest first, optionally for one currency pair
+// in either direction.
+func List(tx *gorm.DB, base, quote string) ([]db.ExchangeRate, error) {
+	q := tx.Model(&db.ExchangeRate{})
+	if base != "" && quote != "" {
+		b, err := money.ParseCurrency(base)
+		if err != nil {
+			return nil, ErrInvalidExchangeRate.Wrap(err)
+		}
+		qt, err := money.ParseCurrency(quote)
+		if err != nil {
+			return nil, ErrInvalidExchangeRate.Wrap(err)
+		}
+		q = q.Where("(base_currency = ? AND quote_currency = ?) OR (base_currency = ? AND quote_currency = ?)", b, qt, qt, b)
+	}
+
+	var rates []db.ExchangeRate
+	if err := q.Order("rate_date DESC, base_currency, quote_currency").Find(&rates).Error; err != nil {
+		return nil, fmt.Errorf("failed to load exchange rates: %w", err)
+	}
+	return rates, nil
+}
+
+// Totals accumulates a report's money per currency
+type Totals map[string]*db.CurrencyTotals
+
+// AddSession adds a work session's cost in its currency and its revenue in
+// its billable currency.
+func (t Totals) AddSession(session *db.TimeSession, cost, revenue money.Amount) {
+	costLine := t.line(session.Currency)
+	costLine.TotalCost += cost
+	costLine.WorkSessions++
+	if revenue != 0 {
+		t.line(session.BillableCurrency).Revenue += revenue
+	}
+}
+
+// Lines returns the totals of every currency, ordered by currency
+func (t Totals) Lines() []db.CurrencyTotals {
+	lines := make([]db.CurrencyTotals, 0, len(t))
+	for _, line := range t {
+		lines = append(lines, *line)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Currency < lines[j].Currency })
+	return lines
+}
+
+func (t Totals) line(currency string) *db.CurrencyTotals {
+	if currency == "" {
+		currency = db.DefaultCurrency
+	}
+	line, ok := t[currency]
+	if !ok {
+		line = &db.CurrencyTotals{Currency: currency}
+		t[currency] = line
+	}
+	return line
+}
+
+// Summary is report money brought to one currency
+type Summary struct {
+	Currency    string // empty when there is no money at all
+	TotalCost   money.Amount
+	Revenue     money.Amount
+	ByCurrency  []db.CurrencyTotals
+	Conversions []db.ConversionUsed
+}
+
+// Summarize converts each currency's totals to target, one conversion (and so
+// one rounding) per currency and amount. Without a target the totals must
+// all be in one currency; several fail with ErrMixedCurrencies.
+func Summarize(tx *gorm.DB, totals Totals, target Target) (*Summary, error) {
+	summary := &Summary{
+		ByCurrency:  totals.Lines(),
+		Conversions: make([]db.ConversionUsed, 0),
+	}
+
+	if target.Currency == "" {
+		switch len(summary.ByCurrency) {
+		case 0:
+		case 1:
+			line := summary.ByCurrency[0]
+			summary.Currency, summary.TotalCost, summary.Revenue = line.Currency, line.TotalCost, line.Revenue
+		default:
+			currencies := make([]string, len(summary.ByCurrency))
+			for i, line := range summary.ByCurrency {
+				currencies[i] = line.Currency
+			}
+			return nil, fmt.Errorf("%w: %s", ErrMixedCurrencies, strings.Join(currencies, ", "))
+		}
+		return summary, nil
+	}
+
+	summary.Currency = target.Currency
+	for _, line := range summary.ByCurrency {
+		if line.Currency == target.Currency {
+			summary.TotalCost += line.TotalCost
+			summary.Revenue += line.Revenue
+			continue
+		}
+		rate, used, err := lookup(tx, line.Currency, target.Currency, target.AsOf)
+		if err != nil {
+			return nil, err
+		}
+		summary.TotalCost += line.TotalCost.Convert(rate)
+		summary.Revenue += line.Revenue.Convert(rate)
+		summary.Conversions = append(summary.Conversions, used)
+	}
+	return summary, nil
+}
+
+// lookup finds the latest rate from->to on or before asOf. A rate stored in
+// the opposite direction is used inverted when it is the more recent one.
+func lookup(tx *gorm.DB, from, to string, asOf time.Time) (*big.Rat, db.ConversionUsed, error) {
+	direct, err := latest(tx, from, to, asOf)
+	if err != nil {
+		return nil, db.ConversionUsed{}, err
+	}
+	inverse, err := latest(tx, to, from, asOf)
+	if err != nil {
+		return nil, db.ConversionUsed{}, err
+	}
+	if direct == nil && inverse == nil {
+		return nil, db.ConversionUsed{}, fmt.Errorf("%w: %s to %s on or before %s",
+			ErrNoExchangeRate, from, to, asOf.Format("2006-01-02"))
+	}
+
+	row, inverted := direct, false
+	if direct == nil || (inverse != nil && inverse.RateDate.After(direct.RateDate)) {
+		row, inverted = inverse, true
+	}
+	rate, err := money.ParseExchangeRate(row.Rate)
+	if err != nil {
+		return nil, db.ConversionUsed{}, fmt.Errorf("stored exchange rate %d is corrupt: %w", row.ID, err)
+	}
+	if inverted {
+		rate.Inv(rate)
+	}
+	return rate, db.ConversionUsed{
+		From:     from,
+		To:       to,
+		Rate:     money.FormatExchangeRate(rate),
+		RateDate: row.RateDate,
+		Inverted: inverted,
+	}, nil
+}
+
+func latest(tx *gorm.DB, base, quote string, asOf time.Time) (*db.ExchangeRate, error) {
+	var row db.ExchangeRate
+	err := tx.Where("base_currency = ? AND quote_currency = ? AND rate_date <= ?", base, quote, asOf).
+		Order("rate_date DESC").First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load exchange rate: %w", err)
+	}
+	return &row, nil
+}
@@ -0,0 +1,52 @@
+package invoices
+
+import (
+	"context"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/render"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/documents"
+)
+
+func (s *InvoiceService) GetInvoiceDocument(
+	id uint,
+	userID string,
+	format string,
+) ([]byte, error) {
+	// Backwards-compat wrapper.
+	return s.GetInvoiceDocumentCtx(context.Background(), id, userID, format)
+}
+
+// GetInvoiceDocumentCtx renders an invoice as HTML or PDF, styled with the
+// template of the issuing company, else the issuer's personal one.
+func (s *InvoiceService) GetInvoiceDocumentCtx(
+	ctx context.Context,
+	id uint,
+	userID string,
+	format string,
+) ([]byte, error) {
+	if err := documents.CheckFormat(format); err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.GetInvoiceCtx(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := []string{documents.UserScope(invoice.IssuedBy)}
+	if invoice.CompanyID != "" {
+		scopes = append([]string{documents.CompanyScope(invoice.CompanyID)}, scopes...)
+	}
+	tpl, err := documents.Load(s.db.WithContext(ctx), scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	style := render.StyleFrom(tpl)
+	out, err := documents.Render(style, style.Invoice(invoice), format)
+	if err != nil {
+		log.Error("get-invoice-document:render-failed", "invoiceID", id, "format", format, "err", err)
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,49 @@
+package invoices
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvoiceNotFound is returned when the invoice does not exist.
+	ErrInvoiceNotFound = apperr.NotFound("invoice_not_found", "invoice not found")
+	// ErrProjectNotFound is returned when the professional project does not exist.
+	ErrProjectNotFound = apperr.NotFound("project_not_found", "professional project not found")
+	// ErrAccessDenied is returned when project-core refuses the user's access to a billed project.
+	ErrAccessDenied = apperr.Forbidden("access_denied", "access denied")
+	// ErrInvalidInvoice wraps validation failures of invoice input.
+	ErrInvalidInvoice = apperr.Validation("invalid_invoice", "invalid invoice")
+	// ErrNothingToInvoice is returned when no unbilled billable session falls into the period.
+	ErrNothingToInvoice = apperr.Validation("nothing_to_invoice", "no unbilled sessions in the period")
+	// ErrUnratedSessions is returned when a session to bill has no billable rate.
+	ErrUnratedSessions = apperr.Validation("unrated_sessions", "sessions without a billable rate - set a rate or mark them non-billable")
+	// ErrMixedCurrencies is returned when the sessions to bill use several currencies.
+	ErrMixedCurrencies = apperr.Validation("mixed_currencies", "sessions are billed in different currencies - invoice them separately")
+	// ErrSessionsAlreadyInvoiced is returned when another invoice claimed the sessions first.
+	ErrSessionsAlreadyInvoiced = apperr.Conflict("sessions_already_invoiced", "sessions were invoiced concurrently")
+	// ErrInvoiceVoid is returned when voiding an invoice twice.
+	ErrInvoiceVoid = apperr.Conflict("invoice_void", "invoice is already void")
+)
+
+// lookupError turns a missing row into notFound and keeps any other database
+// error as an internal failure.
+func lookupError(err error, notFound *apperr.Error, what string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return fmt.Errorf("failed to load %s: %w", what, err)
+}
+
+// accessError classifies a failed permission check against project-core: an
+// unreachable core stays unavailable, any other failure denies access.
+func accessError(err error) error {
+	if errors.Is(err, clients.ErrCoreUnavailable) {
+		return err
+	}
+	return ErrAccessDenied.Wrap(err)
+}
@@ -0,0 +1,509 @@
+// Package invoices bills clients for finished work sessions. An invoice claims
+// its sessions, so a session is billed at most once until the invoice is voided.
+package invoices
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/pgconnect"
+	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/* ------------------------------------------------------------------ */
+/*  Logger                                                            */
+/* ------------------------------------------------------------------ */
+
+var log = slog.Default().With(
+	slog.String("layer", "service"),
+	slog.String("service", "InvoiceService"),
+)
+
+/* ------------------------------------------------------------------ */
+/*  Service definition & constructor                                  */
+/* ------------------------------------------------------------------ */
+
+type InvoiceService struct {
+	db          *gorm.DB
+	projectRepo *pgconnect.Repository[db.ProfessionalProject]
+
+	coreClient clients.CoreProjectClient
+}
+
+func NewInvoiceService(
+	database *pgconnect.DB,
+	coreClient clients.CoreProjectClient,
+) *InvoiceService {
+	return &InvoiceService{
+		db:          database.DB,
+		projectRepo: pgconnect.NewRepository[db.ProfessionalProject](database),
+		coreClient:  coreClient,
+	}
+}
+
+// CreateInvoiceInput selects the sessions to bill and how. Exactly one of
+// ProjectID and ClientName is set.
+type CreateInvoiceInput struct {
+	ProjectID          *uint
+	ClientName         string
+	CompanyID          string    // Client invoices only: the issuing company, empty for personal projects
+	From, To           time.Time // Sessions starting in [From, To)
+	RoundingMinutes    int       // Round each line up to this increment; 0 bills exact minutes
+	TaxLabel           string
+	TaxRateBasisPoints int // 2150 = 21.5%
+	Notes              *string
+}
+
+/* ------------------------------------------------------------------ */
+/*  Issuing                                                           */
+/* ------------------------------------------------------------------ */
+
+func (s *InvoiceService) CreateInvoice(
+	in *CreateInvoiceInput,
+	userID string,
+) (*db.Invoice, error) {
+	// Backwards-compat wrapper.
+	return s.CreateInvoiceCtx(context.Background(), in, userID)
+}
+
+// CreateInvoiceCtx bills the unbilled, billable, finished work sessions of a
+// project (or of every project of a client in a company) over a period. The
+// sessions are grouped into one line per project and billable rate, each line
+// priced once on its rounded minutes, and claimed by the new invoice. It needs
+// update permission on every billed project in project-core.
+func (s *InvoiceService) CreateInvoiceCtx(
+	ctx context.Context,
+	in *CreateInvoiceInput,
+	userID string,
+) (*db.Invoice, error) {
+	log.Info("create-invoice:start", "userID", userID, "projectID", in.ProjectID, "client", in.ClientName)
+
+	if err := validateInput(in); err != nil {
+		return nil, err
+	}
+
+	projects, companyID, clientName, err := s.billedProjectsCtx(ctx, in, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	projectIDs := make([]uint, 0, len(projects))
+	for id := range projects {
+		projectIDs = append(projectIDs, id)
+	}
+	sort.Slice(projectIDs, func(i, j int) bool { return projectIDs[i] < projectIDs[j] })
+
+	now := time.Now()
+	invoice := &db.Invoice{
+		CompanyID:          companyID,
+		ClientName:         clientName,
+		ProjectID:          in.ProjectID,
+		PeriodStart:        in.From,
+		PeriodEnd:          in.To,
+		RoundingMinutes:    in.RoundingMinutes,
+		TaxLabel:           strings.TrimSpace(in.TaxLabel),
+		TaxRateBasisPoints: in.TaxRateBasisPoints,
+		Status:             db.InvoiceStatusIssued,
+		Notes:              in.Notes,
+		IssuedBy:           userID,
+		IssuedAt:           now,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var sessions []db.TimeSession
+		if err := pendingSessions(tx, projectIDs, companyID, in.From, in.To).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Order("project_id, start_time").Find(&sessions).Error; err != nil {
+			return fmt.Errorf("failed to load sessions to invoice: %w", err)
+		}
+		if err := checkBillable(sessions); err != nil {
+			return err
+		}
+
+		invoice.Currency = sessions[0].BillableCurrency
+		invoice.Lines = buildLines(sessions, projects, in.RoundingMinutes)
+		for _, line := range invoice.Lines {
+			invoice.Subtotal += line.Amount
+		}
+		invoice.TaxAmount = invoice.Subtotal.Percent(int64(in.TaxRateBasisPoints))
+		invoice.Total = invoice.Subtotal + invoice.TaxAmount
+
+		invoice.SequenceKey = db.InvoiceSequenceKey(companyID, userID)
+		number, err := nextNumberTx(tx, invoice.SequenceKey, now.Year())
+		if err != nil {
+			return err
+		}
+		invoice.Number = number
+		if err := tx.Create(invoice).Error; err != nil {
+			return fmt.Errorf("failed to create invoice: %w", err)
+		}
+
+		sessionIDs := make([]uint, len(sessions))
+		for i, session := range sessions {
+			sessionIDs[i] = session.ID
+		}
+		claim := tx.Model(&db.TimeSession{}).Where("id IN ? AND invoice_id IS NULL", sessionIDs).
+			Update("invoice_id", invoice.ID)
+		if claim.Error != nil {
+			return fmt.Errorf("failed to mark sessions invoiced: %w", claim.Error)
+		}
+		if claim.RowsAffected != int64(len(sessionIDs)) {
+			return ErrSessionsAlreadyInvoiced
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("create-invoice:failed", "userID", userID, "err", err)
+		return nil, err
+	}
+
+	log.Info("create-invoice:success", "invoiceID", invoice.ID, "number", invoice.Number,
+		"total", invoice.Total, "currency", invoice.Currency)
+	return invoice, nil
+}
+
+// billedProjectsCtx resolves the projects an invoice may bill, after checking
+// update permission on each, together with the issuing company and client.
+func (s *InvoiceService) billedProjectsCtx(
+	ctx context.Context,
+	in *CreateInvoiceInput,
+	userID string,
+) (map[uint]*db.ProfessionalProject, string, string, error) {
+	if in.ProjectID != nil {
+		var project db.ProfessionalProject
+		if err := s.projectRepo.FindByID(*in.ProjectID, &project); err != nil {
+			return nil, "", "", lookupError(err, ErrProjectNotFound, "professional project")
+		}
+		if project.ClientName == nil || strings.TrimSpace(*project.ClientName) == "" {
+			return nil, "", "", fmt.Errorf("%w: project has no client name", ErrInvalidInvoice)
+		}
+		if err := s.requireProjectManagerCtx(ctx, &project, userID); err != nil {
+			return nil, "", "", err
+		}
+		base, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
+		if err != nil {
+			return nil, "", "", accessError(err)
+		}
+		companyID := ""
+		if base.CompanyID != nil {
+			companyID = *base.CompanyID
+		}
+		return map[uint]*db.ProfessionalProject{project.ID: &project}, companyID, strings.TrimSpace(*project.ClientName), nil
+	}
+
+	clientName := strings.TrimSpace(in.ClientName)
+	var candidates []db.ProfessionalProject
+	if err := s.db.Where("TRIM(client_name) = ? AND id IN (?)", clientName,
+		pendingSessions(s.db, nil, in.CompanyID, in.From, in.To).Distinct("project_id")).
+		Order("id").Find(&candidates).Error; err != nil {
+		return nil, "", "", fmt.Errorf("failed to load client projects: %w", err)
+	}
+	if len(candidates) == 0 {
+		return nil, "", "", ErrNothingToInvoice
+	}
+
+	projects := make(map[uint]*db.ProfessionalProject, len(candidates))
+	for i := range candidates {
+		if err := s.requireProjectManagerCtx(ctx, &candidates[i], userID); err != nil {
+			return nil, "", "", err
+		}
+		projects[candidates[i].ID] = &candidates[i]
+	}
+	return projects, in.CompanyID, clientName, nil
+}
+
+// pendingSessions selects the unbilled, billable, finished work sessions of
+// companyID that started in [from, to), limited to projectIDs unless nil.
+func pendingSessions(tx *gorm.DB, projectIDs []uint, companyID string, from, to time.Time) *gorm.DB {
+	q := tx.Model(&db.TimeSession{}).
+		Where("company_id = ? AND session_type = ? AND is_active = ? AND non_billable = ? AND invoice_id IS NULL",
+			companyID, db.SessionTypeWork, false, false).
+		Where("start_time >= ? AND start_time < ?", from, to)
+	if projectIDs != nil {
+		q = q.Where("project_id IN ?", projectIDs)
+	}
+	return q
+}
+
+// checkBillable rejects an empty selection, sessions without a billable rate
+// and selections mixing billing currencies.
+func checkBillable(sessions []db.TimeSession) error {
+	if len(sessions) == 0 {
+		return ErrNothingToInvoice
+	}
+	unrated := 0
+	for _, session := range sessions {
+		if session.BillableRate == nil {
+			unrated++
+			continue
+		}
+		if session.BillableCurrency != sessions[0].BillableCurrency {
+			return fmt.Errorf("%w: %s and %s", ErrMixedCurrencies, sessions[0].BillableCurrency, session.BillableCurrency)
+		}
+	}
+	if unrated > 0 {
+		return fmt.Errorf("%w: %d of %d sessions", ErrUnratedSessions, unrated, len(sessions))
+	}
+	return nil
+}
+
+// buildLines groups sessions (ordered by project) into one line per project
+// and rate, ordered by project and rate
+func buildLines(sessions []db.TimeSession, projects map[uint]*db.ProfessionalProject, roundingMinutes int) []db.InvoiceLine {
+	type lineKey struct {
+		projectID uint
+		rate      money.Amount
+	}
+	byKey := make(map[lineKey]*db.InvoiceLine)
+	keys := make([]lineKey, 0)
+	for _, session := range sessions {
+		key := lineKey{session.ProjectID, *session.BillableRate}
+		line, ok := byKey[key]
+		if !ok {
+			line = &db.InvoiceLine{ProjectID: key.projectID, Rate: key.rate}
+			byKey[key] = line
+			keys = append(keys, key)
+		}
+		line.Sessions++
+		line.WorkedMinutes += session.NetMinutes
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].projectID != keys[j].projectID {
+			return keys[i].projectID < keys[j].projectID
+		}
+		return keys[i].rate < keys[j].rate
+	})
+
+	lines := make([]db.InvoiceLine, 0, len(keys))
+	for i, key := range keys {
+		line := byKey[key]
+		line.Position = i + 1
+		line.BilledMinutes = roundUp(line.WorkedMinutes, roundingMinutes)
+		line.Amount = line.Rate.ForMinutes(line.BilledMinutes)
+		line.Description = fmt.Sprintf("%s - %d session(s) at %s/h", projectTitle(projects[key.projectID]), line.Sessions, line.Rate)
+		lines = append(lines, *line)
+	}
+	return lines
+}
+
+// nextNumberTx takes the next invoice number of the sequence key (see
+// db.InvoiceSequenceKey) for year. The sequence row lock serializes concurrent
+// invoices, so numbers have no gaps.
+func nextNumberTx(tx *gorm.DB, key string, year int) (string, error) {
+	seq := db.InvoiceSequence{CompanyID: key, Year: year}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seq).Error; err != nil {
+		return "", fmt.Errorf("failed to create invoice sequence: %w", err)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("company_id = ? AND year = ?", key, year).First(&seq).Error; err != nil {
+		return "", fmt.Errorf("failed to lock invoice sequence: %w", err)
+	}
+	seq.LastNumber++
+	if err := tx.Model(&db.InvoiceSequence{}).Where("company_id = ? AND year = ?", key, year).
+		Update("last_number", seq.LastNumber).Error; err != nil {
+		return "", fmt.Errorf("failed to advance invoice sequence: %w", err)
+	}
+	return fmt.Sprintf("INV-%d-%04d", year, seq.LastNumber), nil
+}
+
+/* ------------------------------------------------------------------ */
+/*  Reading & voiding                                                 */
+/* ------------------------------------------------------------------ */
+
+func (s *InvoiceService) GetInvoice(
+	id uint,
+	userID string,
+) (*db.Invoice, error) {
+	// Backwards-compat wrapper.
+	return s.GetInvoiceCtx(context.Background(), id, userID)
+}
+
+// GetInvoiceCtx returns an invoice with its lines to a user with access to
+// every billed project.
+func (s *InvoiceService) GetInvoiceCtx(
+	ctx context.Context,
+	id uint,
+	userID string,
+) (*db.Invoice, error) {
+	var invoice db.Invoice
+	if err := s.db.Preload("Lines", func(q *gorm.DB) *gorm.DB { return q.Order("position") }).
+		First(&invoice, id).Error; err != nil {
+		return nil, lookupError(err, ErrInvoiceNotFound, "invoice")
+	}
+	if err := s.checkLinesAccessCtx(ctx, &invoice, userID, false); err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (s *InvoiceService) ListInvoices(
+	userID string,
+	projectID *uint,
+) ([]db.Invoice, error) {
+	// Backwards-compat wrapper.
+	return s.ListInvoicesCtx(context.Background(), userID, projectID)
+}
+
+// ListInvoicesCtx lists the invoices billing projectID, or those the user
+// issued when projectID is nil, newest first.
+func (s *InvoiceService) ListInvoicesCtx(
+	ctx context.Context,
+	userID string,
+	projectID *uint,
+) ([]db.Invoice, error) {
+	q := s.db.Preload("Lines", func(q *gorm.DB) *gorm.DB { return q.Order("position") })
+	if projectID != nil {
+		var project db.ProfessionalProject
+		if err := s.projectRepo.FindByID(*projectID, &project); err != nil {
+			return nil, lookupError(err, ErrProjectNotFound, "professional project")
+		}
+		if _, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID); err != nil {
+			return nil, accessError(err)
+		}
+		q = q.Where("id IN (?)", s.db.Model(&db.InvoiceLine{}).Select("invoice_id").Where("project_id = ?", *projectID))
+	} else {
+		q = q.Where("issued_by = ?", userID)
+	}
+
+	var invoices []db.Invoice
+	if err := q.Order("issued_at DESC").Find(&invoices).Error; err != nil {
+		log.Error("list-invoices:query-failed", "err", err)
+		return nil, fmt.Errorf("failed to retrieve invoices: %w", err)
+	}
+	return invoices, nil
+}
+
+func (s *InvoiceService) VoidInvoice(
+	id uint,
+	userID string,
+) (*db.Invoice, error) {
+	// Backwards-compat wrapper.
+	return s.VoidInvoiceCtx(context.Background(), id, userID)
+}
+
+// VoidInvoiceCtx cancels an issued invoice and releases its sessions so they
+// can be corrected and billed again. The number stays taken.
+func (s *InvoiceService) VoidInvoiceCtx(
+	ctx context.Context,
+	id uint,
+	userID string,
+) (*db.Invoice, error) {
+	log.Info("void-invoice:start", "invoiceID", id, "userID", userID)
+
+	invoice, err := s.GetInvoiceCtx(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLinesAccessCtx(ctx, invoice, userID, true); err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var locked db.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, id).Error; err != nil {
+			return lookupError(err, ErrInvoiceNotFound, "invoice")
+		}
+		if locked.Status == db.InvoiceStatusVoid {
+			return ErrInvoiceVoid
+		}
+
+		now := time.Now()
+		invoice.Status, invoice.VoidedAt, invoice.UpdatedAt = db.InvoiceStatusVoid, &now, now
+		if err := tx.Model(&locked).Updates(map[string]interface{}{
+			"status": db.InvoiceStatusVoid, "voided_at": now, "updated_at": now,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to void invoice: %w", err)
+		}
+		if err := tx.Model(&db.TimeSession{}).Where("invoice_id = ?", id).
+			Update("invoice_id", nil).Error; err != nil {
+			return fmt.Errorf("failed to release invoiced sessions: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("void-invoice:failed", "invoiceID", id, "err", err)
+		return nil, err
+	}
+
+	log.Info("void-invoice:success", "invoiceID", id, "number", invoice.Number)
+	return invoice, nil
+}
+
+/* ------------------------------------------------------------------ */
+/*  Helpers                                                           */
+/* ------------------------------------------------------------------ */
+
+func validateInput(in *CreateInvoiceInput) error {
+	if (in.ProjectID == nil) == (strings.TrimSpace(in.ClientName) == "") {
+		return fmt.Errorf("%w: set either a project or a client", ErrInvalidInvoice)
+	}
+	if !in.To.After(in.From) {
+		return fmt.Errorf("%w: period end must be after its start", ErrInvalidInvoice)
+	}
+	if in.RoundingMinutes < 0 || in.RoundingMinutes > 60 {
+		return fmt.Errorf("%w: rounding must be between 0 and 60 minutes", ErrInvalidInvoice)
+	}
+	if in.TaxRateBasisPoints < 0 || in.TaxRateBasisPoints > 100*100 {
+		return fmt.Errorf("%w: tax rate must be between 0 and 100%%", ErrInvalidInvoice)
+	}
+	return nil
+}
+
+// requireProjectManagerCtx checks that userID manages project, i.e. may
+// update its BaseProject in project-core, which a no-op update tells.
+func (s *InvoiceService) requireProjectManagerCtx(ctx context.Context, project *db.ProfessionalProject, userID string) error {
+	if _, err := s.coreClient.UpdateProject(ctx, project.BaseProjectID, userID, &clients.UpdateProjectRequest{}); err != nil {
+		log.Error("invoice:access-denied", "projectID", project.ID, "userID", userID, "err", err)
+		return accessError(err)
+	}
+	return nil
+}
+
+// checkLinesAccessCtx checks read (or update) access to every billed project.
+func (s *InvoiceService) checkLinesAccessCtx(ctx context.Context, invoice *db.Invoice, userID string, update bool) error {
+	seen := make(map[uint]bool)
+	for _, line := range invoice.Lines {
+		if seen[line.ProjectID] {
+			continue
+		}
+		seen[line.ProjectID] = true
+
+		var project db.ProfessionalProject
+		if err := s.projectRepo.FindByID(line.ProjectID, &project); err != nil {
+			return lookupError(err, ErrProjectNotFound, "professional project")
+		}
+		if update {
+			if err := s.requireProjectManagerCtx(ctx, &project, userID); err != nil {
+				return err
+			}
+		} else if _, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID); err != nil {
+			return accessError(err)
+		}
+	}
+	return nil
+}
+
+// roundUp rounds minutes up to the next multiple of increment (0 = unchanged)
+func roundUp(minutes, increment int) int {
+	if increment <= 0 || minutes%increment == 0 {
+		return minutes
+	}
+	return (minutes/increment + 1) * increment
+}
+
+func projectTitle(project *db.ProfessionalProject) string {
+	if project == nil || project.Title == "" {
+		return "Professional services"
+	}
+	return project.Title
+}
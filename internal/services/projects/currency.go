@@ -0,0 +1,162 @@
+package projects
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/fx"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/rates"
+	"gorm.io/gorm/clause"
+)
+
+/* ------------------------------------------------------------------ */
+/*  Company default currency                                          */
+/* ------------------------------------------------------------------ */
+
+func (s *ProfessionalProjectService) SetCompanyCurrency(
+	projectID uint,
+	currency string,
+	userID string,
+) (*db.CompanySettings, error) {
+	// Backwards-compat wrapper.
+	return s.SetCompanyCurrencyCtx(context.Background(), projectID, currency, userID)
+}
+
+// SetCompanyCurrencyCtx sets the default currency of the company the project
+// belongs to in project-core. Projects of that company without a currency of
+// their own bill in it; rates already recorded keep their currency.
+func (s *ProfessionalProjectService) SetCompanyCurrencyCtx(
+	ctx context.Context,
+	projectID uint,
+	currency string,
+	userID string,
+) (*db.CompanySettings, error) {
+	log.Info("set-company-currency:start", "projectID", projectID, "userID", userID)
+
+	code, err := money.ParseCurrency(currency)
+	if err != nil {
+		return nil, rates.ErrInvalidRate.Wrap(err)
+	}
+
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		log.Error("set-company-currency:not-found", "err", err)
+		return nil, lookupError(err, ErrProjectNotFound, "professional project")
+	}
+
+	if err := s.requireProjectManagerCtx(ctx, project.BaseProjectID, userID); err != nil {
+		log.Error("set-company-currency:access-denied", "projectID", projectID, "userID", userID, "err", err)
+		return nil, err
+	}
+	base, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
+	if err != nil {
+		return nil, accessError(err)
+	}
+	if base.CompanyID == nil || *base.CompanyID == "" {
+		return nil, ErrProjectHasNoCompany
+	}
+
+	now := time.Now()
+	settings := &db.CompanySettings{
+		CompanyID: *base.CompanyID,
+		Currency:  code,
+		UpdatedBy: userID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "company_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"currency", "updated_by", "updated_at"}),
+	}).Create(settings).Error; err != nil {
+		log.Error("set-company-currency:db-upsert-failed", "err", err)
+		return nil, err
+	}
+
+	log.Info("set-company-currency:success", "companyID", settings.CompanyID, "currency", code)
+	return settings, nil
+}
+
+// projectCurrencyCtx resolves the currency project bills in, asking
+// project-core for its company when the project sets none.
+func (s *ProfessionalProjectService) projectCurrencyCtx(
+	ctx context.Context,
+	project *db.ProfessionalProject,
+	userID string,
+) (string, error) {
+	if project.Currency != "" {
+		return project.Currency, nil
+	}
+	base, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
+	if err != nil {
+		return "", accessError(err)
+	}
+	companyID := ""
+	if base.CompanyID != nil {
+		companyID = *base.CompanyID
+	}
+	return rates.CompanyCurrency(s.db, companyID)
+}
+
+// optionalCurrency normalizes an ISO 4217 code; blank stays empty
+func optionalCurrency(code string) (string, error) {
+	if strings.TrimSpace(code) == "" {
+		return "", nil
+	}
+	normalized, err := money.ParseCurrency(code)
+	if err != nil {
+		return "", rates.ErrInvalidRate.Wrap(err)
+	}
+	return normalized, nil
+}
+
+/* ------------------------------------------------------------------ */
+/*  Exchange rates                                                    */
+/* ------------------------------------------------------------------ */
+
+func (s *ProfessionalProjectService) SetExchangeRate(
+	base, quote, rate string,
+	date time.Time,
+	userID string,
+) (*db.ExchangeRate, error) {
+	// Backwards-compat wrapper.
+	return s.SetExchangeRateCtx(context.Background(), base, quote, rate, date, userID)
+}
+
+// SetExchangeRateCtx records how much one base unit is worth in quote on
+// date. Callers are expected to have checked that userID may manage rates.
+func (s *ProfessionalProjectService) SetExchangeRateCtx(
+	ctx context.Context,
+	base, quote, rate string,
+	date time.Time,
+	userID string,
+) (*db.ExchangeRate, error) {
+	log.Info("set-exchange-rate:start", "base", base, "quote", quote, "date", date, "userID", userID)
+
+	row, err := fx.SetRate(s.db.WithContext(ctx), base, quote, rate, date, userID)
+	if err != nil {
+		log.Error("set-exchange-rate:failed", "err", err)
+		return nil, err
+	}
+
+	log.Info("set-exchange-rate:success", "exchangeRateID", row.ID)
+	return row, nil
+}
+
+func (s *ProfessionalProjectService) GetExchangeRates(
+	base, quote string,
+) ([]db.ExchangeRate, error) {
+	// Backwards-compat wrapper.
+	return s.GetExchangeRatesCtx(context.Background(), base, quote)
+}
+
+// GetExchangeRatesCtx lists the stored rates, newest first, optionally for
+// one currency pair.
+func (s *ProfessionalProjectService) GetExchangeRatesCtx(
+	ctx context.Context,
+	base, quote string,
+) ([]db.ExchangeRate, error) {
+	return fx.List(s.db.WithContext(ctx), base, quote)
+}
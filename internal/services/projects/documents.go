@@ -0,0 +1,120 @@
+package projects
+
+import (
+	"context"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/render"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/documents"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/fx"
+)
+
+/* ------------------------------------------------------------------ */
+/*  Document templates and rendered reports                           */
+/* ------------------------------------------------------------------ */
+
+func (s *ProfessionalProjectService) SetDocumentTemplate(
+	projectID uint,
+	tpl *db.DocumentTemplate,
+	userID string,
+) (*db.DocumentTemplate, error) {
+	// Backwards-compat wrapper.
+	return s.SetDocumentTemplateCtx(context.Background(), projectID, tpl, userID)
+}
+
+// SetDocumentTemplateCtx stores the template the invoices and reports of the
+// project's company are rendered with. Personal projects have no company;
+// their owners set a personal template instead.
+func (s *ProfessionalProjectService) SetDocumentTemplateCtx(
+	ctx context.Context,
+	projectID uint,
+	tpl *db.DocumentTemplate,
+	userID string,
+) (*db.DocumentTemplate, error) {
+	log.Info("set-document-template:start", "projectID", projectID, "userID", userID)
+
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		log.Error("set-document-template:not-found", "err", err)
+		return nil, lookupError(err, ErrProjectNotFound, "professional project")
+	}
+
+	if err := s.requireProjectManagerCtx(ctx, project.BaseProjectID, userID); err != nil {
+		log.Error("set-document-template:access-denied", "projectID", projectID, "userID", userID, "err", err)
+		return nil, err
+	}
+	base, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
+	if err != nil {
+		return nil, accessError(err)
+	}
+	if base.CompanyID == nil || *base.CompanyID == "" {
+		return nil, ErrProjectHasNoCompany
+	}
+
+	saved, err := documents.Save(s.db.WithContext(ctx), documents.CompanyScope(*base.CompanyID), tpl, userID)
+	if err != nil {
+		log.Error("set-document-template:save-failed", "err", err)
+		return nil, err
+	}
+
+	log.Info("set-document-template:success", "scope", saved.Scope)
+	return saved, nil
+}
+
+func (s *ProfessionalProjectService) GetProjectReportDocument(
+	projectID uint,
+	userID string,
+	target fx.Target,
+	format string,
+) ([]byte, error) {
+	// Backwards-compat wrapper.
+	return s.GetProjectReportDocumentCtx(context.Background(), projectID, userID, target, format)
+}
+
+// GetProjectReportDocumentCtx renders the project cost report as HTML or PDF,
+// styled with the company template, else the caller's personal one.
+func (s *ProfessionalProjectService) GetProjectReportDocumentCtx(
+	ctx context.Context,
+	projectID uint,
+	userID string,
+	target fx.Target,
+	format string,
+) ([]byte, error) {
+	if err := documents.CheckFormat(format); err != nil {
+		return nil, err
+	}
+
+	report, err := s.GetProjectCostReportCtx(ctx, projectID, userID, target)
+	if err != nil {
+		return nil, err
+	}
+
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		return nil, lookupError(err, ErrProjectNotFound, "professional project")
+	}
+	base, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
+	if err != nil {
+		return nil, accessError(err)
+	}
+	if base.Title != "" {
+		report.ProjectTitle = base.Title
+	}
+
+	scopes := []string{documents.UserScope(userID)}
+	if base.CompanyID != nil && *base.CompanyID != "" {
+		scopes = append([]string{documents.CompanyScope(*base.CompanyID)}, scopes...)
+	}
+	tpl, err := documents.Load(s.db.WithContext(ctx), scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	style := render.StyleFrom(tpl)
+	out, err := documents.Render(style, style.ProjectReport(report), format)
+	if err != nil {
+		log.Error("get-report-document:render-failed", "projectID", projectID, "format", format, "err", err)
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package projects
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrProjectNotFound is returned when the professional project does not exist.
+	ErrProjectNotFound = apperr.NotFound("project_not_found", "professional project not found")
+	// ErrAssignmentNotFound is returned when the project assignment does not exist.
+	ErrAssignmentNotFound = apperr.NotFound("assignment_not_found", "projectAssignment project not found")
+	// ErrAccessDenied is returned when project-core refuses the user's access to a project.
+	ErrAccessDenied = apperr.Forbidden("access_denied", "access denied")
+	// ErrAssignmentPrivate is returned when someone other than the worker reads an assignment.
+	ErrAssignmentPrivate = apperr.Forbidden("assignment_private", "access denied: projectAssignment project is private to the worker")
+	// ErrProjectHasNoClient is returned when setting a client rate on a project without a client.
+	ErrProjectHasNoClient = apperr.Validation("project_has_no_client", "project has no client name")
+	// ErrProjectHasNoCompany is returned when setting a company default through a personal project.
+	ErrProjectHasNoCompany = apperr.Validation("project_has_no_company", "project does not belong to a company")
+	// ErrProjectHasActiveSessions is returned when deleting a project that is being tracked.
+	ErrProjectHasActiveSessions = apperr.Conflict("project_has_active_sessions", "cannot delete project with active time sessions")
+)
+
+// lookupError turns a missing row into notFound and keeps any other database
+// error as an internal failure.
+func lookupError(err error, notFound *apperr.Error, what string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return fmt.Errorf("failed to load %s: %w", what, err)
+}
+
+// accessError classifies a failed permission check against project-core: an
+// unreachable core stays unavailable, any other failure denies access.
+func accessError(err error) error {
+	if errors.Is(err, clients.ErrCoreUnavailable) {
+		return err
+	}
+	return ErrAccessDenied.Wrap(err)
+}
@@ -2,7 +2,6 @@ package projects
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -13,6 +12,10 @@ import (
 
 	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
 	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/fx"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/rates"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 /* ------------------------------------------------------------------ */
@@ -29,9 +32,11 @@ var log = slog.Default().With(
 /* ------------------------------------------------------------------ */
 
 type ProfessionalProjectService struct {
+	db                    *gorm.DB
 	projectRepo           *pgconnect.Repository[db.ProfessionalProject]
 	projectAssignmentRepo *pgconnect.Repository[db.ProjectAssignment]
 	sessionRepo           *pgconnect.Repository[db.TimeSession]
+	breakRepo             *pgconnect.Repository[db.SessionBreak]
 
 	coreClient clients.CoreProjectClient
 }
@@ -41,9 +46,11 @@ func NewProfessionalProjectService(
 	coreClient clients.CoreProjectClient,
 ) *ProfessionalProjectService {
 	return &ProfessionalProjectService{
+		db:                    database.DB,
 		projectRepo:           pgconnect.NewRepository[db.ProfessionalProject](database),
 		projectAssignmentRepo: pgconnect.NewRepository[db.ProjectAssignment](database),
 		sessionRepo:           pgconnect.NewRepository[db.TimeSession](database),
+		breakRepo:             pgconnect.NewRepository[db.SessionBreak](database),
 		coreClient:            coreClient,
 	}
 }
@@ -55,6 +62,7 @@ func NewProfessionalProjectService(
 type CreateProfessionalProjectInput struct {
 	Title      string  `json:"title"`
 	ClientName *string `json:"clientName,omitempty"`
+	Currency   string  `json:"currency,omitempty"` // empty uses the company default
 }
 
 /* ------------------------------------------------------------------ */
@@ -76,6 +84,11 @@ func (s *ProfessionalProjectService) CreateProfessionalProjectCtx(
 	in *CreateProfessionalProjectInput,
 	userID string,
 ) (*db.ProfessionalProject, error) {
+	currency, err := optionalCurrency(in.Currency)
+	if err != nil {
+		return nil, err
+	}
+
 	bpReq := &clients.BaseProjectCreateRequest{
 		Title:   in.Title,
 		OwnerID: userID,
@@ -96,6 +109,7 @@ func (s *ProfessionalProjectService) CreateProfessionalProjectCtx(
 		BaseProjectID:   base.ID,
 		ClientName:      in.ClientName,
 		Title:           in.Title,
+		Currency:        currency,
 		TotalHours:      0,
 		TotalSalaryCost: 0,
 		IsActive:        true,
@@ -131,14 +145,14 @@ func (s *ProfessionalProjectService) GetProfessionalProjectCtx(
 	var project db.ProfessionalProject
 	if err := s.projectRepo.FindByID(id, &project); err != nil {
 		log.Error("get-professional-project:not-found", "err", err)
-		return nil, fmt.Errorf("professional project not found: %w", err)
+		return nil, lookupError(err, ErrProjectNotFound, "professional project")
 	}
 
 	// Check access through core service
 	_, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
 	if err != nil {
 		log.Error("get-professional-project:access-denied", "projectID", id, "userID", userID, "err", err)
-		return nil, fmt.Errorf("access denied: %w", err)
+		return nil, accessError(err)
 	}
 
 	if err := s.loadProjectRelations(&project); err != nil {
@@ -169,21 +183,29 @@ func (s *ProfessionalProjectService) UpdateProfessionalProjectCtx(
 	var project db.ProfessionalProject
 	if err := s.projectRepo.FindByID(id, &project); err != nil {
 		log.Error("update-professional-project:not-found", "err", err)
-		return nil, fmt.Errorf("professional project not found: %w", err)
+		return nil, lookupError(err, ErrProjectNotFound, "professional project")
 	}
 
-	// NOTE: Permission check using a no-op update (Core requires userId for update authorization).
-	// TODO(core-microservice): expose a dedicated "check update permission" endpoint to avoid no-op calls.
-	_, err := s.coreClient.UpdateProject(ctx, project.BaseProjectID, userID, &clients.UpdateProjectRequest{})
-
+	err := s.requireProjectManagerCtx(ctx, project.BaseProjectID, userID)
 	if err != nil {
 		log.Error("update-professional-project:access-denied", "projectID", id, "userID", userID, "err", err)
-		return nil, fmt.Errorf("access denied: %w", err)
+		return nil, err
 	}
 
 	if updates.ClientName != nil {
 		project.ClientName = updates.ClientName
 	}
+	if updates.BillableRate != nil {
+		if *updates.BillableRate < 0 {
+			return nil, fmt.Errorf("%w: billable rate cannot be negative", rates.ErrInvalidRate)
+		}
+		project.BillableRate = updates.BillableRate
+	}
+	if updates.Currency != "" {
+		if project.Currency, err = optionalCurrency(updates.Currency); err != nil {
+			return nil, err
+		}
+	}
 
 	if updates.IsActive != project.IsActive {
 		project.IsActive = updates.IsActive
@@ -217,14 +239,14 @@ func (s *ProfessionalProjectService) DeleteProfessionalProjectCtx(
 	var project db.ProfessionalProject
 	if err := s.projectRepo.FindByID(id, &project); err != nil {
 		log.Error("delete-professional-project:not-found", "err", err)
-		return fmt.Errorf("professional project not found: %w", err)
+		return lookupError(err, ErrProjectNotFound, "professional project")
 	}
 
 	// Check delete permissions through core service (typically owner only)
 	err := s.coreClient.DeleteProject(ctx, project.BaseProjectID, userID)
 	if err != nil {
 		log.Error("delete-professional-project:access-denied", "projectID", id, "userID", userID, "err", err)
-		return fmt.Errorf("access denied: %w", err)
+		return accessError(err)
 	}
 
 	// Check for active sessions before allowing deletion
@@ -236,7 +258,7 @@ func (s *ProfessionalProjectService) DeleteProfessionalProjectCtx(
 	}
 	if len(activeSessions) > 0 {
 		log.Warn("delete-professional-project:active-sessions", "count", len(activeSessions))
-		return errors.New("cannot delete project with active time sessions")
+		return ErrProjectHasActiveSessions
 	}
 
 	if err := s.projectRepo.Delete(&project); err != nil {
@@ -355,17 +377,46 @@ func (s *ProfessionalProjectService) CreateProjectAssignmentCtx(
 		log.Error("create-projectAssignment-project:parent-invalid", "err", err)
 		return nil, fmt.Errorf("invalid parent project: %w", err)
 	}
+	// The assignment's rates price the worker's sessions, so only managers set them.
+	if err := s.requireProjectManagerCtx(ctx, parentProject.BaseProjectID, userID); err != nil {
+		log.Error("create-projectAssignment-project:access-denied", "parentID", parentProjectID, "userID", userID, "err", err)
+		return nil, err
+	}
 
 	projectAssignment.ParentProjectID = parentProject.ID
 	projectAssignment.IsActive = true
 	projectAssignment.HoursDedicated = 0
 	projectAssignment.TotalCost = 0
+	if projectAssignment.Currency, err = optionalCurrency(projectAssignment.Currency); err != nil {
+		return nil, err
+	}
+	if projectAssignment.Currency == "" {
+		// Workers are paid in the project's currency unless agreed otherwise.
+		if projectAssignment.Currency, err = s.projectCurrencyCtx(ctx, parentProject, userID); err != nil {
+			return nil, err
+		}
+	}
 	projectAssignment.CreatedAt = time.Now()
 	projectAssignment.UpdatedAt = time.Now()
 
-	if err := s.projectAssignmentRepo.Create(projectAssignment); err != nil {
+	// The first rate period starts with the assignment.
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(projectAssignment).Error; err != nil {
+			return fmt.Errorf("failed to create projectAssignment project: %w", err)
+		}
+		change := rates.Change{
+			CostPerHour:  projectAssignment.CostPerHour,
+			BillableRate: projectAssignment.BillableRate,
+			Currency:     projectAssignment.Currency,
+		}
+		if _, err := rates.SetRate(tx, projectAssignment, change, projectAssignment.CreatedAt); err != nil {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		log.Error("create-projectAssignment-project:db-insert-failed", "err", err)
-		return nil, fmt.Errorf("failed to create projectAssignment project: %w", err)
+		return nil, err
 	}
 
 	log.Info("create-projectAssignment-project:success", "projectAssignmentID", projectAssignment.ID)
@@ -390,7 +441,7 @@ func (s *ProfessionalProjectService) GetProjectAssignmentCtx(
 	var projectAssignment db.ProjectAssignment
 	if err := s.projectAssignmentRepo.FindByID(id, &projectAssignment); err != nil {
 		log.Error("get-projectAssignment-project:not-found", "err", err)
-		return nil, fmt.Errorf("projectAssignment project not found: %w", err)
+		return nil, lookupError(err, ErrAssignmentNotFound, "projectAssignment project")
 	}
 
 	// Verify access to parent project
@@ -403,7 +454,7 @@ func (s *ProfessionalProjectService) GetProjectAssignmentCtx(
 	// Additional check for worker-specific access
 	if projectAssignment.WorkerUserID != userID {
 		log.Warn("get-projectAssignment-project:worker-access-denied", "projectAssignmentID", id, "userID", userID)
-		return nil, errors.New("access denied: projectAssignment project is private to the worker")
+		return nil, ErrAssignmentPrivate
 	}
 	return &projectAssignment, nil
 }
@@ -426,25 +477,49 @@ func (s *ProfessionalProjectService) UpdateProjectAssignmentCtx(
 ) (*db.ProjectAssignment, error) {
 	log.Info("update-projectAssignment-project:start", "projectAssignmentID", id, "userID", userID)
 
-	projectAssignment, err := s.GetProjectAssignmentCtx(ctx, id, userID)
+	projectAssignment, err := s.managedAssignmentCtx(ctx, id, userID)
 	if err != nil {
+		log.Warn("update-projectAssignment-project:access-denied", "projectAssignmentID", id, "userID", userID)
 		return nil, err
 	}
 
-	if updates.CostPerHour > 0 {
-		projectAssignment.CostPerHour = updates.CostPerHour
-	}
-	if updates.Description != nil {
-		projectAssignment.Description = updates.Description
-	}
-	if updates.IsActive != projectAssignment.IsActive {
-		projectAssignment.IsActive = updates.IsActive
-	}
-	projectAssignment.UpdatedAt = time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// A new rate starts a rate period now instead of rewriting the old one,
+		// so earlier sessions keep explaining their cost.
+		costChanged := updates.CostPerHour > 0 && updates.CostPerHour != projectAssignment.CostPerHour
+		billableChanged := updates.BillableRate != nil &&
+			(projectAssignment.BillableRate == nil || *updates.BillableRate != *projectAssignment.BillableRate)
+		currencyChanged := updates.Currency != "" && !strings.EqualFold(updates.Currency, projectAssignment.Currency)
+		if costChanged || billableChanged || currencyChanged {
+			change := rates.Change{
+				CostPerHour:  projectAssignment.CostPerHour,
+				BillableRate: updates.BillableRate,
+				Currency:     updates.Currency,
+			}
+			if updates.CostPerHour > 0 {
+				change.CostPerHour = updates.CostPerHour
+			}
+			if _, err := rates.SetRate(tx, projectAssignment, change, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		if updates.Description != nil {
+			projectAssignment.Description = updates.Description
+		}
+		if updates.IsActive != projectAssignment.IsActive {
+			projectAssignment.IsActive = updates.IsActive
+		}
+		projectAssignment.UpdatedAt = time.Now()
 
-	if err := s.projectAssignmentRepo.Update(projectAssignment); err != nil {
+		if err := tx.Omit(clause.Associations).Save(projectAssignment).Error; err != nil {
+			return fmt.Errorf("failed to update projectAssignment project: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		log.Error("update-projectAssignment-project:db-update-failed", "err", err)
-		return nil, fmt.Errorf("failed to update projectAssignment project: %w", err)
+		return nil, err
 	}
 
 	log.Info("update-projectAssignment-project:success", "projectAssignmentID", id)
@@ -510,62 +585,22 @@ func (s *ProfessionalProjectService) GetProjectAssignmentsCtx(
 /*  Reporting / Business logic                                        */
 /* ------------------------------------------------------------------ */
 
-func (s *ProfessionalProjectService) CalculateProjectTotals(
-	projectID uint,
-) error {
-	log.Info("calc-totals:start", "projectID", projectID)
-
-	var project db.ProfessionalProject
-	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
-		log.Error("calc-totals:project-not-found", "err", err)
-		return err
-	}
-
-	var sessions []db.TimeSession
-	if err := s.sessionRepo.FindWhere(&sessions,
-		"project_id = ? AND session_type = ?", projectID, db.SessionTypeWork); err != nil {
-		log.Error("calc-totals:sessions-query-failed", "err", err)
-		return err
-	}
-
-	totalHours := 0.0
-	totalCost := 0.0
-	for _, session := range sessions {
-		duration := s.calculateSessionDuration(&session)
-		hours := float64(duration) / 60.0
-		totalHours += hours
-		if session.HourlyRate != nil {
-			totalCost += hours * (*session.HourlyRate)
-		}
-	}
-
-	project.TotalHours = totalHours
-	project.TotalSalaryCost = totalCost
-	project.UpdatedAt = time.Now()
-
-	if err := s.projectRepo.Update(&project); err != nil {
-		log.Error("calc-totals:project-update-failed", "err", err)
-		return err
-	}
-
-	log.Info("calc-totals:success", "projectID", projectID,
-		"totalHours", totalHours, "totalCost", totalCost)
-	return nil
-}
-
 func (s *ProfessionalProjectService) GetProjectCostReport(
 	projectID uint,
 	userID string,
 ) (*db.ProjectTimeReport, error) {
 	log.Debug("get-cost-report", "projectID", projectID, "userID", userID)
-	return s.GetProjectCostReportCtx(context.Background(), projectID, userID)
+	return s.GetProjectCostReportCtx(context.Background(), projectID, userID, fx.Target{})
 }
 
-// GetProjectCostReportCtx is the request-scoped variant.
+// GetProjectCostReportCtx reports the project's time, cost and revenue. Money
+// is broken down by session currency; with a target currency the totals are
+// converted at the exchange rates known on target.AsOf, which the report lists.
 func (s *ProfessionalProjectService) GetProjectCostReportCtx(
 	ctx context.Context,
 	projectID uint,
 	userID string,
+	target fx.Target,
 ) (*db.ProjectTimeReport, error) {
 	project, err := s.GetProfessionalProjectCtx(ctx, projectID, userID)
 
@@ -579,11 +614,36 @@ func (s *ProfessionalProjectService) GetProjectCostReportCtx(
 	}
 
 	report := &db.ProjectTimeReport{
-		ProjectID:    projectID,
-		ProjectTitle: fmt.Sprintf("Professional Project %d", projectID),
-		TotalHours:   project.TotalHours,
-		TotalCost:    project.TotalSalaryCost,
-		WorkSessions: len(sessions),
+		ProjectID:     projectID,
+		ProjectTitle:  fmt.Sprintf("Professional Project %d", projectID),
+		TotalHours:    project.TotalHours,
+		GrossHours:    project.TotalGrossHours,
+		BreakHours:    project.TotalBreakHours,
+		WorkSessions:  len(sessions),
+		BillableHours: project.TotalBillableHours,
+	}
+
+	byCurrency := make(fx.Totals)
+	for i := range sessions {
+		session := &sessions[i]
+		if session.SessionType == db.SessionTypeWork && !session.IsActive {
+			byCurrency.AddSession(session, session.SessionCost, session.SessionRevenue)
+		}
+	}
+	summary, err := fx.Summarize(s.db.WithContext(ctx), byCurrency, target)
+	if err != nil {
+		log.Error("get-cost-report:conversion-failed", "projectID", projectID, "err", err)
+		return nil, err
+	}
+	report.Currency = summary.Currency
+	report.ByCurrency = summary.ByCurrency
+	report.Conversions = summary.Conversions
+	report.TotalCost = summary.TotalCost
+	report.Revenue = summary.Revenue
+	report.Margin = summary.Revenue - summary.TotalCost
+
+	if report.Revenue > 0 {
+		report.MarginPercent = report.Margin.Float() / report.Revenue.Float() * 100
 	}
 
 	if len(sessions) > 0 {
@@ -639,15 +699,26 @@ func (s *ProfessionalProjectService) GetUserTimeReportCtx(
 		BreakMinutes: 0,
 	}
 
+	breaksBySession, err := s.loadSessionBreaks(sessions)
+	if err != nil {
+		log.Error("get-user-time-report:breaks-query-failed", "err", err)
+		return nil, fmt.Errorf("failed to retrieve session breaks: %w", err)
+	}
+
+	now := time.Now()
+	netMinutes := 0
 	for _, session := range sessions {
-		duration := s.calculateSessionDuration(&session)
-		hours := float64(duration) / 60.0
+		times := session.Times(breaksBySession[session.ID], now)
 
 		if session.SessionType == db.SessionTypeWork {
-			report.TotalHours += hours
+			report.TotalHours += float64(times.GrossMinutes) / 60.0
+			report.BreakMinutes += times.BreakMinutes
 			report.WorkSessions++
+			netMinutes += times.NetMinutes
+			report.TotalCost += session.CostFor(times.NetMinutes)
 		} else {
-			report.BreakMinutes += duration
+			report.TotalHours += float64(times.GrossMinutes) / 60.0
+			report.BreakMinutes += times.GrossMinutes
 		}
 
 		if session.CreatedAt.After(report.LastSession) {
@@ -655,7 +726,7 @@ func (s *ProfessionalProjectService) GetUserTimeReportCtx(
 		}
 	}
 
-	report.ProductiveHours = report.TotalHours - (float64(report.BreakMinutes) / 60.0)
+	report.ProductiveHours = float64(netMinutes) / 60.0
 
 	log.Info("get-user-time-report:success", "userID", userID, "totalHours", report.TotalHours)
 	return report, nil
@@ -665,6 +736,35 @@ func (s *ProfessionalProjectService) GetUserTimeReportCtx(
 /*  Helpers                                                           */
 /* ------------------------------------------------------------------ */
 
+// managedAssignmentCtx loads an assignment for a change by userID, who must
+// manage its parent project. Workers read their own assignment but don't set
+// its rates.
+func (s *ProfessionalProjectService) managedAssignmentCtx(ctx context.Context, id uint, userID string) (*db.ProjectAssignment, error) {
+	var projectAssignment db.ProjectAssignment
+	if err := s.projectAssignmentRepo.FindByID(id, &projectAssignment); err != nil {
+		return nil, lookupError(err, ErrAssignmentNotFound, "projectAssignment project")
+	}
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectAssignment.ParentProjectID, &project); err != nil {
+		return nil, lookupError(err, ErrProjectNotFound, "professional project")
+	}
+	if err := s.requireProjectManagerCtx(ctx, project.BaseProjectID, userID); err != nil {
+		return nil, err
+	}
+	return &projectAssignment, nil
+}
+
+// requireProjectManagerCtx checks that userID manages the BaseProject, i.e.
+// may update it in project-core.
+func (s *ProfessionalProjectService) requireProjectManagerCtx(ctx context.Context, baseProjectID, userID string) error {
+	// NOTE: Permission check using a no-op update (Core requires userId for update authorization).
+	// TODO(core-microservice): expose a dedicated "check update permission" endpoint to avoid no-op calls.
+	if _, err := s.coreClient.UpdateProject(ctx, baseProjectID, userID, &clients.UpdateProjectRequest{}); err != nil {
+		return accessError(err)
+	}
+	return nil
+}
+
 func (s *ProfessionalProjectService) loadProjectRelations(
 	project *db.ProfessionalProject,
 ) error {
@@ -725,11 +825,30 @@ func (s *ProfessionalProjectService) loadRelationsForProjects(projects []db.Prof
 	return nil
 }
 
-func (s *ProfessionalProjectService) calculateSessionDuration(
-	session *db.TimeSession,
-) int {
-	if session.EndTime == nil {
-		return int(time.Since(session.StartTime).Minutes())
+// loadSessionBreaks fetches the breaks of the given sessions, grouped by session ID.
+func (s *ProfessionalProjectService) loadSessionBreaks(
+	sessions []db.TimeSession,
+) (map[uint][]db.SessionBreak, error) {
+	bySession := make(map[uint][]db.SessionBreak, len(sessions))
+	if len(sessions) == 0 {
+		return bySession, nil
+	}
+	ids := make([]interface{}, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+	ph := make([]string, len(ids))
+	for i := range ph {
+		ph[i] = "?"
+	}
+
+	var breaks []db.SessionBreak
+	if err := s.breakRepo.FindWhere(&breaks,
+		"session_id IN ("+strings.Join(ph, ",")+")", ids...); err != nil {
+		return nil, fmt.Errorf("batch load session breaks: %w", err)
+	}
+	for _, b := range breaks {
+		bySession[b.SessionID] = append(bySession[b.SessionID], b)
 	}
-	return int(session.EndTime.Sub(session.StartTime).Minutes())
+	return bySession, nil
 }
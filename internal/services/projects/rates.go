@@ -0,0 +1,268 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/rates"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+/* ------------------------------------------------------------------ */
+/*  Assignment rate history                                           */
+/* ------------------------------------------------------------------ */
+
+func (s *ProfessionalProjectService) SetAssignmentRate(
+	id uint,
+	change rates.Change,
+	effectiveFrom time.Time,
+	userID string,
+) (*db.AssignmentRate, error) {
+	// Backwards-compat wrapper.
+	return s.SetAssignmentRateCtx(context.Background(), id, change, effectiveFrom, userID)
+}
+
+// SetAssignmentRateCtx starts a new rate period for the assignment. New
+// sessions from effectiveFrom on pick up the rate; existing sessions keep theirs.
+// Only managers of the parent project may set it.
+func (s *ProfessionalProjectService) SetAssignmentRateCtx(
+	ctx context.Context,
+	id uint,
+	change rates.Change,
+	effectiveFrom time.Time,
+	userID string,
+) (*db.AssignmentRate, error) {
+	log.Info("set-assignment-rate:start", "projectAssignmentID", id, "userID", userID, "effectiveFrom", effectiveFrom)
+
+	projectAssignment, err := s.managedAssignmentCtx(ctx, id, userID)
+	if err != nil {
+		log.Warn("set-assignment-rate:access-denied", "projectAssignmentID", id, "userID", userID)
+		return nil, err
+	}
+
+	var rate *db.AssignmentRate
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		rate, err = rates.SetRate(tx, projectAssignment, change, effectiveFrom)
+		return err
+	})
+	if err != nil {
+		log.Error("set-assignment-rate:failed", "projectAssignmentID", id, "err", err)
+		return nil, err
+	}
+
+	log.Info("set-assignment-rate:success", "projectAssignmentID", id, "rateID", rate.ID)
+	return rate, nil
+}
+
+func (s *ProfessionalProjectService) GetAssignmentRates(
+	id uint,
+	userID string,
+) ([]db.AssignmentRate, error) {
+	// Backwards-compat wrapper.
+	return s.GetAssignmentRatesCtx(context.Background(), id, userID)
+}
+
+// GetAssignmentRatesCtx is the request-scoped variant.
+func (s *ProfessionalProjectService) GetAssignmentRatesCtx(
+	ctx context.Context,
+	id uint,
+	userID string,
+) ([]db.AssignmentRate, error) {
+	projectAssignment, err := s.GetProjectAssignmentCtx(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := rates.History(s.db, projectAssignment.ID)
+	if err != nil {
+		log.Error("get-assignment-rates:query-failed", "err", err)
+		return nil, err
+	}
+	if len(history) == 0 {
+		// Assignments from before rate history have a single implicit period.
+		history = []db.AssignmentRate{rates.At(nil, projectAssignment, projectAssignment.CreatedAt)}
+	}
+	return history, nil
+}
+
+func (s *ProfessionalProjectService) GetAssignmentCostReport(
+	id uint,
+	from, to time.Time,
+	userID string,
+) (*db.AssignmentCostReport, error) {
+	// Backwards-compat wrapper.
+	return s.GetAssignmentCostReportCtx(context.Background(), id, from, to, userID)
+}
+
+// GetAssignmentCostReportCtx recomputes the cost of the assignment's finished
+// work sessions that started in [from, to), each at the rate in force when it
+// started, next to the cost recorded on the sessions.
+func (s *ProfessionalProjectService) GetAssignmentCostReportCtx(
+	ctx context.Context,
+	id uint,
+	from, to time.Time,
+	userID string,
+) (*db.AssignmentCostReport, error) {
+	log.Debug("get-assignment-cost-report", "projectAssignmentID", id, "userID", userID, "from", from, "to", to)
+
+	projectAssignment, err := s.GetProjectAssignmentCtx(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := rates.History(s.db, projectAssignment.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []db.TimeSession
+	if err := s.sessionRepo.FindWhere(&sessions,
+		"project_assignment_id = ? AND session_type = ? AND is_active = ? AND start_time >= ? AND start_time < ?",
+		projectAssignment.ID, db.SessionTypeWork, false, from, to); err != nil {
+		log.Error("get-assignment-cost-report:sessions-query-failed", "err", err)
+		return nil, fmt.Errorf("failed to retrieve assignment sessions: %w", err)
+	}
+
+	breaksBySession, err := s.loadSessionBreaks(sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &db.AssignmentCostReport{
+		AssignmentID: projectAssignment.ID,
+		From:         from,
+		To:           to,
+		WorkSessions: len(sessions),
+		Periods:      make([]db.RatePeriodCostLine, 0),
+	}
+
+	// Lines are keyed by period start; periods of an assignment never share one.
+	lineIndex := make(map[int64]int)
+	now := time.Now()
+	netMinutes := 0
+	for _, session := range sessions {
+		times := session.Times(breaksBySession[session.ID], now)
+		rate := rates.At(history, projectAssignment, session.StartTime)
+		cost := db.CostAtRate(times.NetMinutes, rate.CostPerHour)
+
+		i, ok := lineIndex[rate.EffectiveFrom.UnixNano()]
+		if !ok {
+			i = len(report.Periods)
+			lineIndex[rate.EffectiveFrom.UnixNano()] = i
+			report.Periods = append(report.Periods, db.RatePeriodCostLine{
+				CostPerHour:   rate.CostPerHour,
+				Currency:      rate.Currency,
+				EffectiveFrom: rate.EffectiveFrom,
+				EffectiveTo:   rate.EffectiveTo,
+			})
+		}
+		line := &report.Periods[i]
+		line.Hours += float64(times.NetMinutes) / 60.0
+		line.Cost += cost
+		line.WorkSessions++
+
+		netMinutes += times.NetMinutes
+		report.TotalCost += cost
+		report.RecordedCost += session.SessionCost
+	}
+	report.TotalHours = float64(netMinutes) / 60.0
+	sort.Slice(report.Periods, func(i, j int) bool {
+		return report.Periods[i].EffectiveFrom.Before(report.Periods[j].EffectiveFrom)
+	})
+
+	log.Info("get-assignment-cost-report:success", "projectAssignmentID", id,
+		"totalHours", report.TotalHours, "totalCost", report.TotalCost, "recordedCost", report.RecordedCost)
+	return report, nil
+}
+
+/* ------------------------------------------------------------------ */
+/*  Client billing rates                                              */
+/* ------------------------------------------------------------------ */
+
+func (s *ProfessionalProjectService) SetClientRate(
+	projectID uint,
+	billableRate money.Amount,
+	currency string,
+	userID string,
+) (*db.ClientRate, error) {
+	// Backwards-compat wrapper.
+	return s.SetClientRateCtx(context.Background(), projectID, billableRate, currency, userID)
+}
+
+// SetClientRateCtx sets the default billable rate of the project's client in
+// the company the project belongs to in project-core. It applies to every
+// project of that client which sets no rate of its own. An empty currency
+// means the project's.
+func (s *ProfessionalProjectService) SetClientRateCtx(
+	ctx context.Context,
+	projectID uint,
+	billableRate money.Amount,
+	currency string,
+	userID string,
+) (*db.ClientRate, error) {
+	log.Info("set-client-rate:start", "projectID", projectID, "userID", userID)
+
+	if billableRate < 0 {
+		return nil, fmt.Errorf("%w: billable rate cannot be negative", rates.ErrInvalidRate)
+	}
+	currency, err := optionalCurrency(currency)
+	if err != nil {
+		return nil, err
+	}
+
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		log.Error("set-client-rate:not-found", "err", err)
+		return nil, lookupError(err, ErrProjectNotFound, "professional project")
+	}
+	if project.ClientName == nil || strings.TrimSpace(*project.ClientName) == "" {
+		return nil, ErrProjectHasNoClient
+	}
+
+	if err := s.requireProjectManagerCtx(ctx, project.BaseProjectID, userID); err != nil {
+		log.Error("set-client-rate:access-denied", "projectID", projectID, "userID", userID, "err", err)
+		return nil, err
+	}
+	base, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
+	if err != nil {
+		return nil, accessError(err)
+	}
+
+	now := time.Now()
+	rate := &db.ClientRate{
+		ClientName:   strings.TrimSpace(*project.ClientName),
+		BillableRate: billableRate,
+		Currency:     currency,
+		UpdatedBy:    userID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if base.CompanyID != nil {
+		rate.CompanyID = *base.CompanyID
+	}
+	if rate.Currency == "" {
+		if project.Currency != "" {
+			rate.Currency = project.Currency
+		} else if rate.Currency, err = rates.CompanyCurrency(s.db, rate.CompanyID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "company_id"}, {Name: "client_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"billable_rate_minor", "currency", "updated_by", "updated_at"}),
+	}).Create(rate).Error; err != nil {
+		log.Error("set-client-rate:db-upsert-failed", "err", err)
+		return nil, fmt.Errorf("failed to save client rate: %w", err)
+	}
+
+	log.Info("set-client-rate:success", "projectID", projectID, "companyID", rate.CompanyID, "client", rate.ClientName)
+	return rate, nil
+}
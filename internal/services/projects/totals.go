@@ -0,0 +1,165 @@
+package projects
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/totals"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// driftTolerance absorbs float rounding between incremental and full sums of
+// hours; money is exact, so any difference in cents exceeds it
+const driftTolerance = 1e-6
+
+// TotalsDrift is a stored aggregate that differed from its recomputed value
+type TotalsDrift struct {
+	Field      string  `json:"field"`
+	Stored     float64 `json:"stored"`
+	Recomputed float64 `json:"recomputed"`
+}
+
+// AssignmentTotals reports the recomputed aggregates of one assignment
+type AssignmentTotals struct {
+	AssignmentID   uint          `json:"assignmentId"`
+	HoursDedicated float64       `json:"hoursDedicated"`
+	TotalCost      money.Amount  `json:"totalCost"`
+	TotalRevenue   money.Amount  `json:"totalRevenue"`
+	Drift          []TotalsDrift `json:"drift"`
+}
+
+// TotalsRecomputeReport is the outcome of recomputing a project from scratch
+type TotalsRecomputeReport struct {
+	ProjectID       uint               `json:"projectId"`
+	TotalHours      float64            `json:"totalHours"`
+	TotalGrossHours float64            `json:"totalGrossHours"`
+	TotalBreakHours float64            `json:"totalBreakHours"`
+	TotalSalaryCost money.Amount       `json:"totalSalaryCost"`
+	TotalRevenue    money.Amount       `json:"totalRevenue"`
+	Drift           []TotalsDrift      `json:"drift"`
+	Assignments     []AssignmentTotals `json:"assignments"`
+	HasDrift        bool               `json:"hasDrift"`
+}
+
+func (s *ProfessionalProjectService) CalculateProjectTotals(
+	projectID uint,
+) error {
+	log.Info("calc-totals:start", "projectID", projectID)
+	// Backwards-compat wrapper.
+	_, err := s.recomputeTotals(projectID)
+	return err
+}
+
+func (s *ProfessionalProjectService) RecomputeProjectTotals(
+	projectID uint,
+	userID string,
+) (*TotalsRecomputeReport, error) {
+	log.Info("recompute-totals:start", "projectID", projectID, "userID", userID)
+	// Backwards-compat wrapper.
+	return s.RecomputeProjectTotalsCtx(context.Background(), projectID, userID)
+}
+
+// RecomputeProjectTotalsCtx rebuilds the aggregates of a project and all its
+// assignments from their sessions and reports every value that had drifted.
+// It needs update permission on the project in project-core.
+func (s *ProfessionalProjectService) RecomputeProjectTotalsCtx(
+	ctx context.Context,
+	projectID uint,
+	userID string,
+) (*TotalsRecomputeReport, error) {
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		log.Error("recompute-totals:not-found", "err", err)
+		return nil, lookupError(err, ErrProjectNotFound, "professional project")
+	}
+
+	if err := s.requireProjectManagerCtx(ctx, project.BaseProjectID, userID); err != nil {
+		log.Error("recompute-totals:access-denied", "projectID", projectID, "userID", userID, "err", err)
+		return nil, err
+	}
+
+	return s.recomputeTotals(projectID)
+}
+
+// recomputeTotals recalculates the project, then its assignments in ID order,
+// comparing each against the values stored before.
+func (s *ProfessionalProjectService) recomputeTotals(projectID uint) (*TotalsRecomputeReport, error) {
+	report := &TotalsRecomputeReport{ProjectID: projectID, Assignments: make([]AssignmentTotals, 0)}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var stored db.ProfessionalProject
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&stored, projectID).Error; err != nil {
+			return lookupError(err, ErrProjectNotFound, "professional project")
+		}
+		project, err := totals.RecalculateProject(tx, projectID)
+		if err != nil {
+			return err
+		}
+		report.TotalHours = project.TotalHours
+		report.TotalGrossHours = project.TotalGrossHours
+		report.TotalBreakHours = project.TotalBreakHours
+		report.TotalSalaryCost = project.TotalSalaryCost
+		report.TotalRevenue = project.TotalRevenue
+		report.Drift = collectDrift(
+			TotalsDrift{"totalHours", stored.TotalHours, project.TotalHours},
+			TotalsDrift{"totalGrossHours", stored.TotalGrossHours, project.TotalGrossHours},
+			TotalsDrift{"totalBreakHours", stored.TotalBreakHours, project.TotalBreakHours},
+			TotalsDrift{"totalBillableHours", stored.TotalBillableHours, project.TotalBillableHours},
+			TotalsDrift{"totalSalaryCost", stored.TotalSalaryCost.Float(), project.TotalSalaryCost.Float()},
+			TotalsDrift{"totalRevenue", stored.TotalRevenue.Float(), project.TotalRevenue.Float()},
+		)
+
+		var assignments []db.ProjectAssignment
+		if err := tx.Where("parent_project_id = ?", projectID).Order("id").Find(&assignments).Error; err != nil {
+			return fmt.Errorf("failed to load project assignments: %w", err)
+		}
+		for _, stored := range assignments {
+			assignment, err := totals.RecalculateAssignment(tx, stored.ID)
+			if err != nil {
+				return err
+			}
+			report.Assignments = append(report.Assignments, AssignmentTotals{
+				AssignmentID:   assignment.ID,
+				HoursDedicated: assignment.HoursDedicated,
+				TotalCost:      assignment.TotalCost,
+				TotalRevenue:   assignment.TotalRevenue,
+				Drift: collectDrift(
+					TotalsDrift{"hoursDedicated", stored.HoursDedicated, assignment.HoursDedicated},
+					TotalsDrift{"totalCost", stored.TotalCost.Float(), assignment.TotalCost.Float()},
+					TotalsDrift{"totalRevenue", stored.TotalRevenue.Float(), assignment.TotalRevenue.Float()},
+				),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		log.Error("recompute-totals:failed", "projectID", projectID, "err", err)
+		return nil, err
+	}
+
+	report.HasDrift = len(report.Drift) > 0
+	for _, a := range report.Assignments {
+		report.HasDrift = report.HasDrift || len(a.Drift) > 0
+	}
+	if report.HasDrift {
+		log.Warn("recompute-totals:drift", "projectID", projectID, "drift", report.Drift)
+	}
+	log.Info("recompute-totals:success", "projectID", projectID,
+		"totalHours", report.TotalHours, "totalCost", report.TotalSalaryCost, "hasDrift", report.HasDrift)
+	return report, nil
+}
+
+// collectDrift keeps the values whose stored and recomputed sides differ
+func collectDrift(values ...TotalsDrift) []TotalsDrift {
+	drift := make([]TotalsDrift, 0)
+	for _, v := range values {
+		if math.Abs(v.Stored-v.Recomputed) > driftTolerance {
+			drift = append(drift, v)
+		}
+	}
+	return drift
+}
@@ -0,0 +1,35 @@
+package rates
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"gorm.io/gorm"
+)
+
+// ProjectCurrency returns the currency of project's own rates: its Currency,
+// else the default of companyID, else db.DefaultCurrency.
+func ProjectCurrency(tx *gorm.DB, project *db.ProfessionalProject, companyID string) (string, error) {
+	if project.Currency != "" {
+		return project.Currency, nil
+	}
+	return CompanyCurrency(tx, companyID)
+}
+
+// CompanyCurrency returns the default currency of companyID, or
+// db.DefaultCurrency when none was set or for personal projects.
+func CompanyCurrency(tx *gorm.DB, companyID string) (string, error) {
+	if companyID == "" {
+		return db.DefaultCurrency, nil
+	}
+	var settings db.CompanySettings
+	err := tx.Where("company_id = ?", companyID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.DefaultCurrency, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to load company settings: %w", err)
+	}
+	return settings.Currency, nil
+}
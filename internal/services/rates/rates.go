@@ -0,0 +1,171 @@
+// Package rates keeps the rate history of project assignments and answers
+// which rate was in force at a given time.
+package rates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrInvalidRate is returned for a negative rate or a malformed currency.
+	ErrInvalidRate = apperr.Validation("invalid_rate", "invalid rate")
+	// ErrRateBeforeHistory is returned when a new rate would start before the
+	// latest period; history is append-only so past costs stay explainable.
+	ErrRateBeforeHistory = apperr.Validation("rate_before_history", "rate must take effect after the latest rate period started")
+)
+
+// EffectiveRate returns the rate period of assignment in force at at. An
+// assignment without history (created before rate periods existed) yields an
+// open period at its CostPerHour.
+func EffectiveRate(tx *gorm.DB, assignment *db.ProjectAssignment, at time.Time) (db.AssignmentRate, error) {
+	var rate db.AssignmentRate
+	err := tx.Where("assignment_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)",
+		assignment.ID, at, at).Order("effective_from DESC").First(&rate).Error
+	if err == nil {
+		return rate, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return db.AssignmentRate{}, fmt.Errorf("failed to load assignment rate: %w", err)
+	}
+	return initialRate(assignment), nil
+}
+
+// At picks the period of history in force at at without touching the
+// database; history must belong to assignment and be ordered as by History.
+func At(history []db.AssignmentRate, assignment *db.ProjectAssignment, at time.Time) db.AssignmentRate {
+	for i := len(history) - 1; i >= 0; i-- {
+		rate := history[i]
+		if !rate.EffectiveFrom.After(at) && (rate.EffectiveTo == nil || rate.EffectiveTo.After(at)) {
+			return rate
+		}
+	}
+	return initialRate(assignment)
+}
+
+// History returns the rate periods of an assignment, oldest first
+func History(tx *gorm.DB, assignmentID uint) ([]db.AssignmentRate, error) {
+	var history []db.AssignmentRate
+	if err := tx.Where("assignment_id = ?", assignmentID).Order("effective_from").Find(&history).Error; err != nil {
+		return nil, fmt.Errorf("failed to load rate history: %w", err)
+	}
+	return history, nil
+}
+
+// Change describes the rates of a new period
+type Change struct {
+	CostPerHour  money.Amount
+	BillableRate *money.Amount // nil keeps the current billable rate
+	Currency     string        // empty keeps the current currency
+}
+
+// SetRate starts a new rate period for assignment at from, closing the current
+// one there. When the new period is already in force its rates also become the
+// assignment's CostPerHour and BillableRate.
+func SetRate(tx *gorm.DB, assignment *db.ProjectAssignment, change Change, from time.Time) (*db.AssignmentRate, error) {
+	if change.CostPerHour < 0 || (change.BillableRate != nil && *change.BillableRate < 0) {
+		return nil, fmt.Errorf("%w: rate cannot be negative", ErrInvalidRate)
+	}
+	currency := ""
+	if strings.TrimSpace(change.Currency) != "" {
+		var err error
+		if currency, err = money.ParseCurrency(change.Currency); err != nil {
+			return nil, ErrInvalidRate.Wrap(err)
+		}
+	}
+
+	// The assignment row serializes concurrent rate changes.
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(assignment, assignment.ID).Error; err != nil {
+		return nil, fmt.Errorf("project assignment not found: %w", err)
+	}
+
+	var latest db.AssignmentRate
+	err := tx.Where("assignment_id = ?", assignment.ID).Order("effective_from DESC").First(&latest).Error
+	switch {
+	case err == nil:
+		if !from.After(latest.EffectiveFrom) {
+			return nil, ErrRateBeforeHistory
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// Record the rate the assignment had so far as its first period.
+		latest = initialRate(assignment)
+		latest.CreatedAt = time.Now()
+		if !from.After(latest.EffectiveFrom) {
+			// Nothing happened before from; the new rate is the first period.
+			latest = db.AssignmentRate{Currency: latest.Currency, BillableRate: latest.BillableRate}
+		} else if err := tx.Create(&latest).Error; err != nil {
+			return nil, fmt.Errorf("failed to record initial rate: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("failed to load rate history: %w", err)
+	}
+
+	if currency == "" {
+		currency = latest.Currency
+	}
+	if currency == "" {
+		currency = currencyOf(assignment)
+	}
+	billableRate := change.BillableRate
+	if billableRate == nil {
+		billableRate = latest.BillableRate
+	}
+
+	if latest.ID != 0 {
+		end := from
+		latest.EffectiveTo = &end
+		if err := tx.Model(&latest).Update("effective_to", end).Error; err != nil {
+			return nil, fmt.Errorf("failed to close rate period: %w", err)
+		}
+	}
+
+	rate := &db.AssignmentRate{
+		AssignmentID:  assignment.ID,
+		CostPerHour:   change.CostPerHour,
+		BillableRate:  billableRate,
+		Currency:      currency,
+		EffectiveFrom: from,
+		CreatedAt:     time.Now(),
+	}
+	if err := tx.Create(rate).Error; err != nil {
+		return nil, fmt.Errorf("failed to create rate period: %w", err)
+	}
+
+	if !from.After(time.Now()) {
+		assignment.CostPerHour = change.CostPerHour
+		assignment.BillableRate = billableRate
+		assignment.Currency = currency
+		assignment.UpdatedAt = time.Now()
+		if err := tx.Model(assignment).Select("CostPerHour", "BillableRate", "Currency", "UpdatedAt").Updates(assignment).Error; err != nil {
+			return nil, fmt.Errorf("failed to update assignment rate: %w", err)
+		}
+	}
+	return rate, nil
+}
+
+// initialRate is the open period standing in for an assignment without history
+func initialRate(assignment *db.ProjectAssignment) db.AssignmentRate {
+	return db.AssignmentRate{
+		AssignmentID:  assignment.ID,
+		CostPerHour:   assignment.CostPerHour,
+		BillableRate:  assignment.BillableRate,
+		Currency:      currencyOf(assignment),
+		EffectiveFrom: assignment.CreatedAt,
+	}
+}
+
+// currencyOf returns the assignment's currency, defaulting rows from before it was tracked
+func currencyOf(assignment *db.ProjectAssignment) string {
+	if assignment.Currency == "" {
+		return db.DefaultCurrency
+	}
+	return assignment.Currency
+}
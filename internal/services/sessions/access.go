@@ -0,0 +1,185 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/fx"
+)
+
+// Project-core roles and permissions that matter for session privacy
+const (
+	RoleOwner = "owner"
+	// PermissionViewSessionDetails lets a member see other members' individual
+	// sessions (times, notes, rates) instead of aggregates only.
+	PermissionViewSessionDetails = "view_session_details"
+)
+
+// ProjectSessionSummary aggregates every work session of a project without
+// exposing who worked when. TotalCost is only set, in Currency, when every
+// session is costed in the same currency; ByCurrency keeps them apart.
+type ProjectSessionSummary struct {
+	WorkSessions  int
+	Workers       int
+	ActiveWorkers int
+	GrossMinutes  int
+	BreakMinutes  int
+	NetMinutes    int
+	TotalCost     money.Amount
+	Currency      string
+	ByCurrency    []db.CurrencyTotals
+}
+
+// ProjectSessionsView is what a member may see of a project's sessions.
+// Sessions holds the detailed sessions visible to the caller: their own, or
+// everyone's with PermissionViewSessionDetails. Summary covers all sessions and
+// is only set for owners and members with the detail permission.
+type ProjectSessionsView struct {
+	Sessions     []db.TimeSession
+	Summary      *ProjectSessionSummary
+	DetailAccess bool
+}
+
+// projectMemberCtx returns the user's membership of the project's BaseProject
+// in project-core. An unreachable core is reported as such; any other failure,
+// or not being listed, is ErrProjectAccessDenied.
+func (s *TimeSessionService) projectMemberCtx(ctx context.Context, project *db.ProfessionalProject, userID string) (*clients.ProjectMember, error) {
+	members, err := s.coreClient.GetProjectMembers(ctx, project.BaseProjectID, userID)
+	if err != nil {
+		if errors.Is(err, clients.ErrCoreUnavailable) {
+			return nil, err
+		}
+		return nil, ErrProjectAccessDenied.Wrap(err)
+	}
+	for i := range members {
+		if members[i].UserID == userID {
+			return &members[i], nil
+		}
+	}
+	return nil, ErrProjectAccessDenied
+}
+
+// requireProjectManagerCtx checks that userID manages the BaseProject, i.e.
+// may update it in project-core, which a no-op update tells. An unreachable
+// core is reported as such; any other failure is ErrProjectAccessDenied.
+func (s *TimeSessionService) requireProjectManagerCtx(ctx context.Context, baseProjectID, userID string) error {
+	if _, err := s.coreClient.UpdateProject(ctx, baseProjectID, userID, &clients.UpdateProjectRequest{}); err != nil {
+		if errors.Is(err, clients.ErrCoreUnavailable) {
+			return err
+		}
+		return ErrProjectAccessDenied.Wrap(err)
+	}
+	return nil
+}
+
+// verifyProjectAccessCtx checks, before a session is started on the project,
+// that userID is a member of its BaseProject and that companyID is the company
+// the project belongs to in project-core. Personal projects (no company in
+// core) accept any company context.
+func (s *TimeSessionService) verifyProjectAccessCtx(ctx context.Context, projectID uint, companyID, userID string) error {
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		return lookupError(err, ErrProjectNotFound, "project")
+	}
+
+	if _, err := s.projectMemberCtx(ctx, &project, userID); err != nil {
+		return err
+	}
+
+	base, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
+	if err != nil {
+		if errors.Is(err, clients.ErrCoreUnavailable) {
+			return err
+		}
+		return ErrProjectAccessDenied.Wrap(err)
+	}
+	if base.CompanyID != nil && *base.CompanyID != companyID {
+		return ErrCompanyMismatch
+	}
+	return nil
+}
+
+// GetProjectSessionsCtx returns the sessions of a project the user may see
+// under the privacy rules: workers see their own sessions in detail, owners
+// additionally get aggregates, and PermissionViewSessionDetails unlocks
+// everyone's detailed sessions.
+func (s *TimeSessionService) GetProjectSessionsCtx(ctx context.Context, projectID uint, userID string) (*ProjectSessionsView, error) {
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		return nil, lookupError(err, ErrProjectNotFound, "project")
+	}
+
+	member, err := s.projectMemberCtx(ctx, &project, userID)
+	if err != nil {
+		return nil, err
+	}
+	detailAccess := hasPermission(member, PermissionViewSessionDetails)
+
+	var sessions []db.TimeSession
+	if err := s.sessionRepo.FindWhere(&sessions, "project_id = ?", projectID); err != nil {
+		return nil, fmt.Errorf("failed to retrieve project sessions: %w", err)
+	}
+	if err := s.fillActiveSessionTimes(sessions); err != nil {
+		return nil, err
+	}
+
+	view := &ProjectSessionsView{DetailAccess: detailAccess}
+	if detailAccess || member.Role == RoleOwner {
+		view.Summary = summarizeSessions(sessions)
+	}
+	if detailAccess {
+		view.Sessions = sessions
+		return view, nil
+	}
+
+	view.Sessions = make([]db.TimeSession, 0)
+	for _, session := range sessions {
+		if session.UserID == userID {
+			view.Sessions = append(view.Sessions, session)
+		}
+	}
+	return view, nil
+}
+
+// summarizeSessions aggregates the work sessions of a project
+func summarizeSessions(sessions []db.TimeSession) *ProjectSessionSummary {
+	summary := &ProjectSessionSummary{}
+	workers := make(map[string]bool)
+	active := make(map[string]bool)
+	byCurrency := make(fx.Totals)
+	for _, session := range sessions {
+		if session.SessionType != db.SessionTypeWork {
+			continue
+		}
+		summary.WorkSessions++
+		summary.GrossMinutes += session.DurationMinutes
+		summary.BreakMinutes += session.BreakMinutes
+		summary.NetMinutes += session.NetMinutes
+		byCurrency.AddSession(&session, session.SessionCost, 0)
+		workers[session.UserID] = true
+		if session.IsActive {
+			active[session.UserID] = true
+		}
+	}
+	summary.Workers = len(workers)
+	summary.ActiveWorkers = len(active)
+	summary.ByCurrency = byCurrency.Lines()
+	if len(summary.ByCurrency) == 1 {
+		summary.Currency, summary.TotalCost = summary.ByCurrency[0].Currency, summary.ByCurrency[0].TotalCost
+	}
+	return summary
+}
+
+// hasPermission reports whether the member was granted permission in project-core
+func hasPermission(member *clients.ProjectMember, permission string) bool {
+	for _, p := range member.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+package sessions
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/ical"
+	"gorm.io/gorm"
+)
+
+// CalendarFeedHistory is how far back a calendar feed reaches. Calendar
+// clients re-download the whole feed on every refresh, so it stays bounded.
+const CalendarFeedHistory = 180 * 24 * time.Hour
+
+// CalendarFeedInput narrows a new feed to a company or a project
+type CalendarFeedInput struct {
+	Name      string
+	CompanyID *string
+	ProjectID *uint
+}
+
+// CreateCalendarFeedCtx creates a feed of the user's sessions and returns it
+// with its token. The token is the only credential of the feed URL and
+// can't be retrieved again; delete the feed to revoke it.
+func (s *TimeSessionService) CreateCalendarFeedCtx(ctx context.Context, userID string, in *CalendarFeedInput) (*db.CalendarFeed, string, error) {
+	if in.CompanyID != nil && *in.CompanyID == "" {
+		in.CompanyID = nil
+	}
+	if in.ProjectID != nil {
+		var project db.ProfessionalProject
+		if err := s.projectRepo.FindByID(*in.ProjectID, &project); err != nil {
+			return nil, "", lookupError(err, ErrProjectNotFound, "project")
+		}
+		if _, err := s.projectMemberCtx(ctx, &project, userID); err != nil {
+			return nil, "", err
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate feed token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		name = "Work sessions"
+	}
+	feed := &db.CalendarFeed{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashFeedToken(token),
+		CompanyID: in.CompanyID,
+		ProjectID: in.ProjectID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(feed).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to create calendar feed: %w", err)
+	}
+	return feed, token, nil
+}
+
+// GetCalendarFeeds lists the user's feeds, newest first
+func (s *TimeSessionService) GetCalendarFeeds(userID string) ([]db.CalendarFeed, error) {
+	var feeds []db.CalendarFeed
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&feeds).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve calendar feeds: %w", err)
+	}
+	return feeds, nil
+}
+
+// DeleteCalendarFeed revokes one of the user's feeds
+func (s *TimeSessionService) DeleteCalendarFeed(userID string, feedID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", feedID, userID).Delete(&db.CalendarFeed{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete calendar feed: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrCalendarFeedNotFound
+	}
+	return nil
+}
+
+// CalendarFeedEventsCtx resolves a feed token and returns the feed with one
+// event per work session of the last CalendarFeedHistory. Unknown tokens are
+// ErrCalendarFeedNotFound, whether they never existed or were revoked.
+func (s *TimeSessionService) CalendarFeedEventsCtx(ctx context.Context, token string) (*db.CalendarFeed, []ical.Event, error) {
+	var feed db.CalendarFeed
+	if err := s.db.WithContext(ctx).Where("token_hash = ?", hashFeedToken(token)).First(&feed).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrCalendarFeedNotFound
+		}
+		return nil, nil, fmt.Errorf("failed to load calendar feed: %w", err)
+	}
+
+	now := time.Now()
+	q := s.db.WithContext(ctx).
+		Where("user_id = ? AND session_type = ? AND start_time >= ?", feed.UserID, db.SessionTypeWork, now.Add(-CalendarFeedHistory))
+	if feed.CompanyID != nil {
+		q = q.Where("company_id = ?", *feed.CompanyID)
+	}
+	if feed.ProjectID != nil {
+		q = q.Where("project_id = ?", *feed.ProjectID)
+	}
+	var sessions []db.TimeSession
+	if err := q.Order("start_time").Find(&sessions).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve sessions: %w", err)
+	}
+
+	breaksBySession, err := s.loadBreaks(sessions)
+	if err != nil {
+		return nil, nil, err
+	}
+	titles := s.projectTitlesCtx(ctx, sessions, feed.UserID)
+
+	events := make([]ical.Event, 0, len(sessions))
+	for i := range sessions {
+		session := &sessions[i]
+		breaks := breaksBySession[session.ID]
+		times := session.Times(breaks, now)
+		end := now
+		if session.EndTime != nil {
+			end = *session.EndTime
+		}
+		events = append(events, ical.Event{
+			UID:          fmt.Sprintf("session-%d@professional-tracker", session.ID),
+			Start:        session.StartTime,
+			End:          end,
+			Stamp:        now,
+			LastModified: session.UpdatedAt,
+			Summary:      titles[session.ProjectID],
+			Description:  sessionDescription(session, breaks, times.NetMinutes),
+			Categories:   []string{session.CompanyID},
+			Tentative:    session.IsActive,
+		})
+	}
+
+	// Best effort: a failed bookkeeping write shouldn't break the calendar
+	s.db.Model(&feed).Update("last_accessed_at", now)
+	return &feed, events, nil
+}
+
+// projectTitlesCtx maps the sessions' project IDs to their titles in
+// project-core, falling back to the ID when core can't tell
+func (s *TimeSessionService) projectTitlesCtx(ctx context.Context, sessions []db.TimeSession, userID string) map[uint]string {
+	titles := make(map[uint]string)
+	var ids []uint
+	for _, session := range sessions {
+		if _, seen := titles[session.ProjectID]; !seen {
+			titles[session.ProjectID] = fmt.Sprintf("Project %d", session.ProjectID)
+			ids = append(ids, session.ProjectID)
+		}
+	}
+	if len(ids) == 0 {
+		return titles
+	}
+
+	var projects []db.ProfessionalProject
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&projects).Error; err != nil {
+		return titles
+	}
+	for _, project := range projects {
+		base, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
+		if err == nil && base.Title != "" {
+			titles[project.ID] = base.Title
+		}
+	}
+	return titles
+}
+
+// sessionDescription is the event body: company, net time, breaks and notes
+func sessionDescription(session *db.TimeSession, breaks []db.SessionBreak, netMinutes int) string {
+	lines := []string{
+		"Company: " + session.CompanyID,
+		fmt.Sprintf("Net time: %dh%02dm", netMinutes/60, netMinutes%60),
+	}
+	for _, b := range breaks {
+		if b.EndTime == nil {
+			lines = append(lines, fmt.Sprintf("Break: %s since %s UTC", b.BreakType, b.StartTime.UTC().Format("15:04")))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("Break: %s %s-%s UTC (%d min)", b.BreakType,
+			b.StartTime.UTC().Format("15:04"), b.EndTime.UTC().Format("15:04"), b.DurationMinutes))
+	}
+	if session.Notes != nil && *session.Notes != "" {
+		lines = append(lines, "", *session.Notes)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
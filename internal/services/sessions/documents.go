@@ -0,0 +1,69 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/render"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/documents"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/fx"
+)
+
+// SetPersonalDocumentTemplate stores the template the user's own timesheets
+// are rendered with. It also styles reports and invoices of personal projects.
+func (s *TimeSessionService) SetPersonalDocumentTemplate(userID string, tpl *db.DocumentTemplate) (*db.DocumentTemplate, error) {
+	return documents.Save(s.db, documents.UserScope(userID), tpl, userID)
+}
+
+// GetPersonalDocumentTemplate returns the user's template, or nil when they set none
+func (s *TimeSessionService) GetPersonalDocumentTemplate(userID string) (*db.DocumentTemplate, error) {
+	return documents.Load(s.db, documents.UserScope(userID))
+}
+
+// GetTimesheetDocument renders the user's timesheet for a period as HTML or PDF
+func (s *TimeSessionService) GetTimesheetDocument(userID string, projectID uint, startDate, endDate time.Time, target fx.Target, format string) ([]byte, error) {
+	// Backwards-compat wrapper.
+	return s.GetTimesheetDocumentCtx(context.Background(), userID, projectID, startDate, endDate, target, format)
+}
+
+// GetTimesheetDocumentCtx renders the time report of the period with the
+// sessions it covers, styled with the user's personal template, else the
+// template of the company of the sessions' project.
+func (s *TimeSessionService) GetTimesheetDocumentCtx(ctx context.Context, userID string, projectID uint, startDate, endDate time.Time, target fx.Target, format string) ([]byte, error) {
+	if err := documents.CheckFormat(format); err != nil {
+		return nil, err
+	}
+
+	report, err := s.GenerateUserTimeReportCtx(ctx, userID, projectID, startDate, endDate, target)
+	if err != nil {
+		return nil, err
+	}
+	history, err := s.GetUserSessionHistory(userID, &startDate, &endDate)
+	if err != nil {
+		return nil, err
+	}
+	var sessions []db.TimeSession
+	for _, session := range history {
+		if session.SessionType == db.SessionTypeWork && (projectID == 0 || session.ProjectID == projectID) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	scopes := []string{documents.UserScope(userID)}
+	if projectID > 0 && len(sessions) > 0 && sessions[0].CompanyID != "" {
+		scopes = append(scopes, documents.CompanyScope(sessions[0].CompanyID))
+	}
+	tpl, err := documents.Load(s.db.WithContext(ctx), scopes...)
+	if err != nil {
+		return nil, err
+	}
+
+	style := render.StyleFrom(tpl)
+	out, err := documents.Render(style, style.Timesheet(report, sessions, startDate, endDate), format)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render timesheet: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,448 @@
+package sessions
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/ical"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DraftImportWindow is how far back an import reaches when no range is given
+const DraftImportWindow = 30 * 24 * time.Hour
+
+// MaxDraftsPerImport bounds the occurrences one upload may propose
+const MaxDraftsPerImport = 2000
+
+// MaxDraftsPerConfirm bounds one bulk confirmation
+const MaxDraftsPerConfirm = 500
+
+// ImportRuleInput describes a new rule; at least one of Keyword and Attendee is required
+type ImportRuleInput struct {
+	Keyword   *string
+	Attendee  *string
+	ProjectID uint
+	CompanyID string
+	Priority  int
+}
+
+// DraftImportResult summarizes one calendar upload
+type DraftImportResult struct {
+	Created    int
+	Duplicates int // Occurrences imported by an earlier upload
+	Unmapped   int // Created without a project; no rule matched
+	Skipped    int // Cancelled, all-day or not yet finished
+	Drafts     []db.DraftSession
+}
+
+// DraftUpdateInput holds the fields of a pending draft to change; nil fields stay as they are
+type DraftUpdateInput struct {
+	ProjectID *uint
+	CompanyID *string
+	StartTime *time.Time
+	EndTime   *time.Time
+	Notes     *string
+}
+
+// DraftConfirmation is the outcome of confirming one draft
+type DraftConfirmation struct {
+	DraftID uint
+	Session *db.TimeSession
+	Err     error
+}
+
+// CreateImportRuleCtx adds a rule mapping calendar events onto a project the
+// user is a member of
+func (s *TimeSessionService) CreateImportRuleCtx(ctx context.Context, userID string, in *ImportRuleInput) (*db.ImportRule, error) {
+	in.Keyword = trimmedOrNil(in.Keyword)
+	in.Attendee = trimmedOrNil(in.Attendee)
+	if in.Attendee != nil {
+		attendee := strings.ToLower(strings.TrimPrefix(*in.Attendee, "mailto:"))
+		in.Attendee = &attendee
+	}
+	if in.Keyword == nil && in.Attendee == nil {
+		return nil, fmt.Errorf("%w: keyword or attendee is required", ErrInvalidImportRule)
+	}
+	if in.ProjectID == 0 || in.CompanyID == "" {
+		return nil, fmt.Errorf("%w: project and company are required", ErrInvalidImportRule)
+	}
+	if err := s.verifyProjectAccessCtx(ctx, in.ProjectID, in.CompanyID, userID); err != nil {
+		return nil, err
+	}
+
+	rule := &db.ImportRule{
+		UserID:    userID,
+		Keyword:   in.Keyword,
+		Attendee:  in.Attendee,
+		ProjectID: in.ProjectID,
+		CompanyID: in.CompanyID,
+		Priority:  in.Priority,
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create import rule: %w", err)
+	}
+	return rule, nil
+}
+
+// GetImportRules lists the user's rules in the order they are tried
+func (s *TimeSessionService) GetImportRules(userID string) ([]db.ImportRule, error) {
+	var rules []db.ImportRule
+	if err := s.db.Where("user_id = ?", userID).Order("priority, id").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve import rules: %w", err)
+	}
+	return rules, nil
+}
+
+// DeleteImportRule removes one of the user's rules. Drafts it mapped keep their project.
+func (s *TimeSessionService) DeleteImportRule(userID string, ruleID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", ruleID, userID).Delete(&db.ImportRule{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete import rule: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrImportRuleNotFound
+	}
+	return nil
+}
+
+// ImportCalendarCtx reads an iCalendar file and proposes a pending draft for
+// every finished event occurrence starting in [from, to), mapped onto a
+// project by the user's import rules. Recurring events are expanded, and an
+// occurrence imported before (even if since confirmed or discarded) is not
+// proposed again. loc applies to floating times in the file.
+func (s *TimeSessionService) ImportCalendarCtx(ctx context.Context, userID string, r io.Reader, from, to time.Time, loc *time.Location) (*DraftImportResult, error) {
+	now := time.Now()
+	if to.IsZero() || to.After(now) {
+		to = now
+	}
+	if from.IsZero() {
+		from = to.Add(-DraftImportWindow)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: end of the range must be after its start", ErrInvalidCalendar)
+	}
+
+	events, err := ical.Parse(r, loc)
+	if err != nil {
+		return nil, ErrInvalidCalendar.Wrap(err)
+	}
+	rules, err := s.GetImportRules(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Changed instances of a recurring event replace the occurrence they moved
+	overridden := make(map[string]bool)
+	for _, event := range events {
+		if event.RecurrenceID != nil {
+			overridden[occurrenceKey(event.UID, *event.RecurrenceID)] = true
+		}
+	}
+
+	result := &DraftImportResult{Drafts: []db.DraftSession{}}
+	var drafts []db.DraftSession
+	for i := range events {
+		event := &events[i]
+		if event.Status == "CANCELLED" || event.AllDay || !event.End.After(event.Start) {
+			result.Skipped++
+			continue
+		}
+		uid := event.UID
+		if uid == "" {
+			uid = syntheticUID(event)
+		}
+		rule := matchImportRule(rules, event)
+
+		for _, occ := range event.Occurrences(from, to) {
+			if event.RecurrenceID == nil && overridden[occurrenceKey(event.UID, occ.Start)] {
+				continue
+			}
+			if occ.End.After(now) {
+				result.Skipped++
+				continue
+			}
+			if len(drafts) == MaxDraftsPerImport {
+				return nil, fmt.Errorf("%w: more than %d events in range - import a shorter range", ErrInvalidCalendar, MaxDraftsPerImport)
+			}
+			drafts = append(drafts, newDraft(userID, uid, event, occ, rule, now))
+		}
+	}
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range drafts {
+			created := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&drafts[i])
+			if created.Error != nil {
+				return fmt.Errorf("failed to create draft session: %w", created.Error)
+			}
+			if created.RowsAffected == 0 {
+				result.Duplicates++
+				continue
+			}
+			result.Created++
+			if drafts[i].ProjectID == nil {
+				result.Unmapped++
+			}
+			result.Drafts = append(result.Drafts, drafts[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetDrafts lists the user's drafts with the given status (pending by
+// default), oldest first
+func (s *TimeSessionService) GetDrafts(userID, status string) ([]db.DraftSession, error) {
+	if status == "" {
+		status = db.DraftStatusPending
+	}
+	switch status {
+	case db.DraftStatusPending, db.DraftStatusConfirmed, db.DraftStatusDiscarded:
+	default:
+		return nil, fmt.Errorf("%w: status must be pending, confirmed or discarded", ErrInvalidDraft)
+	}
+
+	var drafts []db.DraftSession
+	if err := s.db.Where("user_id = ? AND status = ?", userID, status).Order("start_time, id").Find(&drafts).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve draft sessions: %w", err)
+	}
+	return drafts, nil
+}
+
+// UpdateDraftCtx edits a pending draft before it is confirmed. Assigning a
+// project checks the user's membership like starting a session does.
+func (s *TimeSessionService) UpdateDraftCtx(ctx context.Context, userID string, draftID uint, in *DraftUpdateInput) (*db.DraftSession, error) {
+	draft, err := s.pendingDraft(userID, draftID)
+	if err != nil {
+		return nil, err
+	}
+
+	if in.StartTime != nil {
+		draft.StartTime = *in.StartTime
+	}
+	if in.EndTime != nil {
+		draft.EndTime = *in.EndTime
+	}
+	if !draft.EndTime.After(draft.StartTime) {
+		return nil, fmt.Errorf("%w: end time must be after start time", ErrInvalidDraft)
+	}
+	if in.Notes != nil {
+		draft.Notes = trimmedOrNil(in.Notes)
+	}
+
+	if in.ProjectID != nil || in.CompanyID != nil {
+		if in.ProjectID != nil {
+			draft.ProjectID = in.ProjectID
+		}
+		if in.CompanyID != nil {
+			draft.CompanyID = in.CompanyID
+		}
+		if draft.ProjectID == nil || draft.CompanyID == nil || *draft.CompanyID == "" {
+			return nil, fmt.Errorf("%w: project and company are required together", ErrInvalidDraft)
+		}
+		if err := s.verifyProjectAccessCtx(ctx, *draft.ProjectID, *draft.CompanyID, userID); err != nil {
+			return nil, err
+		}
+		draft.RuleID = nil // mapped by hand now
+	}
+
+	draft.UpdatedAt = time.Now()
+	result := s.db.WithContext(ctx).Model(draft).Where("status = ?", db.DraftStatusPending).
+		Select("project_id", "company_id", "start_time", "end_time", "notes", "rule_id", "updated_at").
+		Updates(draft)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to update draft session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrDraftNotPending
+	}
+	return draft, nil
+}
+
+// DiscardDraft rejects a pending draft. It is kept, so importing the same
+// calendar again doesn't propose it anew.
+func (s *TimeSessionService) DiscardDraft(userID string, draftID uint) error {
+	if _, err := s.pendingDraft(userID, draftID); err != nil {
+		return err
+	}
+	result := s.db.Model(&db.DraftSession{}).
+		Where("id = ? AND status = ?", draftID, db.DraftStatusPending).
+		Updates(map[string]interface{}{"status": db.DraftStatusDiscarded, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("failed to discard draft session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrDraftNotPending
+	}
+	return nil
+}
+
+// ConfirmDraftsCtx turns pending drafts into manual sessions. Each draft is
+// confirmed on its own, under the same overlap, lock and rate rules as a
+// manual entry, so one failing draft doesn't hold back the others; the
+// result reports the outcome per requested ID.
+func (s *TimeSessionService) ConfirmDraftsCtx(ctx context.Context, userID string, draftIDs []uint) ([]DraftConfirmation, error) {
+	if len(draftIDs) == 0 {
+		return nil, fmt.Errorf("%w: no drafts given", ErrInvalidDraft)
+	}
+	if len(draftIDs) > MaxDraftsPerConfirm {
+		return nil, fmt.Errorf("%w: at most %d drafts can be confirmed at once", ErrInvalidDraft, MaxDraftsPerConfirm)
+	}
+
+	var drafts []db.DraftSession
+	if err := s.db.WithContext(ctx).Where("id IN ? AND user_id = ?", draftIDs, userID).Find(&drafts).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve draft sessions: %w", err)
+	}
+	byID := make(map[uint]*db.DraftSession, len(drafts))
+	for i := range drafts {
+		byID[drafts[i].ID] = &drafts[i]
+	}
+
+	results := make([]DraftConfirmation, 0, len(draftIDs))
+	for _, id := range draftIDs {
+		confirmation := DraftConfirmation{DraftID: id}
+		if draft, ok := byID[id]; ok {
+			confirmation.Session, confirmation.Err = s.confirmDraftCtx(ctx, userID, draft)
+		} else {
+			confirmation.Err = ErrDraftNotFound
+		}
+		results = append(results, confirmation)
+	}
+	return results, nil
+}
+
+func (s *TimeSessionService) confirmDraftCtx(ctx context.Context, userID string, draft *db.DraftSession) (*db.TimeSession, error) {
+	if draft.Status != db.DraftStatusPending {
+		return nil, ErrDraftNotPending
+	}
+	if draft.ProjectID == nil || draft.CompanyID == nil {
+		return nil, ErrDraftUnmapped
+	}
+
+	in := &ManualSessionInput{
+		ProjectID: *draft.ProjectID,
+		CompanyID: *draft.CompanyID,
+		StartTime: draft.StartTime,
+		EndTime:   draft.EndTime,
+		Notes:     draft.Notes,
+	}
+	if err := s.validateManualInput(in); err != nil {
+		return nil, err
+	}
+	if err := s.verifyProjectAccessCtx(ctx, in.ProjectID, in.CompanyID, userID); err != nil {
+		return nil, err
+	}
+
+	var session *db.TimeSession
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if session, err = s.createManualSessionTx(tx, userID, in, false); err != nil {
+			return err
+		}
+		result := tx.Model(draft).Where("status = ?", db.DraftStatusPending).
+			Updates(map[string]interface{}{"status": db.DraftStatusConfirmed, "session_id": session.ID, "updated_at": time.Now()})
+		if result.Error != nil {
+			return fmt.Errorf("failed to confirm draft session: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return ErrDraftNotPending // confirmed concurrently
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *TimeSessionService) pendingDraft(userID string, draftID uint) (*db.DraftSession, error) {
+	var draft db.DraftSession
+	if err := s.db.Where("id = ? AND user_id = ?", draftID, userID).First(&draft).Error; err != nil {
+		return nil, lookupError(err, ErrDraftNotFound, "draft session")
+	}
+	if draft.Status != db.DraftStatusPending {
+		return nil, ErrDraftNotPending
+	}
+	return &draft, nil
+}
+
+// matchImportRule returns the first rule (rules are sorted) matching the event
+func matchImportRule(rules []db.ImportRule, event *ical.ParsedEvent) *db.ImportRule {
+	text := strings.ToLower(event.Summary + "\n" + event.Description + "\n" + event.Location)
+	for i := range rules {
+		rule := &rules[i]
+		if rule.Keyword != nil && !strings.Contains(text, strings.ToLower(*rule.Keyword)) {
+			continue
+		}
+		if rule.Attendee != nil && !hasAttendee(event, *rule.Attendee) {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
+
+func hasAttendee(event *ical.ParsedEvent, email string) bool {
+	if event.Organizer == email {
+		return true
+	}
+	for _, attendee := range event.Attendees {
+		if attendee == email {
+			return true
+		}
+	}
+	return false
+}
+
+func newDraft(userID, uid string, event *ical.ParsedEvent, occ ical.Occurrence, rule *db.ImportRule, now time.Time) db.DraftSession {
+	draft := db.DraftSession{
+		UserID:      userID,
+		StartTime:   occ.Start.UTC(),
+		EndTime:     occ.End.UTC(),
+		Title:       event.Summary,
+		Notes:       trimmedOrNil(&event.Summary),
+		SourceUID:   uid,
+		SourceStart: occ.Start.UTC(),
+		Status:      db.DraftStatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if rule != nil {
+		projectID, companyID, ruleID := rule.ProjectID, rule.CompanyID, rule.ID
+		draft.ProjectID = &projectID
+		draft.CompanyID = &companyID
+		draft.RuleID = &ruleID
+	}
+	return draft
+}
+
+func occurrenceKey(uid string, start time.Time) string {
+	return uid + "|" + start.UTC().Format(time.RFC3339)
+}
+
+// syntheticUID identifies events exported without a UID by their content
+func syntheticUID(event *ical.ParsedEvent) string {
+	sum := sha256.Sum256([]byte(event.Summary + "|" + event.Start.UTC().Format(time.RFC3339)))
+	return "nouid-" + hex.EncodeToString(sum[:8])
+}
+
+func trimmedOrNil(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*s)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,452 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/totals"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UpdateSessionInput holds the corrections to apply to a finished session.
+// Nil fields are left untouched.
+type UpdateSessionInput struct {
+	ProjectID   *uint
+	StartTime   *time.Time
+	EndTime     *time.Time
+	Notes       *string
+	HourlyRate  *money.Amount
+	NonBillable *bool
+}
+
+// UpdateSession corrects a finished session of the user and recomputes its
+// duration, cost and the totals of every project involved.
+func (s *TimeSessionService) UpdateSession(userID string, sessionID uint, in *UpdateSessionInput) (*db.TimeSession, error) {
+	// Backwards-compat wrapper.
+	return s.UpdateSessionCtx(context.Background(), userID, sessionID, in)
+}
+
+// UpdateSessionCtx is the request-scoped variant. Moving the session to
+// another project requires membership of it in the session's company.
+func (s *TimeSessionService) UpdateSessionCtx(ctx context.Context, userID string, sessionID uint, in *UpdateSessionInput) (*db.TimeSession, error) {
+	// Membership and the right to set a rate are checked against
+	// project-core outside the transaction, for the company the session
+	// belongs to; edits never change it. The project checked must still be
+	// the session's one below.
+	var rateManager bool
+	var checkedProjectID uint
+	if in.ProjectID != nil || in.HourlyRate != nil {
+		current, err := s.findEditableSessionTx(s.db.WithContext(ctx), userID, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		checkedProjectID = current.ProjectID
+		if in.ProjectID != nil && *in.ProjectID != current.ProjectID {
+			checkedProjectID = *in.ProjectID
+			if err := s.verifyProjectAccessCtx(ctx, checkedProjectID, current.CompanyID, userID); err != nil {
+				return nil, err
+			}
+		}
+		if rateManager, err = s.rateManagerCtx(ctx, checkedProjectID, userID, in.HourlyRate); err != nil {
+			return nil, err
+		}
+	}
+
+	var session *db.TimeSession
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTimeline(tx, userID); err != nil {
+			return err
+		}
+
+		var err error
+		session, err = s.findEditableSessionTx(tx, userID, sessionID)
+		if err != nil {
+			return err
+		}
+		before := *session
+
+		// The original window must not be locked, or corrections could move
+		// time out of a closed period.
+		if err := s.checkLockedTx(tx, userID, session.CompanyID, session.StartTime, *session.EndTime); err != nil {
+			return err
+		}
+
+		if in.ProjectID != nil && *in.ProjectID != session.ProjectID {
+			var project db.ProfessionalProject
+			if err := tx.First(&project, *in.ProjectID).Error; err != nil {
+				return lookupError(err, ErrProjectNotFound, "project")
+			}
+			session.ProjectID = *in.ProjectID
+		}
+		if session.ProjectID != checkedProjectID {
+			rateManager = false // Moved concurrently; checked for another project
+		}
+		if in.StartTime != nil {
+			session.StartTime = *in.StartTime
+		}
+		if in.EndTime != nil {
+			end := *in.EndTime
+			session.EndTime = &end
+		}
+		if in.Notes != nil {
+			session.Notes = in.Notes
+		}
+		if in.NonBillable != nil {
+			session.NonBillable = *in.NonBillable
+		}
+		// A new project, rate or start time is checked against the worker's
+		// assignment again. Without an assignment, a rate the caller supplied
+		// or imported on the same project is kept; a moved session takes the
+		// new project's rate.
+		if session.ProjectID != before.ProjectID || in.HourlyRate != nil || !session.StartTime.Equal(before.StartTime) {
+			rate, err := s.resolveRateTx(tx, session.ProjectID, session.CompanyID, userID, in.HourlyRate, rateManager, session.StartTime)
+			if err != nil {
+				return err
+			}
+			if rate.AssignmentID == nil && in.HourlyRate == nil &&
+				session.ProjectID == before.ProjectID && before.RateSource != db.RateSourceAssignment {
+				rate.HourlyRate, rate.Currency, rate.Source = before.HourlyRate, before.Currency, before.RateSource
+			}
+			rate.apply(session)
+		}
+
+		if !session.EndTime.After(session.StartTime) {
+			return fmt.Errorf("%w: end time must be after start time", ErrInvalidSession)
+		}
+		if session.EndTime.After(time.Now()) {
+			return fmt.Errorf("%w: end time cannot be in the future", ErrInvalidSession)
+		}
+		if err := s.checkSessionWindowTx(tx, userID, session.CompanyID, session.StartTime, *session.EndTime, session.ID); err != nil {
+			return err
+		}
+
+		breaks, err := s.sessionBreaksTx(tx, session.ID)
+		if err != nil {
+			return err
+		}
+		for _, b := range breaks {
+			if b.StartTime.Before(session.StartTime) || (b.EndTime != nil && b.EndTime.After(*session.EndTime)) {
+				return fmt.Errorf("%w: breaks must stay within the session - adjust or split instead", ErrInvalidSession)
+			}
+		}
+
+		return s.saveRecalculatedTx(tx, session, breaks, &before)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// DeleteSession removes a finished session of the user together with its breaks.
+func (s *TimeSessionService) DeleteSession(userID string, sessionID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTimeline(tx, userID); err != nil {
+			return err
+		}
+
+		session, err := s.findEditableSessionTx(tx, userID, sessionID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkLockedTx(tx, userID, session.CompanyID, session.StartTime, *session.EndTime); err != nil {
+			return err
+		}
+
+		if err := tx.Where("session_id = ?", session.ID).Delete(&db.SessionBreak{}).Error; err != nil {
+			return fmt.Errorf("failed to delete session breaks: %w", err)
+		}
+		if err := tx.Delete(session).Error; err != nil {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+
+		return totals.Remove(tx, session)
+	})
+}
+
+// SplitSession cuts a finished session in two at the given instant. Breaks
+// after the cut move to the second session; a break spanning the cut is split too.
+func (s *TimeSessionService) SplitSession(userID string, sessionID uint, at time.Time) (*db.TimeSession, *db.TimeSession, error) {
+	var first, second *db.TimeSession
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTimeline(tx, userID); err != nil {
+			return err
+		}
+
+		var err error
+		first, err = s.findEditableSessionTx(tx, userID, sessionID)
+		if err != nil {
+			return err
+		}
+		if !at.After(first.StartTime) || !at.Before(*first.EndTime) {
+			return fmt.Errorf("%w: split time must be inside the session", ErrInvalidSession)
+		}
+		original := *first
+		if err := s.checkLockedTx(tx, userID, first.CompanyID, first.StartTime, *first.EndTime); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		end := *first.EndTime
+		second = &db.TimeSession{
+			ProjectID:           first.ProjectID,
+			ProjectAssignmentID: first.ProjectAssignmentID,
+			UserID:              first.UserID,
+			CompanyID:           first.CompanyID,
+			StartTime:           at,
+			EndTime:             &end,
+			SessionType:         first.SessionType,
+			HourlyRate:          first.HourlyRate,
+			RateSource:          first.RateSource,
+			Currency:            first.Currency,
+			BillableRate:        first.BillableRate,
+			BillableCurrency:    first.BillableCurrency,
+			NonBillable:         first.NonBillable,
+			Notes:               first.Notes,
+			IsActive:            false,
+			IsManualEntry:       first.IsManualEntry,
+			CreatedAt:           now,
+			UpdatedAt:           now,
+		}
+		if err := tx.Omit(clause.Associations).Create(second).Error; err != nil {
+			return fmt.Errorf("failed to create split session: %w", err)
+		}
+		cut := at
+		first.EndTime = &cut
+
+		breaks, err := s.sessionBreaksTx(tx, first.ID)
+		if err != nil {
+			return err
+		}
+		var firstBreaks, secondBreaks []db.SessionBreak
+		for _, b := range breaks {
+			if b.EndTime == nil {
+				b.EndTime = &end
+			}
+			switch {
+			case !b.EndTime.After(at):
+				firstBreaks = append(firstBreaks, b)
+			case !b.StartTime.Before(at):
+				b.SessionID = second.ID
+				if err := tx.Omit(clause.Associations).Save(&b).Error; err != nil {
+					return fmt.Errorf("failed to move break: %w", err)
+				}
+				secondBreaks = append(secondBreaks, b)
+			default:
+				// The break spans the cut: keep the head, re-home the tail.
+				tailEnd := *b.EndTime
+				tail := db.SessionBreak{
+					SessionID: second.ID,
+					BreakType: b.BreakType,
+					StartTime: at,
+					EndTime:   &tailEnd,
+					IsActive:  false,
+					CreatedAt: now,
+				}
+				tail.DurationMinutes = s.calculateBreakDuration(&tail)
+				if err := tx.Omit(clause.Associations).Create(&tail).Error; err != nil {
+					return fmt.Errorf("failed to create split break: %w", err)
+				}
+				secondBreaks = append(secondBreaks, tail)
+
+				b.EndTime = &cut
+				b.DurationMinutes = s.calculateBreakDuration(&b)
+				if err := tx.Omit(clause.Associations).Save(&b).Error; err != nil {
+					return fmt.Errorf("failed to update split break: %w", err)
+				}
+				firstBreaks = append(firstBreaks, b)
+			}
+		}
+
+		applySessionTimes(second, secondBreaks, now)
+		second.UpdatedAt = now
+		if err := tx.Omit(clause.Associations).Save(second).Error; err != nil {
+			return fmt.Errorf("failed to update split session: %w", err)
+		}
+		if err := totals.Add(tx, second); err != nil {
+			return err
+		}
+		return s.saveRecalculatedTx(tx, first, firstBreaks, &original)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return first, second, nil
+}
+
+// MergeSessions joins two finished, adjacent sessions of the same project,
+// company and rate into the earlier one. A gap between them is recorded as a
+// break so the billable time stays the same.
+func (s *TimeSessionService) MergeSessions(userID string, firstID, secondID uint) (*db.TimeSession, error) {
+	if firstID == secondID {
+		return nil, fmt.Errorf("%w: cannot merge a session with itself", ErrInvalidSession)
+	}
+
+	var merged *db.TimeSession
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTimeline(tx, userID); err != nil {
+			return err
+		}
+
+		a, err := s.findEditableSessionTx(tx, userID, firstID)
+		if err != nil {
+			return err
+		}
+		b, err := s.findEditableSessionTx(tx, userID, secondID)
+		if err != nil {
+			return err
+		}
+		if b.StartTime.Before(a.StartTime) {
+			a, b = b, a
+		}
+
+		original := *a
+		if a.ProjectID != b.ProjectID || a.CompanyID != b.CompanyID {
+			return fmt.Errorf("%w: only sessions of the same project and company can be merged", ErrInvalidSession)
+		}
+		if !money.Equal(a.HourlyRate, b.HourlyRate) || !money.Equal(a.BillableRate, b.BillableRate) ||
+			a.Currency != b.Currency || a.BillableCurrency != b.BillableCurrency {
+			return fmt.Errorf("%w: sessions have different hourly rates", ErrInvalidSession)
+		}
+		if a.NonBillable != b.NonBillable {
+			return fmt.Errorf("%w: only sessions that are both billable or both non-billable can be merged", ErrInvalidSession)
+		}
+		if b.StartTime.Before(*a.EndTime) {
+			return fmt.Errorf("%w: sessions overlap", ErrInvalidSession)
+		}
+
+		// Adjacent means nothing else of the user sits in between.
+		var between int64
+		if err := tx.Model(&db.TimeSession{}).
+			Where("user_id = ? AND id NOT IN ? AND start_time < ? AND (end_time IS NULL OR end_time > ?)",
+				userID, []uint{a.ID, b.ID}, b.StartTime, *a.EndTime).
+			Count(&between).Error; err != nil {
+			return fmt.Errorf("failed to check sessions in between: %w", err)
+		}
+		if between > 0 {
+			return fmt.Errorf("%w: sessions are not adjacent", ErrInvalidSession)
+		}
+
+		for _, session := range []*db.TimeSession{a, b} {
+			if err := s.checkLockedTx(tx, userID, session.CompanyID, session.StartTime, *session.EndTime); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		if b.StartTime.After(*a.EndTime) {
+			gapStart, gapEnd := *a.EndTime, b.StartTime
+			gap := db.SessionBreak{
+				SessionID: a.ID,
+				BreakType: db.BreakTypeShort,
+				StartTime: gapStart,
+				EndTime:   &gapEnd,
+				IsActive:  false,
+				CreatedAt: now,
+			}
+			gap.DurationMinutes = s.calculateBreakDuration(&gap)
+			if err := tx.Omit(clause.Associations).Create(&gap).Error; err != nil {
+				return fmt.Errorf("failed to record gap as break: %w", err)
+			}
+		}
+
+		if err := tx.Model(&db.SessionBreak{}).Where("session_id = ?", b.ID).
+			Update("session_id", a.ID).Error; err != nil {
+			return fmt.Errorf("failed to move breaks: %w", err)
+		}
+		if err := tx.Delete(b).Error; err != nil {
+			return fmt.Errorf("failed to delete merged session: %w", err)
+		}
+
+		a.EndTime = b.EndTime
+		a.Notes = joinNotes(a.Notes, b.Notes)
+		a.IsManualEntry = a.IsManualEntry || b.IsManualEntry
+
+		breaks, err := s.sessionBreaksTx(tx, a.ID)
+		if err != nil {
+			return err
+		}
+		merged = a
+		return s.saveRecalculatedTx(tx, a, breaks, &original, b)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+// findEditableSessionTx loads a finished, uninvoiced session owned by userID inside tx
+func (s *TimeSessionService) findEditableSessionTx(tx *gorm.DB, userID string, sessionID uint) (*db.TimeSession, error) {
+	var session db.TimeSession
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&session, sessionID).Error; err != nil {
+		return nil, lookupError(err, ErrSessionNotFound, "session")
+	}
+	if session.UserID != userID {
+		return nil, ErrSessionAccessDenied
+	}
+	if session.IsActive || session.EndTime == nil {
+		return nil, ErrSessionStillActive
+	}
+	if session.InvoiceID != nil {
+		return nil, ErrSessionInvoiced
+	}
+	return &session, nil
+}
+
+// checkLockedTx fails with ErrPeriodLocked when [start, end) touches a locked period
+func (s *TimeSessionService) checkLockedTx(tx *gorm.DB, userID, companyID string, start, end time.Time) error {
+	var locked int64
+	if err := tx.Model(&db.PeriodLock{}).
+		Where("company_id = ? AND (user_id IS NULL OR user_id = ?) AND start_time < ? AND end_time > ?",
+			companyID, userID, end, start).
+		Count(&locked).Error; err != nil {
+		return fmt.Errorf("failed to check locked periods: %w", err)
+	}
+	if locked > 0 {
+		return ErrPeriodLocked
+	}
+	return nil
+}
+
+// sessionBreaksTx loads the breaks of a session ordered by start time
+func (s *TimeSessionService) sessionBreaksTx(tx *gorm.DB, sessionID uint) ([]db.SessionBreak, error) {
+	var breaks []db.SessionBreak
+	if err := tx.Where("session_id = ?", sessionID).Order("start_time").Find(&breaks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load session breaks: %w", err)
+	}
+	return breaks, nil
+}
+
+// saveRecalculatedTx recomputes and stores the session, then moves the totals
+// of the projects and assignments involved from the previous sessions, as
+// stored before the change, to the session.
+func (s *TimeSessionService) saveRecalculatedTx(tx *gorm.DB, session *db.TimeSession, breaks []db.SessionBreak, previous ...*db.TimeSession) error {
+	now := time.Now()
+	applySessionTimes(session, breaks, now)
+	session.UpdatedAt = now
+	if err := tx.Omit(clause.Associations).Save(session).Error; err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return totals.Change(tx, previous, []*db.TimeSession{session})
+}
+
+// joinNotes concatenates the notes of merged sessions
+func joinNotes(a, b *string) *string {
+	var parts []string
+	for _, n := range []*string{a, b} {
+		if n != nil && strings.TrimSpace(*n) != "" {
+			parts = append(parts, *n)
+		}
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	joined := strings.Join(parts, "\n")
+	return &joined
+}
@@ -0,0 +1,96 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/apperr"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrActiveSessionExists is returned when a session is started while the user
+	// already has one running. The UserActiveSession primary key (user_id) is the
+	// source of truth, so concurrent starts from several devices also end here.
+	ErrActiveSessionExists = apperr.Conflict("active_session_exists", "user already has an active session - finish current session first")
+	// ErrNoActiveSession is returned by operations that need a running session.
+	ErrNoActiveSession = apperr.NotFound("no_active_session", "no active session found")
+	// ErrAlreadyOnBreak is returned when a break is started during another one.
+	ErrAlreadyOnBreak = apperr.Conflict("already_on_break", "already on break - end current break first")
+	// ErrNotOnBreak is returned when resuming a session that is not on break.
+	ErrNotOnBreak = apperr.Conflict("not_on_break", "not currently on break")
+	// ErrInvalidBreakType is returned for break types other than break, lunch and brb.
+	ErrInvalidBreakType = apperr.Validation("invalid_break_type", "invalid break type - use: break, lunch, or brb")
+	// ErrProjectNotFound is returned when the professional project does not exist.
+	ErrProjectNotFound = apperr.NotFound("project_not_found", "project not found")
+	// ErrProjectAccessDenied is returned when the user is not a member of the project in project-core.
+	ErrProjectAccessDenied = apperr.Forbidden("project_access_denied", "access denied: not a member of this project")
+	// ErrCompanyMismatch is returned when the company context differs from the project's company in project-core.
+	ErrCompanyMismatch = apperr.Validation("company_mismatch", "company does not match the project's company")
+	// ErrRateMismatch is returned when a requested hourly rate differs from the
+	// worker's ProjectAssignment rate for the project.
+	ErrRateMismatch = apperr.Validation("rate_mismatch", "hourly rate does not match the project assignment")
+	// ErrRateNotAllowed is returned when someone who doesn't manage the project
+	// supplies an hourly rate for work without a ProjectAssignment.
+	ErrRateNotAllowed = apperr.Forbidden("rate_not_allowed", "access denied: only project managers can set a rate without an assignment")
+	// ErrSessionNotFound is returned when the time session does not exist.
+	ErrSessionNotFound = apperr.NotFound("session_not_found", "session not found")
+
+	// ErrInvalidSession wraps validation failures of session input.
+	ErrInvalidSession = apperr.Validation("invalid_session", "invalid session")
+	// ErrSessionOverlap is returned when a session would overlap another one of the same user.
+	ErrSessionOverlap = apperr.Conflict("session_overlap", "session overlaps an existing session")
+	// ErrPeriodLocked is returned when a session falls into a locked period.
+	ErrPeriodLocked = apperr.Conflict("period_locked", "time period is locked")
+	// ErrSessionAccessDenied is returned when a user touches another user's session.
+	ErrSessionAccessDenied = apperr.Forbidden("session_access_denied", "access denied: session belongs to another user")
+	// ErrSessionStillActive is returned when a running session is edited; finish it first.
+	ErrSessionStillActive = apperr.Conflict("session_still_active", "cannot modify an active session - finish it first")
+	// ErrSessionInvoiced is returned when an invoiced session is edited; void the invoice first.
+	ErrSessionInvoiced = apperr.Conflict("session_invoiced", "cannot modify an invoiced session - void the invoice first")
+
+	// ErrPeriodLockNotFound is returned when the period lock does not exist.
+	ErrPeriodLockNotFound = apperr.NotFound("period_lock_not_found", "period lock not found")
+	// ErrPeriodLockAccessDenied is returned when a user removes a lock they don't own.
+	ErrPeriodLockAccessDenied = apperr.Forbidden("period_lock_access_denied", "access denied: period lock was set by someone else")
+	// ErrCompanyAdminRequired is returned when a non-administrator manages company locks.
+	ErrCompanyAdminRequired = apperr.Forbidden("company_admin_required", "access denied: requires update permission on a project of the company")
+	// ErrProjectHasNoCompany is returned when company locks are managed through a personal project.
+	ErrProjectHasNoCompany = apperr.Validation("project_has_no_company", "project does not belong to a company")
+
+	// ErrCalendarFeedNotFound is returned for unknown or revoked calendar feeds.
+	ErrCalendarFeedNotFound = apperr.NotFound("calendar_feed_not_found", "calendar feed not found")
+
+	// ErrInvalidCalendar is returned for uploads that can't be read as iCalendar.
+	ErrInvalidCalendar = apperr.Validation("invalid_calendar", "invalid calendar file")
+	// ErrImportRuleNotFound is returned when the import rule does not exist.
+	ErrImportRuleNotFound = apperr.NotFound("import_rule_not_found", "import rule not found")
+	// ErrInvalidImportRule wraps validation failures of import rule input.
+	ErrInvalidImportRule = apperr.Validation("invalid_import_rule", "invalid import rule")
+	// ErrDraftNotFound is returned when the draft session does not exist.
+	ErrDraftNotFound = apperr.NotFound("draft_not_found", "draft session not found")
+	// ErrDraftNotPending is returned when a confirmed or discarded draft is changed.
+	ErrDraftNotPending = apperr.Conflict("draft_not_pending", "draft session was already confirmed or discarded")
+	// ErrDraftUnmapped is returned when a draft without a project is confirmed.
+	ErrDraftUnmapped = apperr.Validation("draft_unmapped", "draft session has no project - assign one before confirming")
+	// ErrInvalidDraft wraps validation failures of draft input.
+	ErrInvalidDraft = apperr.Validation("invalid_draft", "invalid draft session")
+
+	// ErrImportNotFound is returned when the import batch does not exist.
+	ErrImportNotFound = apperr.NotFound("import_not_found", "import not found")
+	// ErrImportApplied is returned when an import is applied a second time.
+	ErrImportApplied = apperr.Conflict("import_applied", "import was already applied")
+	// ErrImportMappingNotFound is returned when the import mapping does not exist.
+	ErrImportMappingNotFound = apperr.NotFound("import_mapping_not_found", "import mapping not found")
+	// ErrInvalidImportMapping wraps validation failures of import mapping input.
+	ErrInvalidImportMapping = apperr.Validation("invalid_import_mapping", "invalid import mapping")
+)
+
+// lookupError turns a missing row into notFound and keeps any other database
+// error as an internal failure.
+func lookupError(err error, notFound *apperr.Error, what string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return notFound
+	}
+	return fmt.Errorf("failed to load %s: %w", what, err)
+}
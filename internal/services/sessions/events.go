@@ -0,0 +1,42 @@
+package sessions
+
+import (
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/events"
+)
+
+// Subscribe streams the session events of userID until cancel is called
+func (s *TimeSessionService) Subscribe(userID string) (<-chan events.SessionEvent, func()) {
+	return s.broker.Subscribe(userID)
+}
+
+// publishSession announces a change of session to the user's devices. Call it
+// only after the transaction committed, so subscribers never see rolled-back state.
+func (s *TimeSessionService) publishSession(eventType string, session *db.TimeSession) {
+	s.broker.Publish(events.SessionEvent{
+		Type:      eventType,
+		UserID:    session.UserID,
+		SessionID: session.ID,
+		ProjectID: session.ProjectID,
+		CompanyID: session.CompanyID,
+		EndReason: session.EndReason,
+		At:        time.Now(),
+	})
+}
+
+// publishBreak announces a break change of the user's active session
+func (s *TimeSessionService) publishBreak(eventType string, activeSession *db.UserActiveSession, breakRecord *db.SessionBreak) {
+	breakType := breakRecord.BreakType
+	s.broker.Publish(events.SessionEvent{
+		Type:      eventType,
+		UserID:    activeSession.UserID,
+		SessionID: activeSession.SessionID,
+		ProjectID: activeSession.ProjectID,
+		CompanyID: activeSession.CompanyID,
+		BreakID:   &breakRecord.ID,
+		BreakType: &breakType,
+		At:        time.Now(),
+	})
+}
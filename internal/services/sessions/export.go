@@ -0,0 +1,136 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"gorm.io/gorm"
+)
+
+// Exports read sessions through a database cursor and hand them over one at
+// a time, so a year of history never sits in memory at once. Periods are
+// [startDate, endDate); a nil bound leaves that side open.
+
+// StreamUserSessionsCtx calls fn with each of the user's sessions that
+// started in the period, oldest first.
+func (s *TimeSessionService) StreamUserSessionsCtx(ctx context.Context, userID string, startDate, endDate *time.Time, fn func(*db.TimeSession) error) error {
+	q := s.db.WithContext(ctx).Model(&db.TimeSession{}).Where("user_id = ?", userID)
+	return s.streamSessions(periodScope(q, startDate, endDate), fn)
+}
+
+// StreamProjectSessionsCtx calls fn with the project's sessions the user may
+// see in detail, under the same rules as GetProjectSessionsCtx.
+func (s *TimeSessionService) StreamProjectSessionsCtx(ctx context.Context, projectID uint, userID string, startDate, endDate *time.Time, fn func(*db.TimeSession) error) error {
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		return lookupError(err, ErrProjectNotFound, "project")
+	}
+	member, err := s.projectMemberCtx(ctx, &project, userID)
+	if err != nil {
+		return err
+	}
+
+	q := s.db.WithContext(ctx).Model(&db.TimeSession{}).Where("project_id = ?", projectID)
+	if !hasPermission(member, PermissionViewSessionDetails) {
+		q = q.Where("user_id = ?", userID)
+	}
+	return s.streamSessions(periodScope(q, startDate, endDate), fn)
+}
+
+// StreamDailyTotalsCtx calls fn with the user's work totals per day of loc
+// and currency, oldest day first, optionally for one project.
+func (s *TimeSessionService) StreamDailyTotalsCtx(ctx context.Context, userID string, projectID uint, startDate, endDate *time.Time, loc *time.Location, fn func(*db.DailyTimeTotals) error) error {
+	q := s.db.WithContext(ctx).Model(&db.TimeSession{}).
+		Where("user_id = ? AND session_type = ?", userID, db.SessionTypeWork)
+	if projectID > 0 {
+		q = q.Where("project_id = ?", projectID)
+	}
+
+	// Sessions come ordered by start, so a day is complete once the next begins
+	var day time.Time
+	byCurrency := make(map[string]*db.DailyTimeTotals)
+	flush := func() error {
+		currencies := make([]string, 0, len(byCurrency))
+		for currency := range byCurrency {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+		for _, currency := range currencies {
+			if err := fn(byCurrency[currency]); err != nil {
+				return err
+			}
+		}
+		byCurrency = make(map[string]*db.DailyTimeTotals)
+		return nil
+	}
+
+	err := s.streamSessions(periodScope(q, startDate, endDate), func(session *db.TimeSession) error {
+		start := session.StartTime.In(loc)
+		sessionDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+		if !sessionDay.Equal(day) {
+			if err := flush(); err != nil {
+				return err
+			}
+			day = sessionDay
+		}
+		totals, ok := byCurrency[session.Currency]
+		if !ok {
+			totals = &db.DailyTimeTotals{Day: day, Currency: session.Currency}
+			byCurrency[session.Currency] = totals
+		}
+		totals.WorkSessions++
+		totals.GrossMinutes += session.DurationMinutes
+		totals.BreakMinutes += session.BreakMinutes
+		totals.NetMinutes += session.NetMinutes
+		totals.TotalCost += session.SessionCost
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return flush()
+}
+
+// streamSessions runs q ordered by start time and calls fn per row. Running
+// sessions get their live durations first, as in the JSON endpoints.
+func (s *TimeSessionService) streamSessions(q *gorm.DB, fn func(*db.TimeSession) error) error {
+	rows, err := q.Order("start_time, id").Rows()
+	if err != nil {
+		return fmt.Errorf("failed to retrieve sessions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session db.TimeSession
+		if err := s.db.ScanRows(rows, &session); err != nil {
+			return fmt.Errorf("failed to read session: %w", err)
+		}
+		if session.IsActive {
+			live := []db.TimeSession{session}
+			if err := s.fillActiveSessionTimes(live); err != nil {
+				return err
+			}
+			session = live[0]
+		}
+		if err := fn(&session); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to retrieve sessions: %w", err)
+	}
+	return nil
+}
+
+func periodScope(q *gorm.DB, startDate, endDate *time.Time) *gorm.DB {
+	if startDate != nil {
+		q = q.Where("start_time >= ?", *startDate)
+	}
+	if endDate != nil {
+		q = q.Where("start_time < ?", *endDate)
+	}
+	return q
+}
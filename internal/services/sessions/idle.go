@@ -0,0 +1,203 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var log = slog.Default().With(
+	slog.String("layer", "service"),
+	slog.String("service", "TimeSessionService"),
+)
+
+// Idle modes select what the sweeper does with a session that stopped
+// reporting activity.
+const (
+	IdleModeClose = "close" // finish the session at its last activity time
+	IdleModeBreak = "break" // put the session on an idle break starting at its last activity time
+)
+
+// IdleConfig configures idle detection. A zero Threshold disables the sweeper.
+type IdleConfig struct {
+	Threshold  time.Duration // time without activity after which a session counts as idle
+	Mode       string        // IdleModeClose or IdleModeBreak
+	CloseAfter time.Duration // break mode only: finish sessions idle this long; zero never finishes them
+	Interval   time.Duration // how often the sweeper runs
+}
+
+// Heartbeat records activity on the user's running session. Clients should send
+// it periodically while the app is open, breaks included. If the sweeper had put
+// the session on an idle break, that break is ended and returned.
+func (s *TimeSessionService) Heartbeat(userID string) (*db.UserActiveSession, *db.SessionBreak, error) {
+	var activeSession *db.UserActiveSession
+	var resumed *db.SessionBreak
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		activeSession, err = s.findActiveSessionTx(tx, userID)
+		if err != nil {
+			return lookupError(err, ErrNoActiveSession, "active session")
+		}
+
+		resumed, err = s.heartbeatTx(tx, activeSession, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if resumed != nil {
+		s.publishBreak(events.SessionBreakEnded, activeSession, resumed)
+	}
+	return activeSession, resumed, nil
+}
+
+// heartbeatTx marks activeSession as active at now inside tx, ending the
+// current break when it is an idle break opened by the sweeper.
+func (s *TimeSessionService) heartbeatTx(tx *gorm.DB, activeSession *db.UserActiveSession, now time.Time) (*db.SessionBreak, error) {
+	if activeSession.IsOnBreak && activeSession.CurrentBreakID != nil {
+		var current db.SessionBreak
+		if err := tx.First(&current, *activeSession.CurrentBreakID).Error; err != nil {
+			return nil, fmt.Errorf("break record not found: %w", err)
+		}
+		if current.BreakType == db.BreakTypeIdle {
+			return s.endBreakTx(tx, activeSession, now)
+		}
+	}
+
+	activeSession.LastActivityAt = now
+	activeSession.UpdatedAt = now
+	if err := tx.Omit(clause.Associations).Save(activeSession).Error; err != nil {
+		return nil, fmt.Errorf("failed to update active session: %w", err)
+	}
+	return nil, nil
+}
+
+// RunIdleSweeper sweeps idle sessions every cfg.Interval until ctx is cancelled.
+// It returns immediately when idle detection is disabled.
+func (s *TimeSessionService) RunIdleSweeper(ctx context.Context, cfg IdleConfig) {
+	if cfg.Threshold <= 0 || cfg.Interval <= 0 {
+		log.Info("idle-sweeper:disabled")
+		return
+	}
+	log.Info("idle-sweeper:start", "threshold", cfg.Threshold, "mode", cfg.Mode, "closeAfter", cfg.CloseAfter, "interval", cfg.Interval)
+
+	ticker := time.NewTicker(cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			swept, err := s.SweepIdleSessions(cfg, time.Now())
+			if err != nil {
+				log.Error("idle-sweeper:failed", "err", err)
+				continue
+			}
+			if swept > 0 {
+				log.Info("idle-sweeper:swept", "sessions", swept)
+			}
+		}
+	}
+}
+
+// SweepIdleSessions applies cfg to every session without activity since
+// now - cfg.Threshold and returns how many sessions it changed. Each user is
+// handled in its own transaction, so a heartbeat that arrives in the meantime
+// wins and one failing user does not block the others.
+func (s *TimeSessionService) SweepIdleSessions(cfg IdleConfig, now time.Time) (int, error) {
+	var candidates []db.UserActiveSession
+	if err := s.db.Where("last_activity_at < ?", now.Add(-cfg.Threshold)).Find(&candidates).Error; err != nil {
+		return 0, fmt.Errorf("failed to query idle sessions: %w", err)
+	}
+
+	swept := 0
+	for _, candidate := range candidates {
+		changed, err := s.sweepIdleUser(cfg, candidate.UserID, now)
+		if err != nil {
+			log.Error("idle-sweeper:user-failed", "userID", candidate.UserID, "err", err)
+			continue
+		}
+		if changed {
+			swept++
+		}
+	}
+	return swept, nil
+}
+
+// sweepIdleUser re-checks the user's session under lock and either opens an
+// idle break or finishes it at the last activity time.
+func (s *TimeSessionService) sweepIdleUser(cfg IdleConfig, userID string, now time.Time) (bool, error) {
+	var activeSession *db.UserActiveSession
+	var idleBreak *db.SessionBreak
+	var finished *db.TimeSession
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		activeSession, err = s.findActiveSessionTx(tx, userID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil // finished in the meantime
+		}
+		if err != nil {
+			return err
+		}
+
+		idle := now.Sub(activeSession.LastActivityAt)
+		if idle < cfg.Threshold {
+			return nil // activity arrived in the meantime
+		}
+
+		if cfg.Mode == IdleModeBreak && (cfg.CloseAfter <= 0 || idle < cfg.CloseAfter) {
+			if activeSession.IsOnBreak {
+				return nil
+			}
+			idleBreak, err = s.startIdleBreakTx(tx, activeSession, now)
+			return err
+		}
+
+		reason := db.EndReasonIdleTimeout
+		finished, err = s.finishSessionTx(tx, userID, activeSession.LastActivityAt, &reason)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case idleBreak != nil:
+		s.publishBreak(events.SessionBreakStarted, activeSession, idleBreak)
+		return true, nil
+	case finished != nil:
+		s.publishSession(events.SessionFinished, finished)
+		return true, nil
+	}
+	return false, nil
+}
+
+// startIdleBreakTx opens an idle break covering the gap since the last
+// activity. LastActivityAt is left untouched so CloseAfter keeps counting.
+func (s *TimeSessionService) startIdleBreakTx(tx *gorm.DB, activeSession *db.UserActiveSession, now time.Time) (*db.SessionBreak, error) {
+	breakRecord := &db.SessionBreak{
+		SessionID: activeSession.SessionID,
+		BreakType: db.BreakTypeIdle,
+		StartTime: activeSession.LastActivityAt,
+		IsActive:  true,
+		CreatedAt: now,
+	}
+	if err := tx.Omit(clause.Associations).Create(breakRecord).Error; err != nil {
+		return nil, fmt.Errorf("failed to create idle break: %w", err)
+	}
+
+	activeSession.IsOnBreak = true
+	activeSession.CurrentBreakID = &breakRecord.ID
+	activeSession.UpdatedAt = now
+	if err := tx.Omit(clause.Associations).Save(activeSession).Error; err != nil {
+		return nil, fmt.Errorf("failed to update active session: %w", err)
+	}
+	return breakRecord, nil
+}
@@ -0,0 +1,713 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/importer"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/totals"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MaxImportReportItems bounds the entries listed one by one in a report
+const MaxImportReportItems = 1000
+
+// What happens to an entry of an import, as listed in its report
+const (
+	ImportActionCreate    = "create"     // Becomes a session
+	ImportActionUnchanged = "unchanged"  // Imported before
+	ImportActionChanged   = "changed"    // Imported before and edited in the source since; not overwritten
+	ImportActionDuplicate = "duplicate"  // Appears twice in the file
+	ImportActionOverlap   = "overlap"    // Overlaps a session of the user
+	ImportActionLocked    = "locked"     // Falls into a locked period
+	ImportActionInvalid   = "invalid"    // Unreadable, still running or in the future
+	ImportActionOtherUser = "other_user" // Belongs to another user of the export
+	ImportActionDenied    = "denied"     // Its project is one the user can't work on
+)
+
+// Where the entries of one source project go
+const (
+	ImportProjectMapped  = "mapped"  // By an ImportMapping
+	ImportProjectMatched = "matched" // To the user's project with the same title and client
+	ImportProjectCreate  = "create"  // To a new project
+	ImportProjectDenied  = "denied"  // Mapped or matched to a project the user can't access
+)
+
+// noProjectName names the project of entries without project or tags
+const noProjectName = "No project"
+
+// errDryRun rolls back the transaction of a planned import
+var errDryRun = errors.New("dry run")
+
+// ImportInput describes an upload. User selects whose entries to import
+// when the export holds several users' (name or email, as exported).
+type ImportInput struct {
+	Source    string
+	CompanyID string
+	User      string
+	FileName  string
+	Options   importer.Options
+}
+
+// ImportMappingInput ties a source client and project to a project
+type ImportMappingInput struct {
+	Source      string
+	ClientName  string
+	ProjectName string
+	ProjectID   uint
+	CompanyID   string
+}
+
+// ImportReport is the diff report of an import: what would happen to each
+// entry (dry run) or what did. It is stored with the batch as JSON.
+type ImportReport struct {
+	Source    string               `json:"source"`
+	DryRun    bool                 `json:"dryRun"`
+	Entries   int                  `json:"entries"`
+	Actions   map[string]int       `json:"actions"` // Entries per action
+	Projects  []*ImportProjectPlan `json:"projects"`
+	Items     []ImportItem         `json:"items"` // Entries not created, up to MaxImportReportItems
+	Truncated bool                 `json:"truncated"`
+}
+
+// ImportProjectPlan tells where the entries of one source project go
+type ImportProjectPlan struct {
+	Client    string `json:"client"`
+	Project   string `json:"project"`
+	Action    string `json:"action"`
+	ProjectID *uint  `json:"projectId"` // Nil while the project is still to be created
+	Detail    string `json:"detail,omitempty"`
+	Entries   int    `json:"entries"` // Entries becoming sessions in it
+	Minutes   int    `json:"minutes"`
+}
+
+// ImportItem is one entry of the report
+type ImportItem struct {
+	Line       int       `json:"line"`
+	ExternalID string    `json:"externalId"`
+	Client     string    `json:"client"`
+	Project    string    `json:"project"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Action     string    `json:"action"`
+	Detail     string    `json:"detail,omitempty"`
+	SessionID  *uint     `json:"sessionId,omitempty"` // Of an entry imported before
+}
+
+// PlanImportCtx reads an export of another time tracker and stores it as a
+// planned batch with its diff report. Nothing else changes until the batch
+// is applied with ApplyImportCtx.
+func (s *TimeSessionService) PlanImportCtx(ctx context.Context, userID string, r io.Reader, in *ImportInput) (*db.ImportBatch, *ImportReport, error) {
+	if strings.TrimSpace(in.CompanyID) == "" {
+		return nil, nil, fmt.Errorf("%w: company is required", importer.ErrInvalidImport)
+	}
+	entries, err := importer.Parse(in.Source, r, in.Options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil, fmt.Errorf("%w: the file holds no time entries", importer.ErrInvalidImport)
+	}
+	if users := importer.Users(entries); in.User == "" && len(users) > 1 {
+		if len(users) > 10 {
+			users = append(users[:10], "...")
+		}
+		return nil, nil, fmt.Errorf("%w: the export holds entries of %d users (%s) - choose whose to import",
+			importer.ErrInvalidImport, len(importer.Users(entries)), strings.Join(users, ", "))
+	}
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode import entries: %w", err)
+	}
+	batch := &db.ImportBatch{
+		UserID:     userID,
+		Source:     in.Source,
+		CompanyID:  in.CompanyID,
+		SourceUser: in.User,
+		FileName:   in.FileName,
+		Status:     db.ImportStatusPlanned,
+		Entries:    data,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(batch).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to create import: %w", err)
+	}
+
+	report, err := s.runImportCtx(ctx, userID, batch, entries, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.saveImportReport(ctx, batch, report); err != nil {
+		return nil, nil, err
+	}
+	return batch, report, nil
+}
+
+// ApplyImportCtx imports a planned batch. The plan is worked out again, since
+// sessions, locks and mappings may have changed since the dry run; projects
+// it creates are mapped so later imports reuse them.
+func (s *TimeSessionService) ApplyImportCtx(ctx context.Context, userID string, batchID uint) (*db.ImportBatch, *ImportReport, error) {
+	var batch db.ImportBatch
+	if err := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", batchID, userID).First(&batch).Error; err != nil {
+		return nil, nil, lookupError(err, ErrImportNotFound, "import")
+	}
+	// Claim the batch, so a second apply can't create its projects twice
+	claimed := s.db.WithContext(ctx).Model(&batch).Where("status = ?", db.ImportStatusPlanned).Update("status", db.ImportStatusApplying)
+	if claimed.Error != nil {
+		return nil, nil, fmt.Errorf("failed to update import: %w", claimed.Error)
+	}
+	if claimed.RowsAffected == 0 {
+		return nil, nil, ErrImportApplied
+	}
+
+	var entries []importer.Entry
+	err := json.Unmarshal(batch.Entries, &entries)
+	var report *ImportReport
+	if err == nil {
+		report, err = s.runImportCtx(ctx, userID, &batch, entries, false)
+	}
+	if err != nil {
+		s.db.Model(&batch).Update("status", db.ImportStatusPlanned)
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	batch.Status, batch.AppliedAt, batch.Entries = db.ImportStatusApplied, &now, nil
+	if err := s.saveImportReport(ctx, &batch, report); err != nil {
+		return nil, nil, err
+	}
+	return &batch, report, nil
+}
+
+// GetImports lists the user's import batches, newest first
+func (s *TimeSessionService) GetImports(userID string) ([]db.ImportBatch, error) {
+	var batches []db.ImportBatch
+	if err := s.db.Omit("entries", "report").Where("user_id = ?", userID).Order("created_at DESC").Find(&batches).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve imports: %w", err)
+	}
+	return batches, nil
+}
+
+// GetImport returns one of the user's batches with its latest report
+func (s *TimeSessionService) GetImport(userID string, batchID uint) (*db.ImportBatch, *ImportReport, error) {
+	var batch db.ImportBatch
+	if err := s.db.Omit("entries").Where("id = ? AND user_id = ?", batchID, userID).First(&batch).Error; err != nil {
+		return nil, nil, lookupError(err, ErrImportNotFound, "import")
+	}
+	var report ImportReport
+	if err := json.Unmarshal(batch.Report, &report); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode import report: %w", err)
+	}
+	return &batch, &report, nil
+}
+
+// GetImportMappings lists the user's mappings, optionally of one source
+func (s *TimeSessionService) GetImportMappings(userID, source string) ([]db.ImportMapping, error) {
+	q := s.db.Where("user_id = ?", userID)
+	if source != "" {
+		q = q.Where("source = ?", source)
+	}
+	var mappings []db.ImportMapping
+	if err := q.Order("source, client_name, project_name").Find(&mappings).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve import mappings: %w", err)
+	}
+	return mappings, nil
+}
+
+// SetImportMappingCtx maps a source client and project onto a project the
+// user may work on in the company, replacing an earlier mapping
+func (s *TimeSessionService) SetImportMappingCtx(ctx context.Context, userID string, in *ImportMappingInput) (*db.ImportMapping, error) {
+	switch in.Source {
+	case importer.SourceToggl, importer.SourceClockify, importer.SourceHarvest:
+	default:
+		return nil, fmt.Errorf("%w: unknown source %q (use toggl, clockify or harvest)", ErrInvalidImportMapping, in.Source)
+	}
+	if strings.TrimSpace(in.ProjectName) == "" || in.ProjectID == 0 || in.CompanyID == "" {
+		return nil, fmt.Errorf("%w: project name, project and company are required", ErrInvalidImportMapping)
+	}
+	if err := s.verifyProjectAccessCtx(ctx, in.ProjectID, in.CompanyID, userID); err != nil {
+		return nil, err
+	}
+
+	mapping := &db.ImportMapping{
+		UserID:      userID,
+		Source:      in.Source,
+		ClientName:  strings.TrimSpace(in.ClientName),
+		ProjectName: strings.TrimSpace(in.ProjectName),
+		ProjectID:   in.ProjectID,
+		CreatedAt:   time.Now(),
+	}
+	if err := upsertImportMapping(s.db.WithContext(ctx), mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// DeleteImportMapping removes one of the user's mappings
+func (s *TimeSessionService) DeleteImportMapping(userID string, mappingID uint) error {
+	result := s.db.Where("id = ? AND user_id = ?", mappingID, userID).Delete(&db.ImportMapping{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete import mapping: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrImportMappingNotFound
+	}
+	return nil
+}
+
+// runImportCtx works out what happens to every entry and, unless dryRun,
+// does it. A dry run goes through the same checks inside a transaction that
+// is rolled back, so the report also catches entries overlapping each other.
+func (s *TimeSessionService) runImportCtx(ctx context.Context, userID string, batch *db.ImportBatch, entries []importer.Entry, dryRun bool) (*ImportReport, error) {
+	report := &ImportReport{Source: batch.Source, DryRun: dryRun, Entries: len(entries), Actions: make(map[string]int)}
+	plans, err := s.resolveImportProjectsCtx(ctx, userID, batch, entries)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range sortedPlanKeys(plans) {
+		report.Projects = append(report.Projects, plans[key])
+	}
+	if !dryRun {
+		if err := s.createImportProjectsCtx(ctx, userID, batch.Source, report.Projects); err != nil {
+			return nil, err
+		}
+	}
+
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return entries[order[a]].Start.Before(entries[order[b]].Start) })
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockUserTimeline(tx, userID); err != nil {
+			return err
+		}
+		projectIDs, err := planProjectIDsTx(tx, batch.ID, plans)
+		if err != nil {
+			return err
+		}
+		imported, err := importedEntriesTx(tx, userID, batch.Source, entries)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		seen := make(map[string]bool, len(entries))
+		var created []*db.TimeSession
+		for _, i := range order {
+			e := &entries[i]
+			key := importProjectKey(e)
+			item := ImportItem{Line: e.Line, ExternalID: e.ExternalID, Client: key.client, Project: key.project, Start: e.Start, End: e.End}
+
+			switch previous, ok := imported[e.ExternalID]; {
+			case batch.SourceUser != "" && !e.IsUser(batch.SourceUser):
+				item.Action = ImportActionOtherUser
+			case e.Problem != "":
+				item.Action, item.Detail = ImportActionInvalid, e.Problem
+			case e.End.After(now):
+				item.Action, item.Detail = ImportActionInvalid, "ends in the future"
+			case seen[e.ExternalID]:
+				item.Action = ImportActionDuplicate
+			case ok && previous.Fingerprint == e.Fingerprint():
+				item.Action, item.SessionID = ImportActionUnchanged, &previous.SessionID
+			case ok:
+				item.Action, item.SessionID = ImportActionChanged, &previous.SessionID
+			case plans[key].Action == ImportProjectDenied:
+				item.Action, item.Detail = ImportActionDenied, plans[key].Detail
+			}
+			seen[e.ExternalID] = true
+			if item.Action != "" {
+				report.add(item)
+				continue
+			}
+
+			err := s.checkSessionWindowTx(tx, userID, batch.CompanyID, e.Start, e.End, 0)
+			switch {
+			case errors.Is(err, ErrSessionOverlap):
+				item.Action = ImportActionOverlap
+			case errors.Is(err, ErrPeriodLocked):
+				item.Action = ImportActionLocked
+			case err != nil:
+				return err
+			}
+			if item.Action != "" {
+				report.add(item)
+				continue
+			}
+
+			session, err := s.createImportedSessionTx(tx, userID, batch, e, projectIDs[key], now)
+			if err != nil {
+				return err
+			}
+			created = append(created, session)
+			item.Action = ImportActionCreate
+			report.add(item)
+			plans[key].Entries++
+			plans[key].Minutes += session.NetMinutes
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return totals.Add(tx, created...)
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
+
+// createImportedSessionTx records one entry as a finished manual session,
+// keeping the entry's rates where the export has them
+func (s *TimeSessionService) createImportedSessionTx(tx *gorm.DB, userID string, batch *db.ImportBatch, e *importer.Entry, projectID uint, now time.Time) (*db.TimeSession, error) {
+	rate, err := s.resolveRateTx(tx, projectID, batch.CompanyID, userID, nil, false, e.Start)
+	if err != nil {
+		return nil, err
+	}
+	if e.CostRate != nil {
+		rate.HourlyRate, rate.Source = e.CostRate, db.RateSourceImport
+		if e.Currency != "" {
+			rate.Currency = e.Currency
+		}
+	}
+	if e.BillableRate != nil && e.Billable {
+		rate.BillableRate = e.BillableRate
+		if e.Currency != "" {
+			rate.BillableCurrency = e.Currency
+		}
+	}
+
+	end := e.End
+	session := &db.TimeSession{
+		ProjectID:     projectID,
+		UserID:        userID,
+		CompanyID:     batch.CompanyID,
+		StartTime:     e.Start,
+		EndTime:       &end,
+		SessionType:   db.SessionTypeWork,
+		Notes:         importNotes(e),
+		NonBillable:   !e.Billable,
+		IsManualEntry: true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	rate.apply(session)
+	applySessionTimes(session, nil, now)
+	if err := tx.Omit(clause.Associations).Create(session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	record := &db.ImportedEntry{
+		UserID:      userID,
+		Source:      batch.Source,
+		ExternalID:  e.ExternalID,
+		Fingerprint: e.Fingerprint(),
+		SessionID:   session.ID,
+		BatchID:     batch.ID,
+		CreatedAt:   now,
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to record imported entry: %w", err)
+	}
+	return session, nil
+}
+
+// importKey identifies a source project: its client and name
+type importKey struct {
+	client  string
+	project string
+}
+
+// importProjectKey is the source project of an entry; entries without a
+// project go by their first tag, so tag-based workspaces keep their split
+func importProjectKey(e *importer.Entry) importKey {
+	project := e.Project
+	if project == "" && len(e.Tags) > 0 {
+		project = e.Tags[0]
+	}
+	if project == "" {
+		project = noProjectName
+	}
+	return importKey{client: e.Client, project: project}
+}
+
+// resolveImportProjectsCtx decides, per source project, which of the
+// user's projects the entries go to: a mapping first, then a project with
+// the same title and client, else a new one
+func (s *TimeSessionService) resolveImportProjectsCtx(ctx context.Context, userID string, batch *db.ImportBatch, entries []importer.Entry) (map[importKey]*ImportProjectPlan, error) {
+	plans := make(map[importKey]*ImportProjectPlan)
+	for i := range entries {
+		key := importProjectKey(&entries[i])
+		if plans[key] == nil {
+			plans[key] = &ImportProjectPlan{Client: key.client, Project: key.project, Action: ImportProjectCreate}
+		}
+	}
+
+	var mappings []db.ImportMapping
+	if err := s.db.WithContext(ctx).Where("user_id = ? AND source = ?", userID, batch.Source).Find(&mappings).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve import mappings: %w", err)
+	}
+	for _, m := range mappings {
+		if plan := plans[importKey{client: m.ClientName, project: m.ProjectName}]; plan != nil {
+			id := m.ProjectID
+			plan.Action, plan.ProjectID = ImportProjectMapped, &id
+		}
+	}
+
+	byName, err := s.userProjectsByNameCtx(ctx, userID, plans)
+	if err != nil {
+		return nil, err
+	}
+	checked := make(map[uint]error)
+	for key, plan := range plans {
+		if plan.Action == ImportProjectCreate {
+			project, ok := byName[strings.ToLower(key.client)+"\x00"+strings.ToLower(key.project)]
+			if !ok {
+				continue
+			}
+			id := project.ID
+			plan.Action, plan.ProjectID = ImportProjectMatched, &id
+		}
+
+		accessErr, done := checked[*plan.ProjectID]
+		if !done {
+			accessErr = s.verifyProjectAccessCtx(ctx, *plan.ProjectID, batch.CompanyID, userID)
+			if errors.Is(accessErr, clients.ErrCoreUnavailable) {
+				return nil, accessErr
+			}
+			checked[*plan.ProjectID] = accessErr
+		}
+		if accessErr != nil {
+			plan.Action, plan.Detail = ImportProjectDenied, accessErr.Error()
+		}
+	}
+	return plans, nil
+}
+
+// userProjectsByNameCtx indexes the professional projects the user is a
+// member of by client and title, lowercase. Core is only asked when some
+// source project is still unresolved.
+func (s *TimeSessionService) userProjectsByNameCtx(ctx context.Context, userID string, plans map[importKey]*ImportProjectPlan) (map[string]db.ProfessionalProject, error) {
+	byName := make(map[string]db.ProfessionalProject)
+	pending := false
+	for _, plan := range plans {
+		pending = pending || plan.Action == ImportProjectCreate
+	}
+	if !pending {
+		return byName, nil
+	}
+
+	bases, err := s.coreClient.GetUserProjects(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(bases) == 0 {
+		return byName, nil
+	}
+	baseIDs := make([]string, len(bases))
+	for i, base := range bases {
+		baseIDs[i] = base.ID
+	}
+	var projects []db.ProfessionalProject
+	if err := s.db.WithContext(ctx).Where("base_project_id IN ?", baseIDs).Order("id").Find(&projects).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve projects: %w", err)
+	}
+	for _, project := range projects {
+		client := ""
+		if project.ClientName != nil {
+			client = strings.TrimSpace(*project.ClientName)
+		}
+		key := strings.ToLower(client) + "\x00" + strings.ToLower(strings.TrimSpace(project.Title))
+		if _, taken := byName[key]; !taken {
+			byName[key] = project
+		}
+	}
+	return byName, nil
+}
+
+// createImportProjectsCtx creates the projects the plan calls for, in
+// project-core first like any new project, and maps them. Matched projects
+// are mapped too, so renaming one later doesn't split an import.
+func (s *TimeSessionService) createImportProjectsCtx(ctx context.Context, userID, source string, plans []*ImportProjectPlan) error {
+	for _, plan := range plans {
+		switch plan.Action {
+		case ImportProjectCreate:
+			base, err := s.coreClient.CreateBaseProject(ctx, &clients.BaseProjectCreateRequest{
+				Title:   plan.Project,
+				OwnerID: userID,
+				Status:  "active",
+			})
+			if err != nil {
+				return fmt.Errorf("create base project: %w", err)
+			}
+			if base.ID == "" || base.ID == "0" {
+				return fmt.Errorf("core project ID missing (got %q)", base.ID)
+			}
+			now := time.Now()
+			project := &db.ProfessionalProject{
+				BaseProjectID: base.ID,
+				Title:         plan.Project,
+				IsActive:      true,
+				CreatedAt:     now,
+				UpdatedAt:     now,
+			}
+			if plan.Client != "" {
+				client := plan.Client
+				project.ClientName = &client
+			}
+			if err := s.db.WithContext(ctx).Create(project).Error; err != nil {
+				return fmt.Errorf("failed to create professional project: %w", err)
+			}
+			plan.ProjectID = &project.ID
+		case ImportProjectMatched:
+		default:
+			continue
+		}
+
+		mapping := &db.ImportMapping{
+			UserID:      userID,
+			Source:      source,
+			ClientName:  plan.Client,
+			ProjectName: plan.Project,
+			ProjectID:   *plan.ProjectID,
+			CreatedAt:   time.Now(),
+		}
+		if err := upsertImportMapping(s.db.WithContext(ctx), mapping); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// planProjectIDsTx returns the project of each source project. A dry run
+// has no projects for those still to be created, so it adds placeholders
+// that are rolled back with it.
+func planProjectIDsTx(tx *gorm.DB, batchID uint, plans map[importKey]*ImportProjectPlan) (map[importKey]uint, error) {
+	ids := make(map[importKey]uint, len(plans))
+	for i, key := range sortedPlanKeys(plans) {
+		plan := plans[key]
+		if plan.ProjectID != nil {
+			ids[key] = *plan.ProjectID
+			continue
+		}
+		if plan.Action != ImportProjectCreate {
+			continue
+		}
+		placeholder := &db.ProfessionalProject{
+			BaseProjectID: fmt.Sprintf("import-%d-%d", batchID, i),
+			Title:         plan.Project,
+			IsActive:      true,
+		}
+		if err := tx.Create(placeholder).Error; err != nil {
+			return nil, fmt.Errorf("failed to plan project %q: %w", plan.Project, err)
+		}
+		ids[key] = placeholder.ID
+	}
+	return ids, nil
+}
+
+// importedEntriesTx loads the entries of the file imported before, by external ID
+func importedEntriesTx(tx *gorm.DB, userID, source string, entries []importer.Entry) (map[string]db.ImportedEntry, error) {
+	const chunk = 1000
+	imported := make(map[string]db.ImportedEntry)
+	for start := 0; start < len(entries); start += chunk {
+		end := start + chunk
+		if end > len(entries) {
+			end = len(entries)
+		}
+		ids := make([]string, 0, end-start)
+		for _, e := range entries[start:end] {
+			ids = append(ids, e.ExternalID)
+		}
+		var found []db.ImportedEntry
+		if err := tx.Where("user_id = ? AND source = ? AND external_id IN ?", userID, source, ids).Find(&found).Error; err != nil {
+			return nil, fmt.Errorf("failed to retrieve imported entries: %w", err)
+		}
+		for _, record := range found {
+			imported[record.ExternalID] = record
+		}
+	}
+	return imported, nil
+}
+
+func upsertImportMapping(tx *gorm.DB, mapping *db.ImportMapping) error {
+	err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "source"}, {Name: "client_name"}, {Name: "project_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"project_id"}),
+	}).Create(mapping).Error
+	if err != nil {
+		return fmt.Errorf("failed to save import mapping: %w", err)
+	}
+	return nil
+}
+
+func (s *TimeSessionService) saveImportReport(ctx context.Context, batch *db.ImportBatch, report *ImportReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode import report: %w", err)
+	}
+	batch.Report = data
+	if err := s.db.WithContext(ctx).Select("status", "applied_at", "entries", "report").Save(batch).Error; err != nil {
+		return fmt.Errorf("failed to save import: %w", err)
+	}
+	return nil
+}
+
+// add counts an entry and lists it unless it simply becomes a session
+func (r *ImportReport) add(item ImportItem) {
+	r.Actions[item.Action]++
+	if item.Action == ImportActionCreate {
+		return
+	}
+	if len(r.Items) >= MaxImportReportItems {
+		r.Truncated = true
+		return
+	}
+	r.Items = append(r.Items, item)
+}
+
+func sortedPlanKeys(plans map[importKey]*ImportProjectPlan) []importKey {
+	keys := make([]importKey, 0, len(plans))
+	for key := range plans {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].client != keys[j].client {
+			return keys[i].client < keys[j].client
+		}
+		return keys[i].project < keys[j].project
+	})
+	return keys
+}
+
+// importNotes keeps the entry's task, description and tags as session notes
+func importNotes(e *importer.Entry) *string {
+	var parts []string
+	switch {
+	case e.Task != "" && e.Description != "":
+		parts = append(parts, e.Task+": "+e.Description)
+	case e.Task != "":
+		parts = append(parts, e.Task)
+	case e.Description != "":
+		parts = append(parts, e.Description)
+	}
+	if len(e.Tags) > 0 {
+		parts = append(parts, "Tags: "+strings.Join(e.Tags, ", "))
+	}
+	if len(parts) == 0 {
+		return nil
+	}
+	notes := strings.Join(parts, "\n")
+	return &notes
+}
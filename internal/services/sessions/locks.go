@@ -0,0 +1,160 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+)
+
+// LockPeriod locks [start, end) of the user's own timeline in a company, so no
+// session can be entered or corrected there anymore.
+func (s *TimeSessionService) LockPeriod(userID, companyID string, start, end time.Time, reason *string) (*db.PeriodLock, error) {
+	if companyID == "" {
+		return nil, fmt.Errorf("%w: company is required", ErrInvalidSession)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end time must be after start time", ErrInvalidSession)
+	}
+
+	lock := &db.PeriodLock{
+		CompanyID: companyID,
+		UserID:    &userID,
+		StartTime: start,
+		EndTime:   end,
+		Reason:    reason,
+		LockedBy:  userID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.Create(lock).Error; err != nil {
+		return nil, fmt.Errorf("failed to create period lock: %w", err)
+	}
+	return lock, nil
+}
+
+// GetUserPeriodLocks lists the locks that apply to the user: their own and company-wide ones
+func (s *TimeSessionService) GetUserPeriodLocks(userID, companyID string) ([]db.PeriodLock, error) {
+	var locks []db.PeriodLock
+	q := s.db.Where("user_id = ? OR (user_id IS NULL AND company_id IN (?))", userID,
+		s.db.Model(&db.TimeSession{}).Distinct("company_id").Where("user_id = ?", userID))
+	if companyID != "" {
+		q = q.Where("company_id = ?", companyID)
+	}
+	if err := q.Order("start_time").Find(&locks).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve period locks: %w", err)
+	}
+	return locks, nil
+}
+
+// UnlockPeriod removes a lock the user put on their own timeline. Locks set
+// by a company administrator, for the whole company or for the user, can
+// only be removed by an administrator with UnlockCompanyPeriodCtx.
+func (s *TimeSessionService) UnlockPeriod(userID string, lockID uint) error {
+	var lock db.PeriodLock
+	if err := s.db.First(&lock, lockID).Error; err != nil {
+		return lookupError(err, ErrPeriodLockNotFound, "period lock")
+	}
+	if lock.UserID == nil || *lock.UserID != userID || lock.LockedBy != userID {
+		return ErrPeriodLockAccessDenied
+	}
+	if err := s.db.Delete(&lock).Error; err != nil {
+		return fmt.Errorf("failed to delete period lock: %w", err)
+	}
+	return nil
+}
+
+// LockCompanyPeriodCtx locks [start, end) in the company projectID belongs
+// to, for every member or only for targetUserID (e.g. after payroll or an
+// approved timesheet). adminID must be allowed to update the project in
+// project-core; employees can't remove these locks.
+func (s *TimeSessionService) LockCompanyPeriodCtx(ctx context.Context, adminID string, projectID uint, targetUserID *string, start, end time.Time, reason *string) (*db.PeriodLock, error) {
+	if !end.After(start) {
+		return nil, fmt.Errorf("%w: end time must be after start time", ErrInvalidSession)
+	}
+	if targetUserID != nil && *targetUserID == "" {
+		targetUserID = nil
+	}
+	companyID, err := s.companyAdminCtx(ctx, projectID, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &db.PeriodLock{
+		CompanyID: companyID,
+		UserID:    targetUserID,
+		StartTime: start,
+		EndTime:   end,
+		Reason:    reason,
+		LockedBy:  adminID,
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.WithContext(ctx).Create(lock).Error; err != nil {
+		return nil, fmt.Errorf("failed to create period lock: %w", err)
+	}
+	return lock, nil
+}
+
+// GetCompanyPeriodLocksCtx lists every lock of the company projectID belongs
+// to, company-wide and per user, for one of its administrators
+func (s *TimeSessionService) GetCompanyPeriodLocksCtx(ctx context.Context, adminID string, projectID uint) ([]db.PeriodLock, error) {
+	companyID, err := s.companyAdminCtx(ctx, projectID, adminID)
+	if err != nil {
+		return nil, err
+	}
+	var locks []db.PeriodLock
+	if err := s.db.WithContext(ctx).Where("company_id = ?", companyID).Order("start_time").Find(&locks).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve period locks: %w", err)
+	}
+	return locks, nil
+}
+
+// UnlockCompanyPeriodCtx removes any lock of the company projectID belongs
+// to, including the ones employees put on their own timelines
+func (s *TimeSessionService) UnlockCompanyPeriodCtx(ctx context.Context, adminID string, projectID, lockID uint) error {
+	companyID, err := s.companyAdminCtx(ctx, projectID, adminID)
+	if err != nil {
+		return err
+	}
+	var lock db.PeriodLock
+	if err := s.db.WithContext(ctx).First(&lock, lockID).Error; err != nil {
+		return lookupError(err, ErrPeriodLockNotFound, "period lock")
+	}
+	if lock.CompanyID != companyID {
+		return ErrPeriodLockAccessDenied
+	}
+	if err := s.db.WithContext(ctx).Delete(&lock).Error; err != nil {
+		return fmt.Errorf("failed to delete period lock: %w", err)
+	}
+	return nil
+}
+
+// companyAdminCtx returns the company of projectID in project-core when
+// userID may administer it. Like company settings, administering a company
+// means being allowed to update one of its projects.
+func (s *TimeSessionService) companyAdminCtx(ctx context.Context, projectID uint, userID string) (string, error) {
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		return "", lookupError(err, ErrProjectNotFound, "project")
+	}
+
+	if err := s.requireProjectManagerCtx(ctx, project.BaseProjectID, userID); err != nil {
+		if errors.Is(err, clients.ErrCoreUnavailable) {
+			return "", err
+		}
+		return "", ErrCompanyAdminRequired.Wrap(err)
+	}
+	base, err := s.coreClient.GetProject(ctx, project.BaseProjectID, userID)
+	if err != nil {
+		if errors.Is(err, clients.ErrCoreUnavailable) {
+			return "", err
+		}
+		return "", ErrCompanyAdminRequired.Wrap(err)
+	}
+	if base.CompanyID == nil || *base.CompanyID == "" {
+		return "", ErrProjectHasNoCompany
+	}
+	return *base.CompanyID, nil
+}
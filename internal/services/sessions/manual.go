@@ -0,0 +1,200 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/totals"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ManualBreakInput is a break inside a manually entered session
+type ManualBreakInput struct {
+	BreakType string
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// ManualSessionInput describes a completed session entered after the fact
+type ManualSessionInput struct {
+	ProjectID   uint
+	CompanyID   string
+	StartTime   time.Time
+	EndTime     time.Time
+	Breaks      []ManualBreakInput
+	Notes       *string
+	HourlyRate  *money.Amount
+	NonBillable bool
+}
+
+// CreateManualSession records a completed work session retroactively.
+// The session must not overlap any other session of the user (including the
+// running one) and must not fall into a locked period.
+func (s *TimeSessionService) CreateManualSession(userID string, in *ManualSessionInput) (*db.TimeSession, error) {
+	// Backwards-compat wrapper.
+	return s.CreateManualSessionCtx(context.Background(), userID, in)
+}
+
+// CreateManualSessionCtx is the request-scoped variant. Like starting a
+// session, it requires membership of the project in the given company.
+func (s *TimeSessionService) CreateManualSessionCtx(ctx context.Context, userID string, in *ManualSessionInput) (*db.TimeSession, error) {
+	if err := s.validateManualInput(in); err != nil {
+		return nil, err
+	}
+	if err := s.verifyProjectAccessCtx(ctx, in.ProjectID, in.CompanyID, userID); err != nil {
+		return nil, err
+	}
+	rateManager, err := s.rateManagerCtx(ctx, in.ProjectID, userID, in.HourlyRate)
+	if err != nil {
+		return nil, err
+	}
+
+	var session *db.TimeSession
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		session, err = s.createManualSessionTx(tx, userID, in, rateManager)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// createManualSessionTx records a validated manual entry inside tx. Callers
+// verify project access first; rateManager is as in resolveRateTx.
+func (s *TimeSessionService) createManualSessionTx(tx *gorm.DB, userID string, in *ManualSessionInput, rateManager bool) (*db.TimeSession, error) {
+	if err := lockUserTimeline(tx, userID); err != nil {
+		return nil, err
+	}
+
+	var project db.ProfessionalProject
+	if err := tx.First(&project, in.ProjectID).Error; err != nil {
+		return nil, lookupError(err, ErrProjectNotFound, "project")
+	}
+
+	if err := s.checkSessionWindowTx(tx, userID, in.CompanyID, in.StartTime, in.EndTime, 0); err != nil {
+		return nil, err
+	}
+
+	rate, err := s.resolveRateTx(tx, in.ProjectID, in.CompanyID, userID, in.HourlyRate, rateManager, in.StartTime)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	end := in.EndTime
+	session := &db.TimeSession{
+		ProjectID:     in.ProjectID,
+		UserID:        userID,
+		CompanyID:     in.CompanyID,
+		StartTime:     in.StartTime,
+		EndTime:       &end,
+		SessionType:   db.SessionTypeWork,
+		Notes:         in.Notes,
+		NonBillable:   in.NonBillable,
+		IsActive:      false,
+		IsManualEntry: true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	rate.apply(session)
+	if err := tx.Omit(clause.Associations).Create(session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	breaks := make([]db.SessionBreak, 0, len(in.Breaks))
+	for _, b := range in.Breaks {
+		bEnd := b.EndTime
+		breakRecord := db.SessionBreak{
+			SessionID: session.ID,
+			BreakType: b.BreakType,
+			StartTime: b.StartTime,
+			EndTime:   &bEnd,
+			IsActive:  false,
+			CreatedAt: now,
+		}
+		breakRecord.DurationMinutes = s.calculateBreakDuration(&breakRecord)
+		if err := tx.Omit(clause.Associations).Create(&breakRecord).Error; err != nil {
+			return nil, fmt.Errorf("failed to create break record: %w", err)
+		}
+		breaks = append(breaks, breakRecord)
+	}
+
+	applySessionTimes(session, breaks, now)
+	if err := tx.Omit(clause.Associations).Save(session).Error; err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+	if err := totals.Add(tx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// validateManualInput checks the shape of a manual entry before touching the database
+func (s *TimeSessionService) validateManualInput(in *ManualSessionInput) error {
+	if in.ProjectID == 0 || in.CompanyID == "" {
+		return fmt.Errorf("%w: project and company are required", ErrInvalidSession)
+	}
+	if !in.EndTime.After(in.StartTime) {
+		return fmt.Errorf("%w: end time must be after start time", ErrInvalidSession)
+	}
+	if in.EndTime.After(time.Now()) {
+		return fmt.Errorf("%w: end time cannot be in the future", ErrInvalidSession)
+	}
+	if in.HourlyRate != nil && *in.HourlyRate < 0 {
+		return fmt.Errorf("%w: hourly rate cannot be negative", ErrInvalidSession)
+	}
+
+	breaks := make([]ManualBreakInput, len(in.Breaks))
+	copy(breaks, in.Breaks)
+	sort.Slice(breaks, func(i, j int) bool { return breaks[i].StartTime.Before(breaks[j].StartTime) })
+	for i, b := range breaks {
+		if !s.isValidBreakType(b.BreakType) {
+			return fmt.Errorf("%w: invalid break type - use: break, lunch, or brb", ErrInvalidSession)
+		}
+		if !b.EndTime.After(b.StartTime) {
+			return fmt.Errorf("%w: break end time must be after its start time", ErrInvalidSession)
+		}
+		if b.StartTime.Before(in.StartTime) || b.EndTime.After(in.EndTime) {
+			return fmt.Errorf("%w: breaks must be within the session", ErrInvalidSession)
+		}
+		if i > 0 && b.StartTime.Before(breaks[i-1].EndTime) {
+			return fmt.Errorf("%w: breaks must not overlap", ErrInvalidSession)
+		}
+	}
+	return nil
+}
+
+// checkSessionWindowTx ensures [start, end) neither overlaps another session of
+// the user (ignoring excludeID) nor falls into a locked period of the company.
+func (s *TimeSessionService) checkSessionWindowTx(tx *gorm.DB, userID, companyID string, start, end time.Time, excludeID uint) error {
+	var overlapping int64
+	q := tx.Model(&db.TimeSession{}).
+		Where("user_id = ? AND start_time < ? AND (end_time IS NULL OR end_time > ?)", userID, end, start)
+	if excludeID != 0 {
+		q = q.Where("id <> ?", excludeID)
+	}
+	if err := q.Count(&overlapping).Error; err != nil {
+		return fmt.Errorf("failed to check overlapping sessions: %w", err)
+	}
+	if overlapping > 0 {
+		return ErrSessionOverlap
+	}
+
+	return s.checkLockedTx(tx, userID, companyID, start, end)
+}
+
+// lockUserTimeline serializes timeline writes of one user for the rest of tx,
+// so two concurrent entries cannot both pass the overlap check.
+func lockUserTimeline(tx *gorm.DB, userID string) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", userID).Error; err != nil {
+		return fmt.Errorf("failed to lock user timeline: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/rates"
+	"gorm.io/gorm"
+)
+
+// sessionRate is the rate stamped onto a session and where it came from
+type sessionRate struct {
+	AssignmentID     *uint
+	HourlyRate       *money.Amount
+	Currency         string
+	Source           string
+	BillableRate     *money.Amount
+	BillableCurrency string
+}
+
+// apply stamps the rate onto session
+func (r sessionRate) apply(session *db.TimeSession) {
+	session.ProjectAssignmentID = r.AssignmentID
+	session.HourlyRate = r.HourlyRate
+	session.RateSource = r.Source
+	session.Currency = r.Currency
+	session.BillableRate = r.BillableRate
+	session.BillableCurrency = r.BillableCurrency
+}
+
+// resolveRateTx determines the rates of userID's work on projectID starting
+// at at. The worker's active ProjectAssignment wins: the cost rate in force at
+// that time is used and a requested rate that disagrees is rejected with
+// ErrRateMismatch, so costs can't be set from the browser. Without an
+// assignment a requested rate is only kept, flagged as client-supplied, when
+// rateManager says the caller manages the project (see rateManagerCtx);
+// otherwise it is rejected with ErrRateNotAllowed.
+//
+// The billable rate is never taken from the caller. It comes from the
+// assignment's period, else the project, else the client's rate in companyID.
+// Each rate carries the currency of where it came from; a client-supplied
+// rate is in the project's currency.
+func (s *TimeSessionService) resolveRateTx(tx *gorm.DB, projectID uint, companyID, userID string, requested *money.Amount, rateManager bool, at time.Time) (sessionRate, error) {
+	if requested != nil && *requested < 0 {
+		return sessionRate{}, fmt.Errorf("%w: hourly rate cannot be negative", ErrInvalidSession)
+	}
+
+	var project db.ProfessionalProject
+	if err := tx.First(&project, projectID).Error; err != nil {
+		return sessionRate{}, lookupError(err, ErrProjectNotFound, "project")
+	}
+
+	projectCurrency, err := rates.ProjectCurrency(tx, &project, companyID)
+	if err != nil {
+		return sessionRate{}, err
+	}
+
+	rate := sessionRate{Currency: projectCurrency, BillableCurrency: projectCurrency}
+	var assignment db.ProjectAssignment
+	err = tx.Where("parent_project_id = ? AND worker_user_id = ? AND is_active = ?", projectID, userID, true).
+		Order("created_at DESC").First(&assignment).Error
+	switch {
+	case err == nil:
+		period, err := rates.EffectiveRate(tx, &assignment, at)
+		if err != nil {
+			return sessionRate{}, err
+		}
+		if requested != nil && *requested != period.CostPerHour {
+			return sessionRate{}, fmt.Errorf("%w: assignment rate is %s %s", ErrRateMismatch, period.CostPerHour, period.Currency)
+		}
+		cost := period.CostPerHour
+		rate = sessionRate{
+			AssignmentID:     &assignment.ID,
+			HourlyRate:       &cost,
+			Currency:         period.Currency,
+			Source:           db.RateSourceAssignment,
+			BillableRate:     period.BillableRate,
+			BillableCurrency: period.Currency,
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if requested != nil {
+			if !rateManager {
+				return sessionRate{}, ErrRateNotAllowed
+			}
+			rate.HourlyRate = requested
+			rate.Source = db.RateSourceClient
+		}
+	default:
+		return sessionRate{}, fmt.Errorf("failed to load project assignment: %w", err)
+	}
+
+	if rate.BillableRate == nil {
+		billable, currency, err := s.defaultBillableRateTx(tx, &project, projectCurrency, companyID)
+		if err != nil {
+			return sessionRate{}, err
+		}
+		if billable != nil {
+			rate.BillableRate, rate.BillableCurrency = billable, currency
+		}
+	}
+	return rate, nil
+}
+
+// rateManagerCtx tells whether userID may set their own rate on projectID,
+// i.e. may update its BaseProject in project-core. Nothing is asked when no
+// rate was requested.
+func (s *TimeSessionService) rateManagerCtx(ctx context.Context, projectID uint, userID string, requested *money.Amount) (bool, error) {
+	if requested == nil {
+		return false, nil
+	}
+	var project db.ProfessionalProject
+	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
+		return false, lookupError(err, ErrProjectNotFound, "project")
+	}
+
+	if err := s.requireProjectManagerCtx(ctx, project.BaseProjectID, userID); err != nil {
+		if errors.Is(err, clients.ErrCoreUnavailable) {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// defaultBillableRateTx returns the project's billable rate in
+// projectCurrency, or the rate of its client in companyID (or for personal
+// projects) in the client rate's currency, or nil.
+func (s *TimeSessionService) defaultBillableRateTx(tx *gorm.DB, project *db.ProfessionalProject, projectCurrency, companyID string) (*money.Amount, string, error) {
+	if project.BillableRate != nil {
+		return project.BillableRate.Ptr(), projectCurrency, nil
+	}
+	if project.ClientName == nil {
+		return nil, "", nil
+	}
+
+	var clientRate db.ClientRate
+	err := tx.Where("client_name = ? AND company_id IN ?", strings.TrimSpace(*project.ClientName), []string{companyID, ""}).
+		Order("company_id DESC").First(&clientRate).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load client rate: %w", err)
+	}
+	return &clientRate.BillableRate, clientRate.Currency, nil
+}
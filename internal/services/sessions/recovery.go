@@ -0,0 +1,196 @@
+package sessions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Recovery actions offered for a session left running after a client crash.
+const (
+	RecoveryKeep              = "keep"                 // keep the session running
+	RecoveryEndAt             = "end_at"               // finish at a time chosen by the user
+	RecoveryEndAtLastActivity = "end_at_last_activity" // finish at the last heartbeat/break change
+	RecoveryDiscard           = "discard"              // delete the session and its breaks
+)
+
+// recoveryIdleThreshold is how long a session must go without activity before
+// reconnecting clients are asked what to do with it.
+const recoveryIdleThreshold = 30 * time.Minute
+
+// RecoveryInfo describes the user's running session for a reconnecting client.
+type RecoveryInfo struct {
+	ActiveSession *db.UserActiveSession
+	Session       *db.TimeSession // with live duration and cost
+	IdleMinutes   int             // since the last recorded activity
+	NeedsRecovery bool            // idle longer than recoveryIdleThreshold
+	Actions       []string
+}
+
+// GetRecoveryInfo reports the user's running session together with the
+// recovery actions that can be applied to it.
+func (s *TimeSessionService) GetRecoveryInfo(userID string) (*RecoveryInfo, error) {
+	var info *RecoveryInfo
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		activeSession, err := s.findActiveSessionTx(tx, userID)
+		if err != nil {
+			return lookupError(err, ErrNoActiveSession, "active session")
+		}
+
+		session, err := s.liveSessionTx(tx, activeSession.SessionID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		idle := time.Since(activeSession.LastActivityAt)
+		info = &RecoveryInfo{
+			ActiveSession: activeSession,
+			Session:       session,
+			IdleMinutes:   int(idle.Minutes()),
+			NeedsRecovery: idle >= recoveryIdleThreshold,
+			Actions:       []string{RecoveryKeep, RecoveryEndAt, RecoveryEndAtLastActivity, RecoveryDiscard},
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// RecoverSession applies a recovery action to the user's running session.
+// endAt is required for RecoveryEndAt and ignored otherwise. Finishing actions
+// recompute the session cost and the project totals; RecoveryDiscard returns
+// the session as it was before deletion.
+func (s *TimeSessionService) RecoverSession(userID, action string, endAt *time.Time) (*db.TimeSession, error) {
+	var session *db.TimeSession
+	var activeSession *db.UserActiveSession
+	var resumed *db.SessionBreak
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		activeSession, err = s.findActiveSessionTx(tx, userID)
+		if err != nil {
+			return lookupError(err, ErrNoActiveSession, "active session")
+		}
+
+		now := time.Now()
+		switch action {
+		case RecoveryKeep:
+			if resumed, err = s.heartbeatTx(tx, activeSession, now); err != nil {
+				return err
+			}
+			session, err = s.liveSessionTx(tx, activeSession.SessionID, now)
+			return err
+
+		case RecoveryEndAt, RecoveryEndAtLastActivity:
+			end := activeSession.LastActivityAt
+			if action == RecoveryEndAt {
+				if endAt == nil {
+					return fmt.Errorf("%w: end time is required", ErrInvalidSession)
+				}
+				end = *endAt
+			}
+			if !end.After(activeSession.StartedAt) {
+				return fmt.Errorf("%w: end time must be after the session start", ErrInvalidSession)
+			}
+			if end.After(now) {
+				return fmt.Errorf("%w: end time cannot be in the future", ErrInvalidSession)
+			}
+
+			if err := s.trimBreaksTx(tx, activeSession, end); err != nil {
+				return err
+			}
+			reason := db.EndReasonRecovered
+			session, err = s.finishSessionTx(tx, userID, end, &reason)
+			return err
+
+		case RecoveryDiscard:
+			session, err = s.liveSessionTx(tx, activeSession.SessionID, now)
+			if err != nil {
+				return err
+			}
+			// The active record references the current break, so it goes first.
+			if err := tx.Delete(activeSession).Error; err != nil {
+				return fmt.Errorf("failed to remove active session record: %w", err)
+			}
+			if err := tx.Where("session_id = ?", session.ID).Delete(&db.SessionBreak{}).Error; err != nil {
+				return fmt.Errorf("failed to delete session breaks: %w", err)
+			}
+			if err := tx.Delete(&db.TimeSession{}, session.ID).Error; err != nil {
+				return fmt.Errorf("failed to delete session: %w", err)
+			}
+			return nil
+
+		default:
+			return fmt.Errorf("%w: unknown recovery action %q", ErrInvalidSession, action)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case RecoveryKeep:
+		if resumed != nil {
+			s.publishBreak(events.SessionBreakEnded, activeSession, resumed)
+		}
+	case RecoveryDiscard:
+		s.publishSession(events.SessionDiscarded, session)
+	default:
+		s.publishSession(events.SessionFinished, session)
+	}
+	return session, nil
+}
+
+// liveSessionTx loads a running session with its duration and cost computed up to now
+func (s *TimeSessionService) liveSessionTx(tx *gorm.DB, sessionID uint, now time.Time) (*db.TimeSession, error) {
+	var session db.TimeSession
+	if err := tx.First(&session, sessionID).Error; err != nil {
+		return nil, lookupError(err, ErrSessionNotFound, "session")
+	}
+	breaks, err := s.sessionBreaksTx(tx, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	applySessionTimes(&session, breaks, now)
+	return &session, nil
+}
+
+// trimBreaksTx drops the breaks of the running session that start at or after
+// end and cuts closed ones running past it, so finishing earlier than the last
+// recorded activity leaves no break outside the session.
+func (s *TimeSessionService) trimBreaksTx(tx *gorm.DB, activeSession *db.UserActiveSession, end time.Time) error {
+	breaks, err := s.sessionBreaksTx(tx, activeSession.SessionID)
+	if err != nil {
+		return err
+	}
+
+	for i := range breaks {
+		b := &breaks[i]
+		switch {
+		case !b.StartTime.Before(end):
+			if activeSession.CurrentBreakID != nil && *activeSession.CurrentBreakID == b.ID {
+				activeSession.IsOnBreak = false
+				activeSession.CurrentBreakID = nil
+				if err := tx.Omit(clause.Associations).Save(activeSession).Error; err != nil {
+					return fmt.Errorf("failed to update active session: %w", err)
+				}
+			}
+			if err := tx.Delete(b).Error; err != nil {
+				return fmt.Errorf("failed to delete break: %w", err)
+			}
+		case b.EndTime != nil && b.EndTime.After(end):
+			cut := end
+			b.EndTime = &cut
+			b.DurationMinutes = s.calculateBreakDuration(b)
+			if err := tx.Omit(clause.Associations).Save(b).Error; err != nil {
+				return fmt.Errorf("failed to update break: %w", err)
+			}
+		}
+	}
+	return nil
+}
@@ -1,248 +1,275 @@
 package sessions
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
 
 	"github.com/JorgeSaicoski/pgconnect"
+	clients "github.com/JorgeSaicoski/professional-tracker/internal/client"
 	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/events"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/fx"
+	"github.com/JorgeSaicoski/professional-tracker/internal/services/totals"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// pgUniqueViolation is the PostgreSQL SQLSTATE for unique_violation.
+const pgUniqueViolation = "23505"
+
 type TimeSessionService struct {
+	db                *gorm.DB
 	sessionRepo       *pgconnect.Repository[db.TimeSession]
 	breakRepo         *pgconnect.Repository[db.SessionBreak]
 	activeSessionRepo *pgconnect.Repository[db.UserActiveSession]
 	projectRepo       *pgconnect.Repository[db.ProfessionalProject]
+
+	coreClient clients.CoreProjectClient
+	broker     events.Broker
 }
 
-func NewTimeSessionService(database *pgconnect.DB) *TimeSessionService {
+func NewTimeSessionService(
+	database *pgconnect.DB,
+	coreClient clients.CoreProjectClient,
+	broker events.Broker,
+) *TimeSessionService {
 	return &TimeSessionService{
+		db:                database.DB,
 		sessionRepo:       pgconnect.NewRepository[db.TimeSession](database),
 		breakRepo:         pgconnect.NewRepository[db.SessionBreak](database),
 		activeSessionRepo: pgconnect.NewRepository[db.UserActiveSession](database),
 		projectRepo:       pgconnect.NewRepository[db.ProfessionalProject](database),
+		coreClient:        coreClient,
+		broker:            broker,
 	}
 }
 
 // StartWorkSession starts a new work session
-func (s *TimeSessionService) StartWorkSession(projectID uint, companyID, userID string, hourlyRate *float64) (*db.TimeSession, error) {
-	// Check if user already has an active session
-	if hasActive, err := s.HasActiveSession(userID); err != nil {
-		return nil, fmt.Errorf("failed to check active session: %w", err)
-	} else if hasActive {
-		return nil, errors.New("user already has an active session - finish current session first")
-	}
-
-	// Validate project exists
-	var project db.ProfessionalProject
-	if err := s.projectRepo.FindByID(projectID, &project); err != nil {
-		return nil, fmt.Errorf("project not found: %w", err)
-	}
-
-	// TODO: Validate user has access to this project via project-core
-
-	// Create new session
-	session := &db.TimeSession{
-		ProjectID:   projectID,
-		UserID:      userID,
-		CompanyID:   companyID,
-		StartTime:   time.Now(),
-		SessionType: db.SessionTypeWork,
-		HourlyRate:  hourlyRate,
-		IsActive:    true,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
+func (s *TimeSessionService) StartWorkSession(projectID uint, companyID, userID string, hourlyRate *money.Amount) (*db.TimeSession, error) {
+	// Backwards-compat wrapper.
+	return s.StartWorkSessionCtx(context.Background(), projectID, companyID, userID, hourlyRate)
+}
 
-	if err := s.sessionRepo.Create(session); err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+// StartWorkSessionCtx is the request-scoped variant.
+func (s *TimeSessionService) StartWorkSessionCtx(ctx context.Context, projectID uint, companyID, userID string, hourlyRate *money.Amount) (*db.TimeSession, error) {
+	if err := s.verifyProjectAccessCtx(ctx, projectID, companyID, userID); err != nil {
+		return nil, err
 	}
-
-	// Create active session record
-	activeSession := &db.UserActiveSession{
-		UserID:         userID,
-		SessionID:      session.ID,
-		CompanyID:      companyID,
-		ProjectID:      projectID,
-		StartedAt:      session.StartTime,
-		LastActivityAt: session.StartTime,
-		IsOnBreak:      false,
-		UpdatedAt:      time.Now(),
+	rateManager, err := s.rateManagerCtx(ctx, projectID, userID, hourlyRate)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := s.activeSessionRepo.Create(activeSession); err != nil {
-		// Rollback session creation
-		s.sessionRepo.Delete(session)
-		return nil, fmt.Errorf("failed to create active session record: %w", err)
+	var session *db.TimeSession
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		now := time.Now()
+		rate, err := s.resolveRateTx(tx, projectID, companyID, userID, hourlyRate, rateManager, now)
+		if err != nil {
+			return err
+		}
+		session, err = s.startSessionTx(tx, projectID, companyID, userID, rate, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
-
+	s.publishSession(events.SessionStarted, session)
 	return session, nil
 }
 
 // FinishWorkSession ends the current active session
 func (s *TimeSessionService) FinishWorkSession(userID string) (*db.TimeSession, error) {
-	// Get active session
-	activeSession, err := s.GetActiveSession(userID)
+	var session *db.TimeSession
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		session, err = s.finishSessionTx(tx, userID, time.Now(), nil)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("no active session found: %w", err)
-	}
-
-	// End any active break first
-	if activeSession.IsOnBreak && activeSession.CurrentBreakID != nil {
-		if _, err := s.EndBreak(userID); err != nil {
-			return nil, fmt.Errorf("failed to end active break: %w", err)
-		}
-	}
-
-	// Get the session
-	var session db.TimeSession
-	if err := s.sessionRepo.FindByID(activeSession.SessionID, &session); err != nil {
-		return nil, fmt.Errorf("session not found: %w", err)
-	}
-
-	// End the session
-	now := time.Now()
-	session.EndTime = &now
-	session.IsActive = false
-	session.DurationMinutes = s.calculateSessionDuration(&session)
-	session.SessionCost = s.calculateSessionCost(&session)
-	session.UpdatedAt = now
-
-	if err := s.sessionRepo.Update(&session); err != nil {
-		return nil, fmt.Errorf("failed to update session: %w", err)
-	}
-
-	// Remove active session record
-	if err := s.activeSessionRepo.Delete(activeSession); err != nil {
-		return nil, fmt.Errorf("failed to remove active session record: %w", err)
+		return nil, err
 	}
-
-	return &session, nil
+	s.publishSession(events.SessionFinished, session)
+	return session, nil
 }
 
 // TakeBreak starts a break during the current session
 func (s *TimeSessionService) TakeBreak(userID, breakType string) (*db.SessionBreak, error) {
-	// Get active session
-	activeSession, err := s.GetActiveSession(userID)
-	if err != nil {
-		return nil, fmt.Errorf("no active session found: %w", err)
-	}
-
-	if activeSession.IsOnBreak {
-		return nil, errors.New("already on break - end current break first")
-	}
-
 	// Validate break type
 	if !s.isValidBreakType(breakType) {
-		return nil, errors.New("invalid break type - use: break, lunch, or brb")
+		return nil, ErrInvalidBreakType
 	}
 
-	// Create break record
-	breakRecord := &db.SessionBreak{
-		SessionID: activeSession.SessionID,
-		BreakType: breakType,
-		StartTime: time.Now(),
-		IsActive:  true,
-		CreatedAt: time.Now(),
-	}
+	var activeSession *db.UserActiveSession
+	var breakRecord *db.SessionBreak
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		activeSession, err = s.findActiveSessionTx(tx, userID)
+		if err != nil {
+			return lookupError(err, ErrNoActiveSession, "active session")
+		}
 
-	if err := s.breakRepo.Create(breakRecord); err != nil {
-		return nil, fmt.Errorf("failed to create break record: %w", err)
-	}
+		if activeSession.IsOnBreak {
+			return ErrAlreadyOnBreak
+		}
 
-	// Update active session
-	activeSession.IsOnBreak = true
-	activeSession.CurrentBreakID = &breakRecord.ID
-	activeSession.LastActivityAt = time.Now()
-	activeSession.UpdatedAt = time.Now()
+		now := time.Now()
+		breakRecord = &db.SessionBreak{
+			SessionID: activeSession.SessionID,
+			BreakType: breakType,
+			StartTime: now,
+			IsActive:  true,
+			CreatedAt: now,
+		}
 
-	if err := s.activeSessionRepo.Update(activeSession); err != nil {
-		return nil, fmt.Errorf("failed to update active session: %w", err)
-	}
+		if err := tx.Omit(clause.Associations).Create(breakRecord).Error; err != nil {
+			return fmt.Errorf("failed to create break record: %w", err)
+		}
 
+		activeSession.IsOnBreak = true
+		activeSession.CurrentBreakID = &breakRecord.ID
+		activeSession.LastActivityAt = now
+		activeSession.UpdatedAt = now
+
+		if err := tx.Omit(clause.Associations).Save(activeSession).Error; err != nil {
+			return fmt.Errorf("failed to update active session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.publishBreak(events.SessionBreakStarted, activeSession, breakRecord)
 	return breakRecord, nil
 }
 
 // EndBreak ends the current break and resumes work
 func (s *TimeSessionService) EndBreak(userID string) (*db.SessionBreak, error) {
-	// Get active session
-	activeSession, err := s.GetActiveSession(userID)
+	var activeSession *db.UserActiveSession
+	var breakRecord *db.SessionBreak
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		activeSession, err = s.findActiveSessionTx(tx, userID)
+		if err != nil {
+			return lookupError(err, ErrNoActiveSession, "active session")
+		}
+
+		if !activeSession.IsOnBreak || activeSession.CurrentBreakID == nil {
+			return ErrNotOnBreak
+		}
+
+		breakRecord, err = s.endBreakTx(tx, activeSession, time.Now())
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("no active session found: %w", err)
+		return nil, err
 	}
+	s.publishBreak(events.SessionBreakEnded, activeSession, breakRecord)
+	return breakRecord, nil
+}
 
-	if !activeSession.IsOnBreak || activeSession.CurrentBreakID == nil {
-		return nil, errors.New("not currently on break")
-	}
+// SwitchProject switches to a different project within the same company.
+// Finishing the current session and starting the new one happen in a single
+// transaction, so a failed start leaves the current session running.
+func (s *TimeSessionService) SwitchProject(userID string, newProjectID uint) (*db.TimeSession, error) {
+	// Backwards-compat wrapper.
+	return s.SwitchProjectCtx(context.Background(), userID, newProjectID)
+}
 
-	// Get break record
-	var breakRecord db.SessionBreak
-	if err := s.breakRepo.FindByID(*activeSession.CurrentBreakID, &breakRecord); err != nil {
-		return nil, fmt.Errorf("break record not found: %w", err)
+// SwitchProjectCtx is the request-scoped variant.
+func (s *TimeSessionService) SwitchProjectCtx(ctx context.Context, userID string, newProjectID uint) (*db.TimeSession, error) {
+	// Membership is checked against project-core outside the transaction; the
+	// company it was checked for must still be the running session's one below.
+	current, err := s.GetActiveSession(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyProjectAccessCtx(ctx, newProjectID, current.CompanyID, userID); err != nil {
+		return nil, err
 	}
 
-	// End the break
-	now := time.Now()
-	breakRecord.EndTime = &now
-	breakRecord.IsActive = false
-	breakRecord.DurationMinutes = s.calculateBreakDuration(&breakRecord)
+	var newSession *db.TimeSession
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
 
-	if err := s.breakRepo.Update(&breakRecord); err != nil {
-		return nil, fmt.Errorf("failed to update break record: %w", err)
-	}
+		currentSession, err := s.finishSessionTx(tx, userID, now, nil)
+		if err != nil {
+			return fmt.Errorf("failed to finish current session: %w", err)
+		}
+		if currentSession.CompanyID != current.CompanyID {
+			return ErrCompanyMismatch
+		}
 
-	// Update active session
-	activeSession.IsOnBreak = false
-	activeSession.CurrentBreakID = nil
-	activeSession.LastActivityAt = time.Now()
-	activeSession.UpdatedAt = time.Now()
+		// The new project's assignment sets the rate. The finished session's
+		// rate was accepted for its own project and doesn't carry over.
+		rate, err := s.resolveRateTx(tx, newProjectID, currentSession.CompanyID, userID, nil, false, now)
+		if err != nil {
+			return err
+		}
 
-	if err := s.activeSessionRepo.Update(activeSession); err != nil {
-		return nil, fmt.Errorf("failed to update active session: %w", err)
+		// Start new session with the same company
+		newSession, err = s.startSessionTx(tx, newProjectID, currentSession.CompanyID, userID, rate, now)
+		if err != nil {
+			return fmt.Errorf("failed to start new session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	s.publishSession(events.SessionProjectSwitched, newSession)
+	return newSession, nil
+}
 
-	return &breakRecord, nil
+// SwitchCompany switches to a different company (ends current session, starts new one)
+func (s *TimeSessionService) SwitchCompany(userID, newCompanyID string, newProjectID uint, hourlyRate *money.Amount) (*db.TimeSession, error) {
+	// Backwards-compat wrapper.
+	return s.SwitchCompanyCtx(context.Background(), userID, newCompanyID, newProjectID, hourlyRate)
 }
 
-// SwitchProject switches to a different project within the same company
-func (s *TimeSessionService) SwitchProject(userID string, newProjectID uint) (*db.TimeSession, error) {
-	// Get current active session
-	activeSession, err := s.GetActiveSession(userID)
-	if err != nil {
-		return nil, fmt.Errorf("no active session found: %w", err)
+// SwitchCompanyCtx is the request-scoped variant.
+func (s *TimeSessionService) SwitchCompanyCtx(ctx context.Context, userID, newCompanyID string, newProjectID uint, hourlyRate *money.Amount) (*db.TimeSession, error) {
+	if err := s.verifyProjectAccessCtx(ctx, newProjectID, newCompanyID, userID); err != nil {
+		return nil, err
 	}
-
-	// End current session
-	currentSession, err := s.FinishWorkSession(userID)
+	rateManager, err := s.rateManagerCtx(ctx, newProjectID, userID, hourlyRate)
 	if err != nil {
-		return nil, fmt.Errorf("failed to finish current session: %w", err)
+		return nil, err
 	}
 
-	// Start new session with the same company
-	newSession, err := s.StartWorkSession(newProjectID, activeSession.CompanyID, userID, currentSession.HourlyRate)
-	if err != nil {
-		return nil, fmt.Errorf("failed to start new session: %w", err)
-	}
+	var newSession *db.TimeSession
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
 
-	return newSession, nil
-}
+		// End current session if exists
+		if _, err := s.findActiveSessionTx(tx, userID); err == nil {
+			if _, err := s.finishSessionTx(tx, userID, now, nil); err != nil {
+				return fmt.Errorf("failed to finish current session: %w", err)
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to check active session: %w", err)
+		}
 
-// SwitchCompany switches to a different company (ends current session, starts new one)
-func (s *TimeSessionService) SwitchCompany(userID, newCompanyID string, newProjectID uint, hourlyRate *float64) (*db.TimeSession, error) {
-	// End current session if exists
-	if hasActive, _ := s.HasActiveSession(userID); hasActive {
-		if _, err := s.FinishWorkSession(userID); err != nil {
-			return nil, fmt.Errorf("failed to finish current session: %w", err)
+		rate, err := s.resolveRateTx(tx, newProjectID, newCompanyID, userID, hourlyRate, rateManager, now)
+		if err != nil {
+			return err
 		}
-	}
 
-	// Start new session with new company
-	newSession, err := s.StartWorkSession(newProjectID, newCompanyID, userID, hourlyRate)
+		// Start new session with new company
+		newSession, err = s.startSessionTx(tx, newProjectID, newCompanyID, userID, rate, now)
+		if err != nil {
+			return fmt.Errorf("failed to start new session: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to start new session: %w", err)
+		return nil, err
 	}
-
+	s.publishSession(events.SessionCompanySwitched, newSession)
 	return newSession, nil
 }
 
@@ -253,7 +280,7 @@ func (s *TimeSessionService) GetActiveSession(userID string) (*db.UserActiveSess
 		return nil, fmt.Errorf("query active session: %w", err)
 	}
 	if len(sessions) == 0 {
-		return nil, fmt.Errorf("no active session found for user")
+		return nil, ErrNoActiveSession
 	}
 	active := sessions[0]
 	return &active, nil
@@ -288,21 +315,29 @@ func (s *TimeSessionService) GetUserSessionHistory(userID string, startDate, end
 	if err := s.sessionRepo.FindWhere(&sessions, query, args...); err != nil {
 		return nil, fmt.Errorf("failed to retrieve session history: %w", err)
 	}
+	if err := s.fillActiveSessionTimes(sessions); err != nil {
+		return nil, err
+	}
 
 	return sessions, nil
 }
 
-// GetProjectSessions gets all sessions for a specific project
-func (s *TimeSessionService) GetProjectSessions(projectID uint) ([]db.TimeSession, error) {
-	var sessions []db.TimeSession
-	if err := s.sessionRepo.FindWhere(&sessions, "project_id = ?", projectID); err != nil {
-		return nil, fmt.Errorf("failed to retrieve project sessions: %w", err)
-	}
-	return sessions, nil
+// GetProjectSessions gets the sessions of a project visible to userID
+func (s *TimeSessionService) GetProjectSessions(projectID uint, userID string) (*ProjectSessionsView, error) {
+	// Backwards-compat wrapper.
+	return s.GetProjectSessionsCtx(context.Background(), projectID, userID)
 }
 
 // GenerateUserTimeReport generates a time report for a user
 func (s *TimeSessionService) GenerateUserTimeReport(userID string, projectID uint, startDate, endDate time.Time) (*db.UserTimeReport, error) {
+	// Backwards-compat wrapper.
+	return s.GenerateUserTimeReportCtx(context.Background(), userID, projectID, startDate, endDate, fx.Target{})
+}
+
+// GenerateUserTimeReportCtx generates a time report for a user, with the cost
+// broken down by currency and, given a target, converted to it at the
+// exchange rates known on target.AsOf.
+func (s *TimeSessionService) GenerateUserTimeReportCtx(ctx context.Context, userID string, projectID uint, startDate, endDate time.Time, target fx.Target) (*db.UserTimeReport, error) {
 	sessions, err := s.GetUserSessionHistory(userID, &startDate, &endDate)
 	if err != nil {
 		return nil, err
@@ -319,6 +354,11 @@ func (s *TimeSessionService) GenerateUserTimeReport(userID string, projectID uin
 		sessions = filteredSessions
 	}
 
+	breaksBySession, err := s.loadBreaks(sessions)
+	if err != nil {
+		return nil, err
+	}
+
 	report := &db.UserTimeReport{
 		UserID:       userID,
 		ProjectID:    projectID,
@@ -327,14 +367,22 @@ func (s *TimeSessionService) GenerateUserTimeReport(userID string, projectID uin
 		BreakMinutes: 0,
 	}
 
-	for _, session := range sessions {
-		duration := s.calculateSessionDuration(&session)
-		hours := float64(duration) / 60.0
+	now := time.Now()
+	netMinutes := 0
+	byCurrency := make(fx.Totals)
+	for i := range sessions {
+		session := &sessions[i]
+		times := session.Times(breaksBySession[session.ID], now)
 
 		if session.SessionType == db.SessionTypeWork {
-			report.TotalHours += hours
+			report.TotalHours += float64(times.GrossMinutes) / 60.0
+			report.BreakMinutes += times.BreakMinutes
+			netMinutes += times.NetMinutes
+			byCurrency.AddSession(session, session.CostFor(times.NetMinutes), 0)
 		} else {
-			report.BreakMinutes += duration
+			// Legacy break-typed sessions count entirely as break time.
+			report.TotalHours += float64(times.GrossMinutes) / 60.0
+			report.BreakMinutes += times.GrossMinutes
 		}
 
 		if session.CreatedAt.After(report.LastSession) {
@@ -342,7 +390,16 @@ func (s *TimeSessionService) GenerateUserTimeReport(userID string, projectID uin
 		}
 	}
 
-	report.ProductiveHours = report.TotalHours - (float64(report.BreakMinutes) / 60.0)
+	report.ProductiveHours = float64(netMinutes) / 60.0
+
+	summary, err := fx.Summarize(s.db.WithContext(ctx), byCurrency, target)
+	if err != nil {
+		return nil, err
+	}
+	report.TotalCost = summary.TotalCost
+	report.Currency = summary.Currency
+	report.ByCurrency = summary.ByCurrency
+	report.Conversions = summary.Conversions
 
 	// Calculate average daily hours
 	days := endDate.Sub(startDate).Hours() / 24
@@ -355,22 +412,203 @@ func (s *TimeSessionService) GenerateUserTimeReport(userID string, projectID uin
 
 // Helper methods
 
-// calculateSessionDuration calculates session duration in minutes
-func (s *TimeSessionService) calculateSessionDuration(session *db.TimeSession) int {
-	if session.EndTime == nil {
-		return int(time.Since(session.StartTime).Minutes())
+// findActiveSessionTx loads and row-locks the user's active session record
+// inside tx, so concurrent finish/break/switch requests for the same user are
+// serialized. Returns gorm.ErrRecordNotFound when the user has none.
+func (s *TimeSessionService) findActiveSessionTx(tx *gorm.DB, userID string) (*db.UserActiveSession, error) {
+	var active db.UserActiveSession
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).First(&active).Error; err != nil {
+		return nil, err
+	}
+	return &active, nil
+}
+
+// startSessionTx creates the TimeSession and its UserActiveSession record inside
+// tx. The rate comes from resolveRateTx. Concurrent starts are settled by the
+// UserActiveSession primary key, not by lockUserTimeline.
+func (s *TimeSessionService) startSessionTx(tx *gorm.DB, projectID uint, companyID, userID string, rate sessionRate, now time.Time) (*db.TimeSession, error) {
+	// Check if user already has an active session
+	if _, err := s.findActiveSessionTx(tx, userID); err == nil {
+		return nil, ErrActiveSessionExists
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to check active session: %w", err)
+	}
+
+	// Validate project exists
+	var project db.ProfessionalProject
+	if err := tx.First(&project, projectID).Error; err != nil {
+		return nil, lookupError(err, ErrProjectNotFound, "project")
+	}
+
+	session := &db.TimeSession{
+		ProjectID:   projectID,
+		UserID:      userID,
+		CompanyID:   companyID,
+		StartTime:   now,
+		SessionType: db.SessionTypeWork,
+		IsActive:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	rate.apply(session)
+
+	if err := tx.Omit(clause.Associations).Create(session).Error; err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	activeSession := &db.UserActiveSession{
+		UserID:         userID,
+		SessionID:      session.ID,
+		CompanyID:      companyID,
+		ProjectID:      projectID,
+		StartedAt:      session.StartTime,
+		LastActivityAt: session.StartTime,
+		IsOnBreak:      false,
+		UpdatedAt:      now,
+	}
+
+	// A concurrent start that passed the check above loses the race here on
+	// the user_id primary key; the whole transaction is rolled back.
+	if err := tx.Omit(clause.Associations).Create(activeSession).Error; err != nil {
+		if isUniqueViolation(err) {
+			return nil, ErrActiveSessionExists
+		}
+		return nil, fmt.Errorf("failed to create active session record: %w", err)
+	}
+
+	return session, nil
+}
+
+// finishSessionTx closes the user's active session at now (ending any open break)
+// and removes the UserActiveSession record inside tx. reason is stored as the
+// session's EndReason and is nil when the user finished the session themselves.
+func (s *TimeSessionService) finishSessionTx(tx *gorm.DB, userID string, now time.Time, reason *string) (*db.TimeSession, error) {
+	activeSession, err := s.findActiveSessionTx(tx, userID)
+	if err != nil {
+		return nil, lookupError(err, ErrNoActiveSession, "active session")
+	}
+
+	// End any active break first
+	if activeSession.IsOnBreak && activeSession.CurrentBreakID != nil {
+		if _, err := s.endBreakTx(tx, activeSession, now); err != nil {
+			return nil, fmt.Errorf("failed to end active break: %w", err)
+		}
+	}
+
+	var session db.TimeSession
+	if err := tx.First(&session, activeSession.SessionID).Error; err != nil {
+		return nil, fmt.Errorf("session not found: %w", err)
+	}
+
+	var breaks []db.SessionBreak
+	if err := tx.Where("session_id = ?", session.ID).Find(&breaks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load session breaks: %w", err)
+	}
+
+	session.EndTime = &now
+	session.IsActive = false
+	session.EndReason = reason
+	applySessionTimes(&session, breaks, now)
+	session.UpdatedAt = now
+
+	if err := tx.Omit(clause.Associations).Save(&session).Error; err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	if err := tx.Delete(activeSession).Error; err != nil {
+		return nil, fmt.Errorf("failed to remove active session record: %w", err)
+	}
+
+	// The session now counts towards its project and assignment.
+	if err := totals.Add(tx, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// endBreakTx closes the current break of activeSession and marks the user as
+// working again inside tx.
+func (s *TimeSessionService) endBreakTx(tx *gorm.DB, activeSession *db.UserActiveSession, now time.Time) (*db.SessionBreak, error) {
+	var breakRecord db.SessionBreak
+	if err := tx.First(&breakRecord, *activeSession.CurrentBreakID).Error; err != nil {
+		return nil, fmt.Errorf("break record not found: %w", err)
+	}
+
+	breakRecord.EndTime = &now
+	breakRecord.IsActive = false
+	breakRecord.DurationMinutes = s.calculateBreakDuration(&breakRecord)
+
+	if err := tx.Omit(clause.Associations).Save(&breakRecord).Error; err != nil {
+		return nil, fmt.Errorf("failed to update break record: %w", err)
+	}
+
+	activeSession.IsOnBreak = false
+	activeSession.CurrentBreakID = nil
+	activeSession.LastActivityAt = now
+	activeSession.UpdatedAt = now
+
+	if err := tx.Omit(clause.Associations).Save(activeSession).Error; err != nil {
+		return nil, fmt.Errorf("failed to update active session: %w", err)
+	}
+
+	return &breakRecord, nil
+}
+
+// applySessionTimes stores the gross/break/net breakdown and the net cost on session
+func applySessionTimes(session *db.TimeSession, breaks []db.SessionBreak, now time.Time) {
+	times := session.Times(breaks, now)
+	session.DurationMinutes = times.GrossMinutes
+	session.BreakMinutes = times.BreakMinutes
+	session.NetMinutes = times.NetMinutes
+	session.SessionCost = session.CostFor(times.NetMinutes)
+	session.SessionRevenue = session.RevenueFor(times.NetMinutes)
+}
+
+// loadBreaks fetches the breaks of the given sessions, grouped by session ID
+func (s *TimeSessionService) loadBreaks(sessions []db.TimeSession) (map[uint][]db.SessionBreak, error) {
+	bySession := make(map[uint][]db.SessionBreak, len(sessions))
+	if len(sessions) == 0 {
+		return bySession, nil
+	}
+	ids := make([]uint, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
 	}
-	return int(session.EndTime.Sub(session.StartTime).Minutes())
+	var breaks []db.SessionBreak
+	if err := s.breakRepo.FindWhere(&breaks, "session_id IN ?", ids); err != nil {
+		return nil, fmt.Errorf("failed to load session breaks: %w", err)
+	}
+	for _, b := range breaks {
+		bySession[b.SessionID] = append(bySession[b.SessionID], b)
+	}
+	return bySession, nil
 }
 
-// calculateSessionCost calculates session cost
-func (s *TimeSessionService) calculateSessionCost(session *db.TimeSession) float64 {
-	if session.HourlyRate == nil {
-		return 0
+// fillActiveSessionTimes computes live durations for sessions that are still
+// running; finished sessions already carry their stored breakdown.
+func (s *TimeSessionService) fillActiveSessionTimes(sessions []db.TimeSession) error {
+	var active []db.TimeSession
+	for _, session := range sessions {
+		if session.IsActive {
+			active = append(active, session)
+		}
 	}
-	duration := s.calculateSessionDuration(session)
-	hours := float64(duration) / 60.0
-	return hours * (*session.HourlyRate)
+	if len(active) == 0 {
+		return nil
+	}
+	breaksBySession, err := s.loadBreaks(active)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range sessions {
+		if sessions[i].IsActive {
+			applySessionTimes(&sessions[i], breaksBySession[sessions[i].ID], now)
+		}
+	}
+	return nil
 }
 
 // calculateBreakDuration calculates break duration in minutes
@@ -381,6 +619,12 @@ func (s *TimeSessionService) calculateBreakDuration(breakRecord *db.SessionBreak
 	return int(breakRecord.EndTime.Sub(breakRecord.StartTime).Minutes())
 }
 
+// isUniqueViolation reports whether err is a PostgreSQL unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
 // isValidBreakType validates break type
 func (s *TimeSessionService) isValidBreakType(breakType string) bool {
 	validTypes := []string{db.BreakTypeShort, db.BreakTypeLunch, db.BreakTypeBRB}
@@ -0,0 +1,111 @@
+package totals
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"github.com/JorgeSaicoski/professional-tracker/internal/money"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// owner is the project, or the assignment of it, a set of totals belongs to
+type owner struct {
+	projectID    uint
+	assignmentID uint // 0 for the project as a whole
+}
+
+// moneyByCurrency is cost and revenue kept apart per currency
+type moneyByCurrency map[string]*db.CurrencyTotals
+
+// addSession counts cost in the session's currency and revenue in its
+// billable currency, negated for sign -1
+func (m moneyByCurrency) addSession(session *db.TimeSession, cost, revenue money.Amount, sign int) {
+	if cost != 0 {
+		m.line(session.Currency).TotalCost += money.Amount(sign) * cost
+	}
+	if revenue != 0 {
+		m.line(session.BillableCurrency).Revenue += money.Amount(sign) * revenue
+	}
+}
+
+func (m moneyByCurrency) line(currency string) *db.CurrencyTotals {
+	if currency == "" {
+		currency = db.DefaultCurrency
+	}
+	line, ok := m[currency]
+	if !ok {
+		line = &db.CurrencyTotals{Currency: currency}
+		m[currency] = line
+	}
+	return line
+}
+
+func (m moneyByCurrency) isZero() bool {
+	for _, line := range m {
+		if line.TotalCost != 0 || line.Revenue != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// storeCurrencyTotals adds m to the currency totals of o, or replaces them
+// with m, and returns what the scalar totals of o become: cost and revenue in
+// their currency when all of o's money is in one currency, zero with an
+// empty currency when it is in none or several.
+func storeCurrencyTotals(tx *gorm.DB, o owner, m moneyByCurrency, replace bool, now time.Time) (db.CurrencyTotals, error) {
+	scope := tx.Where("project_id = ? AND assignment_id = ?", o.projectID, o.assignmentID)
+	if replace {
+		if err := scope.Delete(&db.ProjectCurrencyTotals{}).Error; err != nil {
+			return db.CurrencyTotals{}, fmt.Errorf("failed to clear currency totals: %w", err)
+		}
+	}
+
+	currencies := make([]string, 0, len(m))
+	for currency := range m {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		line := m[currency]
+		if line.TotalCost == 0 && line.Revenue == 0 {
+			continue
+		}
+		row := &db.ProjectCurrencyTotals{
+			ProjectID:    o.projectID,
+			AssignmentID: o.assignmentID,
+			Currency:     currency,
+			TotalCost:    line.TotalCost,
+			TotalRevenue: line.Revenue,
+			UpdatedAt:    now,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "project_id"}, {Name: "assignment_id"}, {Name: "currency"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"total_cost_minor":    gorm.Expr("project_currency_totals.total_cost_minor + excluded.total_cost_minor"),
+				"total_revenue_minor": gorm.Expr("project_currency_totals.total_revenue_minor + excluded.total_revenue_minor"),
+				"updated_at":          now,
+			}),
+		}).Create(row).Error; err != nil {
+			return db.CurrencyTotals{}, fmt.Errorf("failed to update currency totals: %w", err)
+		}
+	}
+
+	if !replace {
+		if err := tx.Where("project_id = ? AND assignment_id = ? AND total_cost_minor = 0 AND total_revenue_minor = 0",
+			o.projectID, o.assignmentID).Delete(&db.ProjectCurrencyTotals{}).Error; err != nil {
+			return db.CurrencyTotals{}, fmt.Errorf("failed to clear currency totals: %w", err)
+		}
+	}
+	var rows []db.ProjectCurrencyTotals
+	if err := tx.Where("project_id = ? AND assignment_id = ?", o.projectID, o.assignmentID).Find(&rows).Error; err != nil {
+		return db.CurrencyTotals{}, fmt.Errorf("failed to load currency totals: %w", err)
+	}
+	if len(rows) != 1 {
+		return db.CurrencyTotals{}, nil
+	}
+	return db.CurrencyTotals{Currency: rows[0].Currency, TotalCost: rows[0].TotalCost, Revenue: rows[0].TotalRevenue}, nil
+}
@@ -0,0 +1,314 @@
+// Package totals keeps the calculated aggregate columns of professional
+// projects and their assignments in sync with their finished work sessions.
+// Session writes apply their difference with Add, Remove and Change; the
+// Recalculate functions rebuild totals from scratch.
+package totals
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/JorgeSaicoski/professional-tracker/internal/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RecalculateProject recomputes the hour, cost and revenue totals of a project
+// from its finished work sessions inside tx.
+// Running sessions are left out; they are folded in once they finish.
+func RecalculateProject(tx *gorm.DB, projectID uint) (*db.ProfessionalProject, error) {
+	var project db.ProfessionalProject
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&project, projectID).Error; err != nil {
+		return nil, fmt.Errorf("project not found: %w", err)
+	}
+
+	var sessions []db.TimeSession
+	if err := tx.Where("project_id = ? AND session_type = ? AND is_active = ?",
+		projectID, db.SessionTypeWork, false).Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load project sessions: %w", err)
+	}
+
+	breaksBySession, err := loadBreaks(tx, sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	grossMinutes, breakMinutes, netMinutes, billableMinutes := 0, 0, 0, 0
+	byCurrency := make(moneyByCurrency)
+	for i := range sessions {
+		session := &sessions[i]
+		times := session.Times(breaksBySession[session.ID], now)
+		grossMinutes += times.GrossMinutes
+		breakMinutes += times.BreakMinutes
+		netMinutes += times.NetMinutes
+		byCurrency.addSession(session, session.CostFor(times.NetMinutes), session.RevenueFor(times.NetMinutes), 1)
+		if !session.NonBillable {
+			billableMinutes += times.NetMinutes
+		}
+	}
+
+	money, err := storeCurrencyTotals(tx, owner{projectID: projectID}, byCurrency, true, now)
+	if err != nil {
+		return nil, err
+	}
+
+	project.TotalHours = float64(netMinutes) / 60.0
+	project.TotalGrossHours = float64(grossMinutes) / 60.0
+	project.TotalBreakHours = float64(breakMinutes) / 60.0
+	project.TotalBillableHours = float64(billableMinutes) / 60.0
+	project.TotalSalaryCost = money.TotalCost
+	project.TotalRevenue = money.Revenue
+	project.TotalsCurrency = money.Currency
+	project.UpdatedAt = now
+
+	if err := tx.Model(&project).Select(
+		"TotalHours", "TotalGrossHours", "TotalBreakHours", "TotalBillableHours",
+		"TotalSalaryCost", "TotalRevenue", "TotalsCurrency", "UpdatedAt",
+	).Updates(&project).Error; err != nil {
+		return nil, fmt.Errorf("failed to update project totals: %w", err)
+	}
+	return &project, nil
+}
+
+// RecalculateAssignment recomputes HoursDedicated, TotalCost and TotalRevenue
+// of an assignment from its finished work sessions inside tx.
+func RecalculateAssignment(tx *gorm.DB, assignmentID uint) (*db.ProjectAssignment, error) {
+	var assignment db.ProjectAssignment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&assignment, assignmentID).Error; err != nil {
+		return nil, fmt.Errorf("project assignment not found: %w", err)
+	}
+
+	var sessions []db.TimeSession
+	if err := tx.Where("project_assignment_id = ? AND session_type = ? AND is_active = ?",
+		assignmentID, db.SessionTypeWork, false).Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to load assignment sessions: %w", err)
+	}
+
+	breaksBySession, err := loadBreaks(tx, sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	netMinutes := 0
+	byCurrency := make(moneyByCurrency)
+	for i := range sessions {
+		session := &sessions[i]
+		times := session.Times(breaksBySession[session.ID], now)
+		netMinutes += times.NetMinutes
+		byCurrency.addSession(session, session.CostFor(times.NetMinutes), session.RevenueFor(times.NetMinutes), 1)
+	}
+
+	money, err := storeCurrencyTotals(tx, owner{projectID: assignment.ParentProjectID, assignmentID: assignmentID}, byCurrency, true, now)
+	if err != nil {
+		return nil, err
+	}
+
+	assignment.HoursDedicated = float64(netMinutes) / 60.0
+	assignment.TotalCost = money.TotalCost
+	assignment.TotalRevenue = money.Revenue
+	assignment.TotalsCurrency = money.Currency
+	assignment.UpdatedAt = now
+
+	if err := tx.Model(&assignment).Select(
+		"HoursDedicated", "TotalCost", "TotalRevenue", "TotalsCurrency", "UpdatedAt",
+	).Updates(&assignment).Error; err != nil {
+		return nil, fmt.Errorf("failed to update assignment totals: %w", err)
+	}
+	return &assignment, nil
+}
+
+// RecalculateAll rebuilds the totals of every project and its assignments,
+// one project per transaction, e.g. once after a migration added totals the
+// stored ones can't be converted to.
+func RecalculateAll(gdb *gorm.DB) error {
+	var projectIDs []uint
+	if err := gdb.Model(&db.ProfessionalProject{}).Order("id").Pluck("id", &projectIDs).Error; err != nil {
+		return fmt.Errorf("failed to list projects: %w", err)
+	}
+	for _, projectID := range projectIDs {
+		err := gdb.Transaction(func(tx *gorm.DB) error {
+			if _, err := RecalculateProject(tx, projectID); err != nil {
+				return err
+			}
+			var assignmentIDs []uint
+			if err := tx.Model(&db.ProjectAssignment{}).Where("parent_project_id = ?", projectID).
+				Order("id").Pluck("id", &assignmentIDs).Error; err != nil {
+				return fmt.Errorf("failed to list project assignments: %w", err)
+			}
+			for _, assignmentID := range assignmentIDs {
+				if _, err := RecalculateAssignment(tx, assignmentID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to recalculate totals of project %d: %w", projectID, err)
+		}
+	}
+	return nil
+}
+
+// Add folds finished sessions into the totals of their projects and
+// assignments, e.g. once a session finishes or is entered manually.
+func Add(tx *gorm.DB, sessions ...*db.TimeSession) error {
+	return Change(tx, nil, sessions)
+}
+
+// Remove takes deleted sessions out of the totals of their projects and
+// assignments.
+func Remove(tx *gorm.DB, sessions ...*db.TimeSession) error {
+	return Change(tx, sessions, nil)
+}
+
+// Change moves the totals from the sessions as they were stored (before) to
+// the sessions as they are now (after), both sides of a move included. Only
+// the stored durations, cost and revenue of those sessions are applied as
+// increments, so no other session is loaded; RecalculateProject and
+// RecalculateAssignment rebuild totals that drifted. Rows are updated in ID
+// order, projects first, so concurrent changes can't deadlock.
+func Change(tx *gorm.DB, before, after []*db.TimeSession) error {
+	deltas := make(map[owner]*delta)
+	collect := func(sessions []*db.TimeSession, sign int) {
+		for _, session := range sessions {
+			// Running sessions are folded in once they finish.
+			if session == nil || session.IsActive || session.SessionType != db.SessionTypeWork {
+				continue
+			}
+			deltaOf(deltas, owner{projectID: session.ProjectID}).add(session, sign)
+			if session.ProjectAssignmentID != nil {
+				deltaOf(deltas, owner{projectID: session.ProjectID, assignmentID: *session.ProjectAssignmentID}).add(session, sign)
+			}
+		}
+	}
+	collect(before, -1)
+	collect(after, 1)
+
+	now := time.Now()
+	for _, o := range sortedOwners(deltas) {
+		d := deltas[o]
+		if d.isZero() {
+			continue
+		}
+		if o.assignmentID == 0 {
+			if err := tx.Model(&db.ProfessionalProject{}).Where("id = ?", o.projectID).Updates(map[string]interface{}{
+				"total_hours":          gorm.Expr("total_hours + ?", hours(d.netMinutes)),
+				"total_gross_hours":    gorm.Expr("total_gross_hours + ?", hours(d.grossMinutes)),
+				"total_break_hours":    gorm.Expr("total_break_hours + ?", hours(d.breakMinutes)),
+				"total_billable_hours": gorm.Expr("total_billable_hours + ?", hours(d.billableMinutes)),
+				"updated_at":           now,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update project totals: %w", err)
+			}
+		} else {
+			if err := tx.Model(&db.ProjectAssignment{}).Where("id = ?", o.assignmentID).Updates(map[string]interface{}{
+				"hours_dedicated": gorm.Expr("hours_dedicated + ?", hours(d.netMinutes)),
+				"updated_at":      now,
+			}).Error; err != nil {
+				return fmt.Errorf("failed to update assignment totals: %w", err)
+			}
+		}
+		if d.money.isZero() {
+			continue
+		}
+
+		money, err := storeCurrencyTotals(tx, o, d.money, false, now)
+		if err != nil {
+			return err
+		}
+		if o.assignmentID == 0 {
+			err = tx.Model(&db.ProfessionalProject{}).Where("id = ?", o.projectID).Updates(map[string]interface{}{
+				"total_salary_cost_minor": int64(money.TotalCost),
+				"total_revenue_minor":     int64(money.Revenue),
+				"totals_currency":         money.Currency,
+			}).Error
+		} else {
+			err = tx.Model(&db.ProjectAssignment{}).Where("id = ?", o.assignmentID).Updates(map[string]interface{}{
+				"total_cost_minor":    int64(money.TotalCost),
+				"total_revenue_minor": int64(money.Revenue),
+				"totals_currency":     money.Currency,
+			}).Error
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update money totals: %w", err)
+		}
+	}
+	return nil
+}
+
+// delta is what a change adds to the totals of one project or assignment
+type delta struct {
+	grossMinutes, breakMinutes, netMinutes, billableMinutes int
+	money                                                   moneyByCurrency
+}
+
+// add counts the stored values of session once, negated for sign -1
+func (d *delta) add(session *db.TimeSession, sign int) {
+	d.grossMinutes += sign * session.DurationMinutes
+	d.breakMinutes += sign * session.BreakMinutes
+	d.netMinutes += sign * session.NetMinutes
+	if !session.NonBillable {
+		d.billableMinutes += sign * session.NetMinutes
+	}
+	d.money.addSession(session, session.SessionCost, session.SessionRevenue, sign)
+}
+
+func (d *delta) isZero() bool {
+	return d.grossMinutes == 0 && d.breakMinutes == 0 && d.netMinutes == 0 &&
+		d.billableMinutes == 0 && d.money.isZero()
+}
+
+func deltaOf(deltas map[owner]*delta, o owner) *delta {
+	d, ok := deltas[o]
+	if !ok {
+		d = &delta{money: make(moneyByCurrency)}
+		deltas[o] = d
+	}
+	return d
+}
+
+func hours(minutes int) float64 {
+	return float64(minutes) / 60.0
+}
+
+// sortedOwners returns projects by ID, then assignments by ID
+func sortedOwners[V any](owners map[owner]V) []owner {
+	sorted := make([]owner, 0, len(owners))
+	for o := range owners {
+		sorted = append(sorted, o)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if (a.assignmentID == 0) != (b.assignmentID == 0) {
+			return a.assignmentID == 0
+		}
+		if a.assignmentID != b.assignmentID {
+			return a.assignmentID < b.assignmentID
+		}
+		return a.projectID < b.projectID
+	})
+	return sorted
+}
+
+// loadBreaks fetches the breaks of sessions grouped by session ID
+func loadBreaks(tx *gorm.DB, sessions []db.TimeSession) (map[uint][]db.SessionBreak, error) {
+	bySession := make(map[uint][]db.SessionBreak, len(sessions))
+	if len(sessions) == 0 {
+		return bySession, nil
+	}
+	ids := make([]uint, 0, len(sessions))
+	for _, session := range sessions {
+		ids = append(ids, session.ID)
+	}
+	var breaks []db.SessionBreak
+	if err := tx.Where("session_id IN ?", ids).Find(&breaks).Error; err != nil {
+		return nil, fmt.Errorf("failed to load session breaks: %w", err)
+	}
+	for _, b := range breaks {
+		bySession[b.SessionID] = append(bySession[b.SessionID], b)
+	}
+	return bySession, nil
+}